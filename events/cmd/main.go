@@ -3,10 +3,11 @@ package main
 import (
 	"events/internal"
 	"log"
+	"os"
 )
 
 func main() {
-	if err := internal.Run(); err != nil {
+	if err := internal.Run(os.Args[1:]); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+)
+
+// adminTokenHeader is the header of requests given on behalf of an admin, e.g. to override rejection of events
+const adminTokenHeader = "X-Admin-Token"
+
+var (
+	errInvalidAdminToken  = errors.New("invalid admin token")
+	errAdminTokenRequired = errors.New("admin token required")
+)
+
+// isAdmin returns whether the request is given on behalf of an admin, i.e. with the token equal to ADMIN_TOKEN.
+// A request with a token is rejected if the token is invalid or no admin token is configured.
+func isAdmin(cfg config, r *http.Request) (bool, error) {
+	token := r.Header.Get(adminTokenHeader)
+	if token == "" {
+		return false, nil
+	}
+
+	if cfg.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.adminToken)) != 1 {
+		return false, errInvalidAdminToken
+	}
+
+	return true, nil
+}
+
+// requireAdmin responds 403 Forbidden unless the request is given on behalf of an admin, and returns whether it is
+func requireAdmin(cfg config, w http.ResponseWriter, r *http.Request) bool {
+	admin, err := isAdmin(cfg, r)
+	if err == nil && !admin {
+		err = errAdminTokenRequired
+	}
+	if err != nil {
+		respondError(w, http.StatusForbidden, err)
+		return false
+	}
+
+	return true
+}
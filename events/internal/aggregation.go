@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// incrementStatisticsSQL returns an SQL statement to add a change of a per-game row to the sums of the statistics table,
+// and to recalculate the averages from the sums, so a new event costs the same regardless of the number of games in the season.
+// Parameter placeholders are intended for the key columns in the given order, followed by:
+// the number of added per-game rows, i.e. 1 for the first event of a game and 0 otherwise
+// changes of the per-game values in the order of statisticsColumns
+func incrementStatisticsSQL(table table, keyColumns ...string) string {
+	var columns, values, conflict []string
+	for i, key := range keyColumns {
+		columns = append(columns, fmt.Sprintf(`"%s"`, key))
+		values = append(values, fmt.Sprintf("$%d", i+1))
+		if key != "league" {
+			conflict = append(conflict, fmt.Sprintf(`"%s"`, key))
+		}
+	}
+
+	games := len(keyColumns) + 1
+	columns = append(columns, `"games"`)
+	values = append(values, fmt.Sprintf("$%d", games))
+	updates := []string{`"games" = s."games" + EXCLUDED."games"`}
+
+	for i, c := range statisticsColumns {
+		sum := games + 1 + i
+		columns = append(columns, fmt.Sprintf(`"%s_sum"`, c), fmt.Sprintf(`"%s"`, c))
+		values = append(values, fmt.Sprintf("$%d", sum), fmt.Sprintf("CAST($%d::float8 / GREATEST($%d::int4, 1) AS float4)", sum, games))
+		updates = append(updates,
+			fmt.Sprintf(`"%[1]s_sum" = s."%[1]s_sum" + EXCLUDED."%[1]s_sum"`, c),
+			fmt.Sprintf(`"%[1]s" = CAST((s."%[1]s_sum" + EXCLUDED."%[1]s_sum") / GREATEST(s."games" + EXCLUDED."games", 1) AS float4)`, c),
+		)
+	}
+
+	return fmt.Sprintf(`INSERT INTO "%s" AS s (%s, "processed")
+VALUES (%s, false)
+ON CONFLICT ("league", %s) DO
+UPDATE SET
+	%s,
+	"processed" = false;`,
+		table, strings.Join(columns, ", "), strings.Join(values, ", "), strings.Join(conflict, ", "), strings.Join(updates, ",\n\t"),
+	)
+}
+
+// values returns the statistics as arguments in the order of statisticsColumns
+func (s Statistics) values() []any {
+	return []any{s.Points, s.Rebounds, s.Assists, s.Steals, s.Blocks, s.Fouls, s.Turnovers, s.MinutesPlayed, s.PlusMinus,
+		s.FieldGoalsMade, s.FieldGoalsAttempted, s.ThreePointersMade, s.FreeThrowsMade, s.FreeThrowsAttempted, s.OffensiveRebounds, s.Possessions}
+}
+
+// sub returns the change from the other statistics to these ones
+func (s Statistics) sub(other Statistics) Statistics {
+	return Statistics{
+		Points:        s.Points - other.Points,
+		Rebounds:      s.Rebounds - other.Rebounds,
+		Assists:       s.Assists - other.Assists,
+		Steals:        s.Steals - other.Steals,
+		Blocks:        s.Blocks - other.Blocks,
+		Fouls:         s.Fouls - other.Fouls,
+		Turnovers:     s.Turnovers - other.Turnovers,
+		MinutesPlayed: s.MinutesPlayed - other.MinutesPlayed,
+		PlusMinus:     s.PlusMinus - other.PlusMinus,
+
+		FieldGoalsMade:      s.FieldGoalsMade - other.FieldGoalsMade,
+		FieldGoalsAttempted: s.FieldGoalsAttempted - other.FieldGoalsAttempted,
+		ThreePointersMade:   s.ThreePointersMade - other.ThreePointersMade,
+		FreeThrowsMade:      s.FreeThrowsMade - other.FreeThrowsMade,
+		FreeThrowsAttempted: s.FreeThrowsAttempted - other.FreeThrowsAttempted,
+		OffensiveRebounds:   s.OffensiveRebounds - other.OffensiveRebounds,
+		Possessions:         s.Possessions - other.Possessions,
+	}
+}
+
+// selectPlayerGame returns per-game values of the player, and whether the per-game row exists
+func selectPlayerGame(ctx context.Context, tx *sql.Tx, stmts preparedStatements, league, player, gameDate, season string) (Statistics, bool, error) {
+	var s Statistics
+	err := tx.StmtContext(ctx, stmts.selectPlayerGame).QueryRowContext(ctx, league, player, gameDate, season).
+		Scan(&s.Points, &s.Rebounds, &s.Assists, &s.Steals, &s.Blocks, &s.Fouls, &s.Turnovers, &s.MinutesPlayed, &s.PlusMinus,
+			&s.FieldGoalsMade, &s.FieldGoalsAttempted, &s.ThreePointersMade, &s.FreeThrowsMade, &s.FreeThrowsAttempted, &s.OffensiveRebounds, &s.Possessions)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Statistics{}, false, nil
+	}
+	if err != nil {
+		return Statistics{}, false, fmt.Errorf("failed to select %q game of %q on %s: %w", league, player, gameDate, err)
+	}
+
+	return s, true, nil
+}
+
+// updatePlayerGame updates the per-game row of the player after the event. The value of a new counter event is added to the counter,
+// while minutes played are recalculated from 'enter' and 'exit' events of the game as they depend on the order of the events.
+func updatePlayerGame(ctx context.Context, tx *sql.Tx, stmts preparedStatements, e event, gameDate, season string, st seasonType) error {
+	stmt, args := stmts.forUpdatesByEventType[e.Event], []any{e.Player, e.Team, gameDate, season, st, e.League}
+	if increment, ok := stmts.forIncrementsByEventType[e.Event]; ok {
+		stmt, args = increment, append(args, e.value())
+	}
+
+	if err := txExec(ctx, tx, stmt, args...); err != nil {
+		return fmt.Errorf("failed to update %q table after %q event: %w", tablePlayersByGames, e, err)
+	}
+
+	// made shots and rebounds change attempts and offensive rebounds as well, which a 'miss' event has already recalculated
+	if e.Event == eventShot || e.Event == eventRebound {
+		if err := txExec(ctx, tx, stmts.forUpdatesByEventType[eventMiss], e.Player, e.Team, gameDate, season, st, e.League); err != nil {
+			return fmt.Errorf("failed to update attempts in %q table after %q event: %w", tablePlayersByGames, e, err)
+		}
+	}
+
+	return nil
+}
+
+// recalculatePlayerGame recalculates the per-game row of the player from all the events of the game, e.g. after a correction changing the event type
+func recalculatePlayerGame(ctx context.Context, tx *sql.Tx, stmts preparedStatements, e event, gameDate, season string, st seasonType) error {
+	if err := txExec(ctx, tx, stmts.deletePlayerGame, e.League, e.Player, gameDate, season); err != nil {
+		return fmt.Errorf("failed to delete %q game of %q on %s: %w", e.League, e.Player, gameDate, err)
+	}
+
+	for _, eventType := range slices.Sorted(maps.Keys(eventTypes)) {
+		if err := txExec(ctx, tx, stmts.forUpdatesByEventType[eventType], e.Player, e.Team, gameDate, season, st, e.League); err != nil {
+			return fmt.Errorf("failed to update %q table for %q events of %q on %s: %w", tablePlayersByGames, eventType, e.Player, gameDate, err)
+		}
+	}
+
+	return nil
+}
+
+// incrementStatistics adds the change of the per-game row of the player to the statistics of the player, the team and the stint
+func incrementStatistics(ctx context.Context, tx *sql.Tx, stmts preparedStatements, e event, season string, st seasonType, newGame bool, change Statistics) error {
+	games := 0
+	if newGame {
+		games = 1
+	}
+
+	for _, table := range statisticsTables {
+		args := append(statisticsArgsByTables[table](e, season, st), games)
+		if err := txExec(ctx, tx, stmts.forStatisticsByOperation[operationIncrementStatistics][table], append(args, change.values()...)...); err != nil {
+			return fmt.Errorf("failed to increment %q table: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// recalculateStatistics recalculates the statistics of the player, the team and the stint from all the per-game rows of the season
+func recalculateStatistics(ctx context.Context, tx *sql.Tx, stmts preparedStatements, e event, season string, st seasonType) error {
+	for _, table := range statisticsTables {
+		if err := txExec(ctx, tx, stmts.forStatisticsByOperation[operationUpdateStatistics][table], statisticsArgsByTables[table](e, season, st)...); err != nil {
+			return fmt.Errorf("failed to update %q table: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// recalculatePreviousGame recalculates the game of the player the corrected event was counted for before it was moved to the game on the date,
+// i.e. its per-game row if the date changed, the statistics of the season with the previous team, the score and the plus-minus of the game
+func recalculatePreviousGame(ctx context.Context, tx *sql.Tx, stmts preparedStatements, league league, e event, previous teamGame, gameDate string) error {
+	// the previous team is locked after the aggregates of the event, so a deadlock with its events is retried
+	if err := lockKeys(ctx, tx, stmts, aggregateKey(e.League, subjectTeam, previous.team)); err != nil {
+		return err
+	}
+
+	date, err := time.Parse(time.DateOnly, previous.gameDate)
+	if err != nil {
+		return fmt.Errorf("failed to parse game date %q: %w", previous.gameDate, err)
+	}
+	season := league.season(date)
+	seasonType := league.Calendar.seasonType(season, previous.gameDate)
+
+	e.Team = previous.team
+	// per-game rows are keyed by the date, so the row of the same date was recalculated with the event already
+	if previous.gameDate != gameDate {
+		if err := recalculatePlayerGame(ctx, tx, stmts, e, previous.gameDate, season, seasonType); err != nil {
+			return err
+		}
+	}
+	if err := recalculateStatistics(ctx, tx, stmts, e, season, seasonType); err != nil {
+		return err
+	}
+
+	if err := updateScore(ctx, tx, stmts, previous); err != nil {
+		return err
+	}
+	return markPlusMinusUnprocessed(ctx, tx, stmts, previous)
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+)
+
+// markSeasonCacheUnprocessedSQL is an SQL statement to mark the season cache of the player or the team unprocessed,
+// so it's copied to Redis after the transaction recalculating it is committed
+// Parameter placeholders are intended for:
+// $1: league
+// $2: subject, i.e. 'player' or 'team'
+// $3: identifier of the player or the team
+// $4: season
+// $5: season type
+const markSeasonCacheUnprocessedSQL = `INSERT INTO "season_caches" ("league", "subject", "id", "season", "season_type", "processed")
+VALUES ($1, $2, $3, $4, $5, false)
+ON CONFLICT ("league", "subject", "id", "season", "season_type") DO UPDATE SET "processed" = false`
+
+// SQL statements to claim the unprocessed season caches, and to mark a season cache processed with the same parameters as markSeasonCacheUnprocessedSQL
+const (
+	selectUnprocessedSeasonCachesSQL = `SELECT "league", "subject", "id", "season", "season_type" FROM "season_caches"
+WHERE NOT "processed" FOR UPDATE SKIP LOCKED`
+	updateSeasonCacheProcessedSQL = `UPDATE "season_caches" SET "processed" = true
+WHERE "league" = $1 AND "subject" = $2 AND "id" = $3 AND "season" = $4 AND "season_type" = $5`
+)
+
+// seasonCache is a cache of a player or a team for a season recalculated along with plus-minus:
+// lineups and tempo of a team, or on/off statistics of a player
+type seasonCache struct {
+	league     string
+	subject    subject
+	id         string
+	season     string
+	seasonType seasonType
+}
+
+// markSeasonCacheUnprocessed marks the season cache unprocessed in the transaction recalculating it
+func markSeasonCacheUnprocessed(ctx context.Context, tx *sql.Tx, c seasonCache) error {
+	if _, err := tx.ExecContext(ctx, markSeasonCacheUnprocessedSQL, c.league, c.subject, c.id, c.season, c.seasonType); err != nil {
+		return fmt.Errorf("failed to mark %s cache of %q in %s season %s unprocessed: %w", c.subject, c.id, c.league, c.season, err)
+	}
+
+	return nil
+}
+
+// updateSeasonCaches copies the season caches recalculated since the last update to Redis
+func updateSeasonCaches(ctx context.Context, db *sql.DB, rdb *redis.Client) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, selectUnprocessedSeasonCachesSQL)
+	if err != nil {
+		return fmt.Errorf("failed to query unprocessed season caches: %w", err)
+	}
+
+	// all the claimed rows are read before further statements are executed in the same transaction
+	var claimed []seasonCache
+	for rows.Next() {
+		var c seasonCache
+		if err = rows.Scan(&c.league, &c.subject, &c.id, &c.season, &c.seasonType); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan unprocessed season cache: %w", err)
+		}
+		claimed = append(claimed, c)
+	}
+	closeIt("rows", rows)
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to query unprocessed season caches: %w", err)
+	}
+
+	for _, c := range claimed {
+		if c.subject == subjectTeam {
+			if err = cacheLineups(ctx, tx, rdb, c); err != nil {
+				return err
+			}
+			if err = cacheTempo(ctx, tx, rdb, c); err != nil {
+				return err
+			}
+		} else if err = cacheOnOff(ctx, tx, rdb, c); err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, updateSeasonCacheProcessedSQL, c.league, c.subject, c.id, c.season, c.seasonType); err != nil {
+			return fmt.Errorf("failed to mark %s cache of %q in %s season %s processed: %w", c.subject, c.id, c.league, c.season, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if len(claimed) > 0 {
+		log.Println(fmt.Sprintf("Season caches of %d players and teams updated successfully", len(claimed)))
+	}
+
+	return nil
+}
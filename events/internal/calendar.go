@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+type seasonType string
+
+// phases of a season in their chronological order
+const (
+	seasonTypePreseason seasonType = "preseason"
+	seasonTypeRegular   seasonType = "regular"
+	seasonTypePlayIn    seasonType = "playin"
+	seasonTypePlayoffs  seasonType = "playoffs"
+)
+
+var seasonTypes = []seasonType{seasonTypePreseason, seasonTypeRegular, seasonTypePlayIn, seasonTypePlayoffs}
+
+// phases are start dates of season types in format "2006-01-02"
+type phases map[seasonType]string
+
+// seasonCalendar defines phases of every season given in the format of the league, e.g. "2006-07" or "2006"
+type seasonCalendar map[string]phases
+
+// nbaSeasonCalendar is used unless SEASON_CALENDAR environment variable points to a JSON file with another calendar
+var nbaSeasonCalendar = seasonCalendar{
+	"2023-24": {
+		seasonTypePreseason: "2023-10-05",
+		seasonTypeRegular:   "2023-10-24",
+		seasonTypePlayIn:    "2024-04-16",
+		seasonTypePlayoffs:  "2024-04-20",
+	},
+	"2024-25": {
+		seasonTypePreseason: "2024-10-04",
+		seasonTypeRegular:   "2024-10-22",
+		seasonTypePlayIn:    "2025-04-15",
+		seasonTypePlayoffs:  "2025-04-19",
+	},
+	"2025-26": {
+		seasonTypePreseason: "2025-10-02",
+		seasonTypeRegular:   "2025-10-21",
+		seasonTypePlayIn:    "2026-04-14",
+		seasonTypePlayoffs:  "2026-04-18",
+	},
+}
+
+// wnbaSeasonCalendar has no play-in tournament
+var wnbaSeasonCalendar = seasonCalendar{
+	"2024": {
+		seasonTypePreseason: "2024-05-03",
+		seasonTypeRegular:   "2024-05-14",
+		seasonTypePlayoffs:  "2024-09-22",
+	},
+	"2025": {
+		seasonTypePreseason: "2025-05-02",
+		seasonTypeRegular:   "2025-05-16",
+		seasonTypePlayoffs:  "2025-09-14",
+	},
+}
+
+// loadSeasonCalendar reads the calendar from the JSON file, e.g.
+//
+//	{"2024-25": {"preseason": "2024-10-04", "regular": "2024-10-22", "playin": "2025-04-15", "playoffs": "2025-04-19"}}
+func loadSeasonCalendar(path string) (seasonCalendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read season calendar: %w", err)
+	}
+
+	var calendar seasonCalendar
+	if err := json.Unmarshal(data, &calendar); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal season calendar: %w", err)
+	}
+
+	if err := calendar.validate(); err != nil {
+		return nil, fmt.Errorf("failed to validate season calendar: %w", err)
+	}
+
+	return calendar, nil
+}
+
+// validate checks that phases of every season are known and follow in their chronological order
+func (c seasonCalendar) validate() error {
+	for season, phases := range c {
+		for st := range phases {
+			if !isSeasonType(st) {
+				return fmt.Errorf("unknown season type %q in season %s", st, season)
+			}
+		}
+
+		var previous time.Time
+		for _, st := range seasonTypes {
+			start, ok := phases[st]
+			if !ok {
+				continue
+			}
+
+			date, err := time.Parse(time.DateOnly, start)
+			if err != nil {
+				return fmt.Errorf("invalid start date of %s in season %s: %w", st, season, err)
+			}
+
+			if !date.After(previous) {
+				return fmt.Errorf("%s of season %s starts not after the previous phase", st, season)
+			}
+			previous = date
+		}
+	}
+
+	return nil
+}
+
+// seasonType returns the phase of the season the game date in format "2006-01-02" belongs to.
+// Games of seasons missing in the calendar and games before the first phase are regular season games.
+func (c seasonCalendar) seasonType(season, gameDate string) seasonType {
+	result := seasonTypeRegular
+	for _, st := range seasonTypes {
+		// dates in the same format are compared lexicographically
+		if start, ok := c[season][st]; ok && start <= gameDate {
+			result = st
+		}
+	}
+	return result
+}
+
+func isSeasonType(st seasonType) bool {
+	for _, known := range seasonTypes {
+		if st == known {
+			return true
+		}
+	}
+	return false
+}
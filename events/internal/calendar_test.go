@@ -0,0 +1,39 @@
+package internal
+
+import "testing"
+
+func TestSeasonCalendar_SeasonType(t *testing.T) {
+	for _, tc := range []struct {
+		season, gameDate string
+		expected         seasonType
+	}{
+		{"2024-25", "2024-10-01", seasonTypeRegular}, // before the first phase
+		{"2024-25", "2024-10-04", seasonTypePreseason},
+		{"2024-25", "2024-10-21", seasonTypePreseason},
+		{"2024-25", "2024-10-22", seasonTypeRegular},
+		{"2024-25", "2025-04-13", seasonTypeRegular},
+		{"2024-25", "2025-04-16", seasonTypePlayIn},
+		{"2024-25", "2025-05-23", seasonTypePlayoffs},
+		{"2019-20", "2020-08-20", seasonTypeRegular}, // missing in the calendar
+	} {
+		if actual := nbaSeasonCalendar.seasonType(tc.season, tc.gameDate); actual != tc.expected {
+			t.Errorf("seasonType(%s, %s): expected %q, got %q", tc.season, tc.gameDate, tc.expected, actual)
+		}
+	}
+}
+
+func TestSeasonCalendar_Validate(t *testing.T) {
+	if err := nbaSeasonCalendar.validate(); err != nil {
+		t.Errorf("default calendar is invalid: %v", err)
+	}
+
+	for _, calendar := range []seasonCalendar{
+		{"2024-25": {"finals": "2025-06-05"}},
+		{"2024-25": {seasonTypeRegular: "2024-10-32"}},
+		{"2024-25": {seasonTypeRegular: "2024-10-22", seasonTypePlayoffs: "2024-10-01"}},
+	} {
+		if err := calendar.validate(); err == nil {
+			t.Errorf("expected error for calendar %v", calendar)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"slices"
+	"time"
+)
+
+// lockKeySQL is an SQL statement to take a transaction-level advisory lock of the key given by $1,
+// so transactions updating aggregates of the same player or team are serialized
+const lockKeySQL = `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`
+
+// codes of Postgres errors of transactions to be retried
+const (
+	pqSerializationFailure pq.ErrorCode = "40001"
+	pqDeadlockDetected     pq.ErrorCode = "40P01"
+)
+
+// transaction retries are limited, and delayed growing from the initial delay
+const (
+	maxTransactionAttempts = 5
+	retryDelay             = 10 * time.Millisecond
+)
+
+// aggregateKey returns the key of the lock of aggregates of the player or the team in the league
+func aggregateKey(league string, subject subject, id string) string {
+	return fmt.Sprintf("%s:%s:%s", league, subject, id)
+}
+
+// lockAggregates takes advisory locks of aggregates of the player and the team until the end of the transaction.
+// The locks are taken in the order of their keys, so concurrent transactions don't deadlock.
+func lockAggregates(ctx context.Context, tx *sql.Tx, stmts preparedStatements, league, player, team string) error {
+	return lockKeys(ctx, tx, stmts, aggregateKey(league, subjectPlayer, player), aggregateKey(league, subjectTeam, team))
+}
+
+// lockKeys takes advisory locks of the keys until the end of the transaction in their order, as lockAggregates does
+func lockKeys(ctx context.Context, tx *sql.Tx, stmts preparedStatements, keys ...string) error {
+	keys = slices.Sorted(slices.Values(keys))
+
+	for _, key := range keys {
+		if err := txExec(ctx, tx, stmts.lockKey, key); err != nil {
+			return fmt.Errorf("failed to lock %q: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// retryable reports whether the transaction failed on a serialization failure or a deadlock, so it can be retried as a whole
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && (pqErr.Code == pqSerializationFailure || pqErr.Code == pqDeadlockDetected)
+}
+
+// retryTransaction runs the function executing a transaction until it succeeds, fails on a non-retryable error, or runs out of attempts
+func retryTransaction(ctx context.Context, name string, transaction func() error) error {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err := transaction()
+		if err == nil || !retryable(err) || attempt == maxTransactionAttempts {
+			return err
+		}
+		log.Println(fmt.Sprintf("Retrying %s after attempt %d failed: %v", name, attempt, err))
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
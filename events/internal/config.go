@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"time"
+)
+
+// config is the configuration of the events service read from environment variables
+type config struct {
+	rosterValidation     rosterValidation
+	leagues              leagues
+	retentionDays        int    // raw events of finalized games are purged after the number of days since the game date
+	archiveDir           string // directory of archives of purged raw events
+	adminToken           string // optional, admin requests are rejected unless specified
+	partitionMonthsAhead int
+	ingestionMode        ingestionMode
+	ingestionWorkers     int           // number of workers processing queued events in the asynchronous ingestion mode
+	latenessTolerance    time.Duration // how far events of a game may arrive out of order
+}
+
+// defaults of the retention policy
+const (
+	defaultRetentionDays = 7
+	defaultArchiveDir    = "archive"
+)
+
+func loadConfig() (config, error) {
+	var cfg config
+
+	cfg.rosterValidation = rosterValidation(os.Getenv("ROSTER_VALIDATION"))
+	switch cfg.rosterValidation {
+	case "":
+		cfg.rosterValidation = rosterValidationWarn
+	case rosterValidationStrict, rosterValidationWarn:
+	default:
+		return config{}, fmt.Errorf("invalid ROSTER_VALIDATION %q, %q or %q expected", cfg.rosterValidation, rosterValidationStrict, rosterValidationWarn)
+	}
+
+	cfg.leagues = maps.Clone(defaultLeagues)
+	if path := os.Getenv("LEAGUES_CONFIG"); path != "" {
+		leagues, err := loadLeagues(path)
+		if err != nil {
+			return config{}, fmt.Errorf("failed to load LEAGUES_CONFIG %q: %w", path, err)
+		}
+		cfg.leagues = leagues
+	}
+
+	// the calendar of the default league can still be given separately
+	if path := os.Getenv("SEASON_CALENDAR"); path != "" {
+		calendar, err := loadSeasonCalendar(path)
+		if err != nil {
+			return config{}, fmt.Errorf("failed to load SEASON_CALENDAR %q: %w", path, err)
+		}
+		league, ok := cfg.leagues[defaultLeague]
+		if !ok {
+			return config{}, fmt.Errorf("SEASON_CALENDAR %q is given without the default league %q", path, defaultLeague)
+		}
+		league.Calendar = calendar
+		cfg.leagues[defaultLeague] = league
+	}
+
+	cfg.retentionDays = defaultRetentionDays
+	if s := os.Getenv("RETENTION_DAYS"); s != "" {
+		days, err := strconv.Atoi(s)
+		if err != nil || days < 0 {
+			return config{}, fmt.Errorf("invalid RETENTION_DAYS %q, non-negative number of days expected", s)
+		}
+		cfg.retentionDays = days
+	}
+
+	cfg.archiveDir = defaultArchiveDir
+	if dir := os.Getenv("ARCHIVE_DIR"); dir != "" {
+		cfg.archiveDir = dir
+	}
+
+	cfg.adminToken = os.Getenv("ADMIN_TOKEN")
+
+	cfg.partitionMonthsAhead = defaultPartitionMonthsAhead
+	if s := os.Getenv("PARTITION_MONTHS_AHEAD"); s != "" {
+		months, err := strconv.Atoi(s)
+		if err != nil || months < 0 {
+			return config{}, fmt.Errorf("invalid PARTITION_MONTHS_AHEAD %q, non-negative number of months expected", s)
+		}
+		cfg.partitionMonthsAhead = months
+	}
+
+	cfg.ingestionMode = ingestionMode(os.Getenv("INGESTION_MODE"))
+	switch cfg.ingestionMode {
+	case "":
+		cfg.ingestionMode = ingestionModeSync
+	case ingestionModeSync, ingestionModeAsync:
+	default:
+		return config{}, fmt.Errorf("invalid INGESTION_MODE %q, %q or %q expected", cfg.ingestionMode, ingestionModeSync, ingestionModeAsync)
+	}
+
+	cfg.ingestionWorkers = defaultIngestionWorkers
+	if s := os.Getenv("INGESTION_WORKERS"); s != "" {
+		workers, err := strconv.Atoi(s)
+		if err != nil || workers < 1 {
+			return config{}, fmt.Errorf("invalid INGESTION_WORKERS %q, positive number of workers expected", s)
+		}
+		cfg.ingestionWorkers = workers
+	}
+
+	cfg.latenessTolerance = defaultLatenessTolerance
+	if s := os.Getenv("LATENESS_TOLERANCE"); s != "" {
+		tolerance, err := time.ParseDuration(s)
+		if err != nil || tolerance < 0 {
+			return config{}, fmt.Errorf("invalid LATENESS_TOLERANCE %q, non-negative duration expected, e.g. \"5m\"", s)
+		}
+		cfg.latenessTolerance = tolerance
+	}
+
+	return cfg, nil
+}
@@ -13,8 +13,25 @@ const (
 	columnBlocks    column = "blocks"
 	columnFouls     column = "fouls"
 	columnTurnovers column = "turnovers"
+	// columnMinutesPlayed is a column of minutes played calculated from 'enter' and 'exit' events
+	columnMinutesPlayed column = "minutes_played"
+	// columnPlusMinus is a column of the point differential of the team while the player is on the court, see updatePlusMinusSQL
+	columnPlusMinus column = "plus_minus"
+	// columns of made and attempted shots and offensive rebounds calculated from 'shot', 'miss' and 'rebound' events, see updateGameOnAttemptEventSQL
+	columnFieldGoalsMade      column = "field_goals_made"
+	columnFieldGoalsAttempted column = "field_goals_attempted"
+	columnThreePointersMade   column = "three_pointers_made"
+	columnFreeThrowsMade      column = "free_throws_made"
+	columnFreeThrowsAttempted column = "free_throws_attempted"
+	columnOffensiveRebounds   column = "offensive_rebounds"
+	// columnPossessions is a column of the share of the possessions of the team in the game by the minutes of the player, see updatePlayersPossessionsSQL
+	columnPossessions column = "possessions"
 )
 
+// statisticsColumns are per-game columns averaged by statistics tables, in the order of the fields of Statistics
+var statisticsColumns = []column{columnPoints, columnRebounds, columnAssists, columnSteals, columnBlocks, columnFouls, columnTurnovers, columnMinutesPlayed, columnPlusMinus,
+	columnFieldGoalsMade, columnFieldGoalsAttempted, columnThreePointersMade, columnFreeThrowsMade, columnFreeThrowsAttempted, columnOffensiveRebounds, columnPossessions}
+
 type table string
 
 const (
@@ -22,66 +39,11 @@ const (
 	tablePlayersByGames    table = "players_by_games"
 	tablePlayersStatistics table = "players_statistics"
 	tableTeamsStatistics   table = "teams_statistics"
+	// statistics of players per team, i.e. of stints of traded players
+	tablePlayersTeamsStatistics table = "players_teams_statistics"
 )
 
-var statisticsTables = []table{tablePlayersStatistics, tableTeamsStatistics}
-
-// SQL statements to create tables
-const (
-	createTableEventsSQL = `CREATE TABLE IF NOT EXISTS "public"."events" (
-"player" text NOT NULL,
-"team" text NOT NULL,
-"timestamp" timestamp NOT NULL,
-"event" text NOT NULL CHECK (event IN ('shot', 'rebound', 'assist', 'steal', 'block', 'foul', 'turnover', 'enter', 'exit')),
-"game_date" date NOT NULL,
-"value" int2 NOT NULL DEFAULT 0,
-PRIMARY KEY ("player", "timestamp")
-);`
-
-	createTablePlayersByGamesSQL = `CREATE TABLE IF NOT EXISTS "public"."players_by_games" (
-"player" text NOT NULL,
-"team" text NOT NULL,
-"game_date" date NOT NULL,
-"season" text NOT NULL,
-"points" int4 NOT NULL DEFAULT 0 CHECK (points >= 0),
-"rebounds" int4 NOT NULL DEFAULT 0 CHECK (rebounds >= 0),
-"assists" int4 NOT NULL DEFAULT 0 CHECK (assists >= 0),
-"steals" int4 NOT NULL DEFAULT 0 CHECK (steals >= 0),
-"blocks" int4 NOT NULL DEFAULT 0 CHECK (blocks >= 0),
-"fouls" int2 NOT NULL DEFAULT '0'::smallint CHECK ((fouls >= 0) AND (fouls <= 6)),
-"turnovers" int4 NOT NULL DEFAULT 0 CHECK (turnovers >= 0),
-"minutes_played" float4 NOT NULL DEFAULT 0 CHECK ((minutes_played >= (0.0)::double precision) AND (minutes_played <= (48.0)::double precision)),
-"entered" timestamp,
-PRIMARY KEY ("player","game_date"));`
-
-	createTablePlayersStatisticsSQL = `CREATE TABLE IF NOT EXISTS "public"."players_statistics" (
-"player" text NOT NULL,
-"season" text NOT NULL,
-"points" float4 NOT NULL DEFAULT 0 CHECK (points >= (0.0)::double precision),
-"rebounds" float4 NOT NULL DEFAULT 0 CHECK (rebounds >= (0.0)::double precision),
-"assists" float4 NOT NULL DEFAULT 0 CHECK (assists >= (0.0)::double precision),
-"steals" float4 NOT NULL DEFAULT 0 CHECK (steals >= (0.0)::double precision),
-"blocks" float4 NOT NULL DEFAULT 0 CHECK (blocks >= (0.0)::double precision),
-"fouls" float4 NOT NULL DEFAULT 0 CHECK ((fouls >= (0.0)::double precision) AND (fouls <= (6.0)::double precision)),
-"turnovers" float4 NOT NULL DEFAULT 0 CHECK (turnovers >= (0.0)::double precision),
-"minutes_played" float4 NOT NULL DEFAULT 0 CHECK ((minutes_played >= (0.0)::double precision) AND (minutes_played <= (48.0)::double precision)),
-"processed" bool NOT NULL DEFAULT false,
-PRIMARY KEY ("player","season"));`
-
-	createTableTeamsStatisticsSQL = `CREATE TABLE IF NOT EXISTS "public"."teams_statistics" (
-"team" text NOT NULL,
-"season" text NOT NULL,
-"points" float4 NOT NULL DEFAULT 0 CHECK (points >= (0.0)::double precision),
-"rebounds" float4 NOT NULL DEFAULT 0 CHECK (rebounds >= (0.0)::double precision),
-"assists" float4 NOT NULL DEFAULT 0 CHECK (assists >= (0.0)::double precision),
-"steals" float4 NOT NULL DEFAULT 0 CHECK (steals >= (0.0)::double precision),
-"blocks" float4 NOT NULL DEFAULT 0 CHECK (blocks >= (0.0)::double precision),
-"fouls" float4 NOT NULL DEFAULT 0 CHECK ((fouls >= (0.0)::double precision) AND (fouls <= (6.0)::double precision)),
-"turnovers" float4 NOT NULL DEFAULT 0 CHECK (turnovers >= (0.0)::double precision),
-"minutes_played" float4 NOT NULL DEFAULT 0 CHECK ((minutes_played >= (0.0)::double precision) AND (minutes_played <= (48.0)::double precision)),
-"processed" bool NOT NULL DEFAULT false,
-PRIMARY KEY ("team", "season"));`
-)
+var statisticsTables = []table{tablePlayersStatistics, tableTeamsStatistics, tablePlayersTeamsStatistics}
 
 // upsertEventSQL is an SQL statement to upsert event
 // Parameter placeholders are intended for:
@@ -90,23 +52,110 @@ PRIMARY KEY ("team", "season"));`
 // $3: timestamp
 // $4: event
 // $5: game date
-// $6: value -- 0 for enter and exit events; 1, 2, or 3 for shot events; 1 for other event types
+// $6: value -- 0 for enter and exit events; 1, 2, or 3 for shot and miss events; 1 for other event types
+// $7: home team, or NULL if not specified
+// $8: league
+// $9: whether the event is synthetic, i.e. recorded by the game lifecycle
+// $10: whether the rebound is offensive, false for other event types
+//
+// It returns whether the event corrects an existing event of the player at the timestamp, a synthetic one correcting only a synthetic one,
+// and the team and the game date of the corrected event, empty for a new event, as a correction may move the event to another game.
 const upsertEventSQL = `
-INSERT INTO "events" ("player", "team", "timestamp", "event", "game_date", "value") values ($1, $2, $3, $4, $5, $6)
-ON CONFLICT ("player", "timestamp") DO UPDATE SET "event" = EXCLUDED."event", "value" = EXCLUDED."value" 
+WITH "existing" AS (SELECT "team", "game_date" FROM "events" WHERE "player" = $1 AND "timestamp" = $3 AND "synthetic" = $9)
+INSERT INTO "events" ("player", "team", "timestamp", "event", "game_date", "value", "home_team", "league", "synthetic", "offensive") values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT ("player", "timestamp", "synthetic") DO UPDATE SET "team" = EXCLUDED."team", "event" = EXCLUDED."event", "game_date" = EXCLUDED."game_date", "value" = EXCLUDED."value", "home_team" = EXCLUDED."home_team", "league" = EXCLUDED."league", "offensive" = EXCLUDED."offensive" 
+RETURNING EXISTS (SELECT 1 FROM "existing"), COALESCE((SELECT "team" FROM "existing"), ''), COALESCE((SELECT to_char("game_date", 'YYYY-MM-DD') FROM "existing"), '')
 `
 
 type operation string
 
 const (
-	operationUpdateStatistics  operation = "update_statistics"
-	operationSelectUnprocessed operation = "select_unprocessed"
-	operationUpdateUnprocessed operation = "update_unprocessed"
+	operationUpdateStatistics operation = "update_statistics"
+	// operationIncrementStatistics adds a change of a per-game row to the sums of statistics, see incrementStatisticsSQL
+	operationIncrementStatistics operation = "increment_statistics"
+	operationSelectUnprocessed   operation = "select_unprocessed"
+	operationUpdateUnprocessed   operation = "update_unprocessed"
 )
 
 const (
-	updatePlayersStatisticsSQL = `INSERT INTO "players_statistics" ("player", "season", "points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played", "processed")
-SELECT "player", "season", 
+	updatePlayersStatisticsSQL = `INSERT INTO "players_statistics" ("league", "player", "season", "season_type", "points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played", "plus_minus", "field_goals_made", "field_goals_attempted", "three_pointers_made", "free_throws_made", "free_throws_attempted", "offensive_rebounds", "possessions", "games", "points_sum", "rebounds_sum", "assists_sum", "steals_sum", "blocks_sum", "fouls_sum", "turnovers_sum", "minutes_played_sum", "plus_minus_sum", "field_goals_made_sum", "field_goals_attempted_sum", "three_pointers_made_sum", "free_throws_made_sum", "free_throws_attempted_sum", "offensive_rebounds_sum", "possessions_sum", "processed")
+SELECT "league", "player", "season", "season_type", 
+	CAST(AVG("points") as float4), 
+	CAST(AVG("rebounds") as float4), 
+	CAST(AVG("assists") as float4), 
+	CAST(AVG("steals") as float4), 
+	CAST(AVG("blocks") as float4), 
+	CAST(AVG("fouls") as float4), 
+	CAST(AVG("turnovers") as float4), 
+	CAST(AVG("minutes_played") as float4),
+	CAST(AVG("plus_minus") as float4),
+	CAST(AVG("field_goals_made") as float4),
+	CAST(AVG("field_goals_attempted") as float4),
+	CAST(AVG("three_pointers_made") as float4),
+	CAST(AVG("free_throws_made") as float4),
+	CAST(AVG("free_throws_attempted") as float4),
+	CAST(AVG("offensive_rebounds") as float4),
+	CAST(AVG("possessions") as float4),
+	COUNT(*),
+	SUM("points"),
+	SUM("rebounds"),
+	SUM("assists"),
+	SUM("steals"),
+	SUM("blocks"),
+	SUM("fouls"),
+	SUM("turnovers"),
+	SUM("minutes_played"),
+	SUM("plus_minus"),
+	SUM("field_goals_made"),
+	SUM("field_goals_attempted"),
+	SUM("three_pointers_made"),
+	SUM("free_throws_made"),
+	SUM("free_throws_attempted"),
+	SUM("offensive_rebounds"),
+	SUM("possessions"),
+    false
+FROM "players_by_games_all"
+WHERE "player" = $1 AND "season" = $2 AND "season_type" = $3 AND "league" = $4 
+GROUP BY "league", "player", "season", "season_type" 
+ON CONFLICT ("league", "player", "season", "season_type") DO 
+UPDATE SET 
+	"points" = EXCLUDED."points", 
+	"rebounds" = EXCLUDED."rebounds", 
+	"assists" = EXCLUDED."assists", 
+	"steals" = EXCLUDED."steals", 
+	"blocks" = EXCLUDED."blocks", 
+	"fouls" = EXCLUDED."fouls", 
+	"turnovers" = EXCLUDED."turnovers", 
+	"minutes_played" = EXCLUDED."minutes_played",
+	"plus_minus" = EXCLUDED."plus_minus",
+	"field_goals_made" = EXCLUDED."field_goals_made",
+	"field_goals_attempted" = EXCLUDED."field_goals_attempted",
+	"three_pointers_made" = EXCLUDED."three_pointers_made",
+	"free_throws_made" = EXCLUDED."free_throws_made",
+	"free_throws_attempted" = EXCLUDED."free_throws_attempted",
+	"offensive_rebounds" = EXCLUDED."offensive_rebounds",
+	"possessions" = EXCLUDED."possessions",
+	"games" = EXCLUDED."games",
+	"points_sum" = EXCLUDED."points_sum",
+	"rebounds_sum" = EXCLUDED."rebounds_sum",
+	"assists_sum" = EXCLUDED."assists_sum",
+	"steals_sum" = EXCLUDED."steals_sum",
+	"blocks_sum" = EXCLUDED."blocks_sum",
+	"fouls_sum" = EXCLUDED."fouls_sum",
+	"turnovers_sum" = EXCLUDED."turnovers_sum",
+	"minutes_played_sum" = EXCLUDED."minutes_played_sum",
+	"plus_minus_sum" = EXCLUDED."plus_minus_sum",
+	"field_goals_made_sum" = EXCLUDED."field_goals_made_sum",
+	"field_goals_attempted_sum" = EXCLUDED."field_goals_attempted_sum",
+	"three_pointers_made_sum" = EXCLUDED."three_pointers_made_sum",
+	"free_throws_made_sum" = EXCLUDED."free_throws_made_sum",
+	"free_throws_attempted_sum" = EXCLUDED."free_throws_attempted_sum",
+	"offensive_rebounds_sum" = EXCLUDED."offensive_rebounds_sum",
+	"possessions_sum" = EXCLUDED."possessions_sum",
+	"processed" = EXCLUDED."processed";`
+
+	updateTeamsStatisticsSQL = `INSERT INTO "teams_statistics" ("league", "team", "season", "season_type", "points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played", "plus_minus", "field_goals_made", "field_goals_attempted", "three_pointers_made", "free_throws_made", "free_throws_attempted", "offensive_rebounds", "possessions", "games", "points_sum", "rebounds_sum", "assists_sum", "steals_sum", "blocks_sum", "fouls_sum", "turnovers_sum", "minutes_played_sum", "plus_minus_sum", "field_goals_made_sum", "field_goals_attempted_sum", "three_pointers_made_sum", "free_throws_made_sum", "free_throws_attempted_sum", "offensive_rebounds_sum", "possessions_sum", "processed")
+SELECT "league", "team", "season", "season_type", 
 	CAST(AVG("points") as float4), 
 	CAST(AVG("rebounds") as float4), 
 	CAST(AVG("assists") as float4), 
@@ -115,11 +164,36 @@ SELECT "player", "season",
 	CAST(AVG("fouls") as float4), 
 	CAST(AVG("turnovers") as float4), 
 	CAST(AVG("minutes_played") as float4),
+	CAST(AVG("plus_minus") as float4),
+	CAST(AVG("field_goals_made") as float4),
+	CAST(AVG("field_goals_attempted") as float4),
+	CAST(AVG("three_pointers_made") as float4),
+	CAST(AVG("free_throws_made") as float4),
+	CAST(AVG("free_throws_attempted") as float4),
+	CAST(AVG("offensive_rebounds") as float4),
+	CAST(AVG("possessions") as float4),
+	COUNT(*),
+	SUM("points"),
+	SUM("rebounds"),
+	SUM("assists"),
+	SUM("steals"),
+	SUM("blocks"),
+	SUM("fouls"),
+	SUM("turnovers"),
+	SUM("minutes_played"),
+	SUM("plus_minus"),
+	SUM("field_goals_made"),
+	SUM("field_goals_attempted"),
+	SUM("three_pointers_made"),
+	SUM("free_throws_made"),
+	SUM("free_throws_attempted"),
+	SUM("offensive_rebounds"),
+	SUM("possessions"),
     false
-FROM "players_by_games"
-WHERE "player" = $1 AND "season" = $2 
-GROUP BY "player", "season" 
-ON CONFLICT ("player", "season") DO 
+FROM "players_by_games_all"
+WHERE "team" = $1 AND "season" = $2 AND "season_type" = $3 AND "league" = $4 
+GROUP BY "league", "team", "season", "season_type" 
+ON CONFLICT ("league", "team", "season", "season_type") DO 
 UPDATE SET 
 	"points" = EXCLUDED."points", 
 	"rebounds" = EXCLUDED."rebounds", 
@@ -129,10 +203,35 @@ UPDATE SET
 	"fouls" = EXCLUDED."fouls", 
 	"turnovers" = EXCLUDED."turnovers", 
 	"minutes_played" = EXCLUDED."minutes_played",
+	"plus_minus" = EXCLUDED."plus_minus",
+	"field_goals_made" = EXCLUDED."field_goals_made",
+	"field_goals_attempted" = EXCLUDED."field_goals_attempted",
+	"three_pointers_made" = EXCLUDED."three_pointers_made",
+	"free_throws_made" = EXCLUDED."free_throws_made",
+	"free_throws_attempted" = EXCLUDED."free_throws_attempted",
+	"offensive_rebounds" = EXCLUDED."offensive_rebounds",
+	"possessions" = EXCLUDED."possessions",
+	"games" = EXCLUDED."games",
+	"points_sum" = EXCLUDED."points_sum",
+	"rebounds_sum" = EXCLUDED."rebounds_sum",
+	"assists_sum" = EXCLUDED."assists_sum",
+	"steals_sum" = EXCLUDED."steals_sum",
+	"blocks_sum" = EXCLUDED."blocks_sum",
+	"fouls_sum" = EXCLUDED."fouls_sum",
+	"turnovers_sum" = EXCLUDED."turnovers_sum",
+	"minutes_played_sum" = EXCLUDED."minutes_played_sum",
+	"plus_minus_sum" = EXCLUDED."plus_minus_sum",
+	"field_goals_made_sum" = EXCLUDED."field_goals_made_sum",
+	"field_goals_attempted_sum" = EXCLUDED."field_goals_attempted_sum",
+	"three_pointers_made_sum" = EXCLUDED."three_pointers_made_sum",
+	"free_throws_made_sum" = EXCLUDED."free_throws_made_sum",
+	"free_throws_attempted_sum" = EXCLUDED."free_throws_attempted_sum",
+	"offensive_rebounds_sum" = EXCLUDED."offensive_rebounds_sum",
+	"possessions_sum" = EXCLUDED."possessions_sum",
 	"processed" = EXCLUDED."processed";`
 
-	updateTeamsStatisticsSQL = `INSERT INTO "teams_statistics" ("team", "season", "points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played", "processed")
-SELECT "team", "season", 
+	updatePlayersTeamsStatisticsSQL = `INSERT INTO "players_teams_statistics" ("league", "player", "team", "season", "season_type", "points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played", "plus_minus", "field_goals_made", "field_goals_attempted", "three_pointers_made", "free_throws_made", "free_throws_attempted", "offensive_rebounds", "possessions", "games", "points_sum", "rebounds_sum", "assists_sum", "steals_sum", "blocks_sum", "fouls_sum", "turnovers_sum", "minutes_played_sum", "plus_minus_sum", "field_goals_made_sum", "field_goals_attempted_sum", "three_pointers_made_sum", "free_throws_made_sum", "free_throws_attempted_sum", "offensive_rebounds_sum", "possessions_sum", "processed")
+SELECT "league", "player", "team", "season", "season_type", 
 	CAST(AVG("points") as float4), 
 	CAST(AVG("rebounds") as float4), 
 	CAST(AVG("assists") as float4), 
@@ -141,11 +240,36 @@ SELECT "team", "season",
 	CAST(AVG("fouls") as float4), 
 	CAST(AVG("turnovers") as float4), 
 	CAST(AVG("minutes_played") as float4),
+	CAST(AVG("plus_minus") as float4),
+	CAST(AVG("field_goals_made") as float4),
+	CAST(AVG("field_goals_attempted") as float4),
+	CAST(AVG("three_pointers_made") as float4),
+	CAST(AVG("free_throws_made") as float4),
+	CAST(AVG("free_throws_attempted") as float4),
+	CAST(AVG("offensive_rebounds") as float4),
+	CAST(AVG("possessions") as float4),
+	COUNT(*),
+	SUM("points"),
+	SUM("rebounds"),
+	SUM("assists"),
+	SUM("steals"),
+	SUM("blocks"),
+	SUM("fouls"),
+	SUM("turnovers"),
+	SUM("minutes_played"),
+	SUM("plus_minus"),
+	SUM("field_goals_made"),
+	SUM("field_goals_attempted"),
+	SUM("three_pointers_made"),
+	SUM("free_throws_made"),
+	SUM("free_throws_attempted"),
+	SUM("offensive_rebounds"),
+	SUM("possessions"),
     false
-FROM "players_by_games"
-WHERE "team" = $1 AND "season" = $2 
-GROUP BY "team", "season" 
-ON CONFLICT ("team", "season") DO 
+FROM "players_by_games_all"
+WHERE "player" = $1 AND "team" = $2 AND "season" = $3 AND "season_type" = $4 AND "league" = $5 
+GROUP BY "league", "player", "team", "season", "season_type" 
+ON CONFLICT ("league", "player", "team", "season", "season_type") DO 
 UPDATE SET 
 	"points" = EXCLUDED."points", 
 	"rebounds" = EXCLUDED."rebounds", 
@@ -155,52 +279,136 @@ UPDATE SET
 	"fouls" = EXCLUDED."fouls", 
 	"turnovers" = EXCLUDED."turnovers", 
 	"minutes_played" = EXCLUDED."minutes_played",
+	"plus_minus" = EXCLUDED."plus_minus",
+	"field_goals_made" = EXCLUDED."field_goals_made",
+	"field_goals_attempted" = EXCLUDED."field_goals_attempted",
+	"three_pointers_made" = EXCLUDED."three_pointers_made",
+	"free_throws_made" = EXCLUDED."free_throws_made",
+	"free_throws_attempted" = EXCLUDED."free_throws_attempted",
+	"offensive_rebounds" = EXCLUDED."offensive_rebounds",
+	"possessions" = EXCLUDED."possessions",
+	"games" = EXCLUDED."games",
+	"points_sum" = EXCLUDED."points_sum",
+	"rebounds_sum" = EXCLUDED."rebounds_sum",
+	"assists_sum" = EXCLUDED."assists_sum",
+	"steals_sum" = EXCLUDED."steals_sum",
+	"blocks_sum" = EXCLUDED."blocks_sum",
+	"fouls_sum" = EXCLUDED."fouls_sum",
+	"turnovers_sum" = EXCLUDED."turnovers_sum",
+	"minutes_played_sum" = EXCLUDED."minutes_played_sum",
+	"plus_minus_sum" = EXCLUDED."plus_minus_sum",
+	"field_goals_made_sum" = EXCLUDED."field_goals_made_sum",
+	"field_goals_attempted_sum" = EXCLUDED."field_goals_attempted_sum",
+	"three_pointers_made_sum" = EXCLUDED."three_pointers_made_sum",
+	"free_throws_made_sum" = EXCLUDED."free_throws_made_sum",
+	"free_throws_attempted_sum" = EXCLUDED."free_throws_attempted_sum",
+	"offensive_rebounds_sum" = EXCLUDED."offensive_rebounds_sum",
+	"possessions_sum" = EXCLUDED."possessions_sum",
 	"processed" = EXCLUDED."processed";`
 
-	selectUnprocessedPlayersStatisticsSQL = `SELECT "player", "season", "points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played" FROM "players_statistics" WHERE "processed" = false`
-	selectUnprocessedTeamsStatisticsSQL   = `SELECT "team", "season", "points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played" FROM "teams_statistics" WHERE "processed" = false`
+	// statistics are provisional while any of their games is not settled, i.e. its watermark hasn't passed its end
+	selectUnprocessedPlayersStatisticsSQL = `SELECT "league", "player", "season", "season_type", "points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played", "plus_minus", "plus_minus_sum",
+	"field_goals_made", "field_goals_attempted", "three_pointers_made", "free_throws_made", "free_throws_attempted", "offensive_rebounds", "possessions",
+` + provisionalSQL + ` AND p."player" = s."player")
+FROM "players_statistics" s WHERE "processed" = false FOR UPDATE SKIP LOCKED`
+	selectUnprocessedTeamsStatisticsSQL = `SELECT "league", "team", "season", "season_type", "points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played", "plus_minus", "plus_minus_sum",
+	"field_goals_made", "field_goals_attempted", "three_pointers_made", "free_throws_made", "free_throws_attempted", "offensive_rebounds", "possessions",
+` + provisionalSQL + ` AND p."team" = s."team")
+FROM "teams_statistics" s WHERE "processed" = false FOR UPDATE SKIP LOCKED`
+	// the team is selected as a part of the player's key
+	selectUnprocessedPlayersTeamsStatisticsSQL = `SELECT "league", "player", "team", "season", "season_type", "points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played", "plus_minus", "plus_minus_sum",
+	"field_goals_made", "field_goals_attempted", "three_pointers_made", "free_throws_made", "free_throws_attempted", "offensive_rebounds", "possessions",
+` + provisionalSQL + ` AND p."player" = s."player" AND p."team" = s."team")
+FROM "players_teams_statistics" s WHERE "processed" = false FOR UPDATE SKIP LOCKED`
+
+	updateUnprocessedPlayersStatisticsSQL = `UPDATE "players_statistics" SET "processed" = true WHERE "league" = $1 AND "player" = $2 AND "season" = $3 AND "season_type" = $4;`
+	updateUnprocessedTeamsStatisticsSQL   = `UPDATE "teams_statistics" SET "processed" = true WHERE "league" = $1 AND "team" = $2 AND "season" = $3 AND "season_type" = $4;`
 
-	updateUnprocessedPlayersStatisticsSQL = `UPDATE "players_statistics" SET "processed" = true WHERE "player" = $1 AND "season" = $2;`
-	updateUnprocessedTeamsStatisticsSQL   = `UPDATE "teams_statistics" SET "processed" = true WHERE "team" = $1 AND "season" = $2;`
+	updateUnprocessedPlayersTeamsStatisticsSQL = `UPDATE "players_teams_statistics" SET "processed" = true WHERE "league" = $1 AND "player" = $2 AND "team" = $3 AND "season" = $4 AND "season_type" = $5;`
 )
 
 var statisticsTableOperationsSQLs = map[operation]map[table]string{
 	operationUpdateStatistics: {
-		tablePlayersStatistics: updatePlayersStatisticsSQL,
-		tableTeamsStatistics:   updateTeamsStatisticsSQL,
+		tablePlayersStatistics:      updatePlayersStatisticsSQL,
+		tableTeamsStatistics:        updateTeamsStatisticsSQL,
+		tablePlayersTeamsStatistics: updatePlayersTeamsStatisticsSQL,
+	},
+	operationIncrementStatistics: {
+		tablePlayersStatistics:      incrementStatisticsSQL(tablePlayersStatistics, "player", "season", "season_type", "league"),
+		tableTeamsStatistics:        incrementStatisticsSQL(tableTeamsStatistics, "team", "season", "season_type", "league"),
+		tablePlayersTeamsStatistics: incrementStatisticsSQL(tablePlayersTeamsStatistics, "player", "team", "season", "season_type", "league"),
 	},
 	operationSelectUnprocessed: {
-		tablePlayersStatistics: selectUnprocessedPlayersStatisticsSQL,
-		tableTeamsStatistics:   selectUnprocessedTeamsStatisticsSQL,
+		tablePlayersStatistics:      selectUnprocessedPlayersStatisticsSQL,
+		tableTeamsStatistics:        selectUnprocessedTeamsStatisticsSQL,
+		tablePlayersTeamsStatistics: selectUnprocessedPlayersTeamsStatisticsSQL,
 	},
 	operationUpdateUnprocessed: {
-		tablePlayersStatistics: updateUnprocessedPlayersStatisticsSQL,
-		tableTeamsStatistics:   updateUnprocessedTeamsStatisticsSQL,
+		tablePlayersStatistics:      updateUnprocessedPlayersStatisticsSQL,
+		tableTeamsStatistics:        updateUnprocessedTeamsStatisticsSQL,
+		tablePlayersTeamsStatistics: updateUnprocessedPlayersTeamsStatisticsSQL,
 	},
 }
 
+// substitutionsOrder orders 'enter' and 'exit' events of a player by their timestamps. At the same timestamp, a synthetic 'enter' event reopening
+// the interval of the player comes before given events, and a synthetic 'exit' event closing it comes after them.
+const substitutionsOrder = `"timestamp", "synthetic" AND "event" = 'enter' DESC, "synthetic" AND "event" = 'exit'`
+
 // updateGameOnTimeEventSQL is an SQL statement to be prepared for updating the `players_by_games` table on events changing `minutes_played`, i.e. 'enter' and 'exit' events
 // Parameter placeholders are intended for:
 // $1: player
 // $2: team
 // $3: game date in format "2006-01-02"
 // $4: season in format "2006-07",
-const updateGameOnTimeEventSQL = `INSERT INTO "players_by_games" ("player", "team", "game_date", "season", "minutes_played")
+// $5: season type, i.e. "preseason", "regular", "playin" or "playoffs"
+// $6: league
+//
+// Events are selected from the partitions of the timestamps the game date in any timezone spans.
+const updateGameOnTimeEventSQL = `INSERT INTO "players_by_games" ("league", "player", "team", "game_date", "season", "season_type", "minutes_played")
 (
-	SELECT "player", "team", "game_date", $4, EXTRACT(EPOCH FROM SUM("next_timestamp" - "timestamp")) / 60.0 AS "minutes_played"
+	SELECT $6, "player", "team", "game_date", $4, $5, EXTRACT(EPOCH FROM SUM("next_timestamp" - "timestamp")) / 60.0 AS "minutes_played"
 	FROM (
 		SELECT "player", "team", "game_date", "event", "timestamp", "next_timestamp" 
 		FROM (
 			SELECT "player", "team", "game_date", "event", "timestamp", 
-			LEAD("timestamp") OVER (PARTITION BY "player", "team", "game_date" ORDER BY "timestamp") AS "next_timestamp"
+			LEAD("timestamp") OVER (PARTITION BY "player", "team", "game_date" ORDER BY ` + substitutionsOrder + `) AS "next_timestamp"
 			FROM "events"
-			WHERE "player" = $1 AND "team" = $2 AND "game_date" = $3 AND "event" IN ('enter', 'exit')
+			WHERE "league" = $6 AND "player" = $1 AND "team" = $2 AND "game_date" = $3 AND "event" IN ('enter', 'exit') 
+				AND "timestamp" >= $3::date - 1 AND "timestamp" < $3::date + 2
 		)
 		WHERE "event" = 'enter' AND "next_timestamp" IS NOT NULL
 	)
 	GROUP BY "player", "team", "game_date"
 )
-ON CONFLICT ("player", "game_date") DO UPDATE SET "minutes_played" = EXCLUDED."minutes_played";`
+ON CONFLICT ("league", "player", "game_date", "season") DO UPDATE SET "minutes_played" = EXCLUDED."minutes_played";`
+
+// updateGameOnAttemptEventSQL is an SQL statement to be prepared for updating the `players_by_games` table on events changing made and attempted shots
+// or offensive rebounds, i.e. 'shot', 'miss' and 'rebound' events, with the same parameters as updateGameOnTimeEventSQL.
+// Shots of 1 point are free throws, and other shots are field goals.
+//
+// Events are selected from the partitions of the timestamps the game date in any timezone spans.
+const updateGameOnAttemptEventSQL = `INSERT INTO "players_by_games" ("league", "player", "team", "game_date", "season", "season_type",
+	"field_goals_made", "field_goals_attempted", "three_pointers_made", "free_throws_made", "free_throws_attempted", "offensive_rebounds")
+(
+	SELECT $6, "player", "team", "game_date", $4, $5,
+		COUNT(*) FILTER (WHERE "event" = 'shot' AND "value" > 1),
+		COUNT(*) FILTER (WHERE "event" IN ('shot', 'miss') AND "value" > 1),
+		COUNT(*) FILTER (WHERE "event" = 'shot' AND "value" = 3),
+		COUNT(*) FILTER (WHERE "event" = 'shot' AND "value" = 1),
+		COUNT(*) FILTER (WHERE "event" IN ('shot', 'miss') AND "value" = 1),
+		COUNT(*) FILTER (WHERE "event" = 'rebound' AND "offensive")
+	FROM "events"
+	WHERE "league" = $6 AND "player" = $1 AND "team" = $2 AND "game_date" = $3 AND "event" IN ('shot', 'miss', 'rebound')
+		AND "timestamp" >= $3::date - 1 AND "timestamp" < $3::date + 2
+	GROUP BY "player", "team", "game_date"
+)
+ON CONFLICT ("league", "player", "game_date", "season") DO UPDATE SET
+	"field_goals_made" = EXCLUDED."field_goals_made",
+	"field_goals_attempted" = EXCLUDED."field_goals_attempted",
+	"three_pointers_made" = EXCLUDED."three_pointers_made",
+	"free_throws_made" = EXCLUDED."free_throws_made",
+	"free_throws_attempted" = EXCLUDED."free_throws_attempted",
+	"offensive_rebounds" = EXCLUDED."offensive_rebounds";`
 
 // updateGameOnCounterEventSQL returns an SQL statement to be prepared for updating the `players_by_games` table on events incrementing counters
 // Parameter placeholders are intended for:
@@ -208,17 +416,46 @@ ON CONFLICT ("player", "game_date") DO UPDATE SET "minutes_played" = EXCLUDED."m
 // $2: team
 // $3: game date in format "2006-01-02"
 // $4: season in format "2006-07",
+// $5: season type, i.e. "preseason", "regular", "playin" or "playoffs"
+// $6: league
 //
 // counterColumn -- the column to be incremented
+//
+// Events are selected from the partitions of the timestamps the game date in any timezone spans.
 func updateGameOnCounterEventSQL(event eventType, counterColumn column) string {
-	return fmt.Sprintf(`INSERT INTO "players_by_games" ("player", "team", "game_date", "season", "%s") 
+	return fmt.Sprintf(`INSERT INTO "players_by_games" ("league", "player", "team", "game_date", "season", "season_type", "%s") 
 (
-	select "player", "team", "game_date", $4, sum("value") 
+	select $6, "player", "team", "game_date", $4, $5, sum("value") 
 	from "events" 
-	where "player" = $1 and "team" = $2 and "game_date" = $3 and "event" = '%s' 
+	where "league" = $6 and "player" = $1 and "team" = $2 and "game_date" = $3 and "event" = '%s' 
+		and "timestamp" >= $3::date - 1 and "timestamp" < $3::date + 2
 	group by "player", "team", "game_date"
 )
-ON CONFLICT ("player", "game_date") DO UPDATE SET "%s" = EXCLUDED."%s";`,
+ON CONFLICT ("league", "player", "game_date", "season") DO UPDATE SET "%s" = EXCLUDED."%s";`,
 		counterColumn, event, counterColumn, counterColumn,
 	)
 }
+
+// incrementGameOnCounterEventSQL returns an SQL statement to be prepared for adding the value of a new event to the counter of the `players_by_games` table
+// Parameter placeholders are intended for the same parameters as of updateGameOnCounterEventSQL, followed by:
+// $7: value of the event
+func incrementGameOnCounterEventSQL(counterColumn column) string {
+	return fmt.Sprintf(`INSERT INTO "players_by_games" ("league", "player", "team", "game_date", "season", "season_type", "%[1]s") 
+VALUES ($6, $1, $2, $3, $4, $5, $7)
+ON CONFLICT ("league", "player", "game_date", "season") DO UPDATE SET "%[1]s" = "players_by_games"."%[1]s" + EXCLUDED."%[1]s";`,
+		counterColumn,
+	)
+}
+
+// selectPlayerGameSQL is an SQL statement to select per-game values of a player in the order of statisticsColumns
+// Parameter placeholders are intended for:
+// $1: league
+// $2: player
+// $3: game date in format "2006-01-02"
+// $4: season
+const selectPlayerGameSQL = `SELECT "points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played", "plus_minus",
+	"field_goals_made", "field_goals_attempted", "three_pointers_made", "free_throws_made", "free_throws_attempted", "offensive_rebounds", "possessions"
+FROM "players_by_games" WHERE "league" = $1 AND "player" = $2 AND "game_date" = $3 AND "season" = $4`
+
+// deletePlayerGameSQL is an SQL statement to delete the per-game row of a player before it's recalculated, with the same parameters
+const deletePlayerGameSQL = `DELETE FROM "players_by_games" WHERE "league" = $1 AND "player" = $2 AND "game_date" = $3 AND "season" = $4`
@@ -0,0 +1,339 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// limits of the number of listed dead letters
+const (
+	defaultDeadLettersLimit = 100
+	maxDeadLettersLimit     = 1000
+)
+
+// deadLetterColumns are the columns of dead letters in the order of deadLetter fields
+const deadLetterColumns = `"id", "queue_id", "event", "override", "error", "attempts", "failed_at", "replayed_at"`
+
+// selectDeadLettersSQL is an SQL statement to list dead letters in the order of failures
+// Parameter placeholders are intended for:
+// $1: whether replayed dead letters are listed too
+// $2: maximum number of dead letters
+const selectDeadLettersSQL = `SELECT ` + deadLetterColumns + ` FROM "dead_letters" WHERE $1 OR "replayed_at" IS NULL ORDER BY "id" LIMIT $2`
+
+// selectDeadLetterSQL is an SQL statement to select the dead letter given by $1
+const selectDeadLetterSQL = `SELECT ` + deadLetterColumns + ` FROM "dead_letters" WHERE "id" = $1`
+
+// selectDeadLetterForReplaySQL is an SQL statement to select the dead letter given by $1 and hold it until the replay is recorded
+const selectDeadLetterForReplaySQL = selectDeadLetterSQL + ` FOR UPDATE`
+
+// updateDeadLetterEventSQL is an SQL statement to replace the event of the dead letter given by $1 with the event in JSON $2, unless replayed
+const updateDeadLetterEventSQL = `UPDATE "dead_letters" SET "event" = $2 WHERE "id" = $1 AND "replayed_at" IS NULL RETURNING ` + deadLetterColumns
+
+// updateDeadLetterReplayedSQL is an SQL statement to record the successful replay of the dead letter given by $1
+const updateDeadLetterReplayedSQL = `UPDATE "dead_letters" SET "attempts" = "attempts" + 1, "replayed_at" = NOW() AT TIME ZONE 'UTC' WHERE "id" = $1`
+
+// updateDeadLetterFailedSQL is an SQL statement to record the failed replay of the dead letter given by $1 with the error $2
+const updateDeadLetterFailedSQL = `UPDATE "dead_letters" SET "error" = $2, "attempts" = "attempts" + 1, "failed_at" = NOW() AT TIME ZONE 'UTC' WHERE "id" = $1`
+
+// updateQueuedEventReplayedSQL is an SQL statement to mark the queued event given by $1 processed after its dead letter is replayed
+const updateQueuedEventReplayedSQL = `UPDATE "ingestion_queue" SET "state" = 'processed', "error" = NULL, "processed_at" = NOW() AT TIME ZONE 'UTC' WHERE "id" = $1`
+
+var (
+	errDeadLetterReplayed = errors.New("dead letter is already replayed")
+	errInvalidEvent       = errors.New("invalid event") // the event of the dead letter can't be replayed until edited
+)
+
+// deadLetter is an event failed to be processed
+type deadLetter struct {
+	ID         int64           `json:"id"`
+	QueueID    *int64          `json:"queueId,omitempty"` // identifier of the queued event in the asynchronous ingestion mode
+	Event      json.RawMessage `json:"event"`             // raw, as a queued event may not be a valid one
+	Override   bool            `json:"override"`
+	Error      string          `json:"error"`
+	Attempts   int             `json:"attempts"`
+	FailedAt   time.Time       `json:"failedAt"`
+	ReplayedAt *time.Time      `json:"replayedAt,omitempty"`
+}
+
+// scanDeadLetter scans the row of deadLetterColumns
+func scanDeadLetter(row interface{ Scan(dest ...any) error }) (deadLetter, error) {
+	var d deadLetter
+	var queueID sql.NullInt64
+	var replayedAt sql.NullTime
+	if err := row.Scan(&d.ID, &queueID, &d.Event, &d.Override, &d.Error, &d.Attempts, &d.FailedAt, &replayedAt); err != nil {
+		return deadLetter{}, err
+	}
+	if queueID.Valid {
+		d.QueueID = &queueID.Int64
+	}
+	if replayedAt.Valid {
+		d.ReplayedAt = &replayedAt.Time
+	}
+
+	return d, nil
+}
+
+// insertDeadLetter stores the valid event failed to be processed on the first attempt, and returns the identifier of the dead letter
+func insertDeadLetter(ctx context.Context, db *sql.DB, e event, override bool, processErr error) (id int64, err error) {
+	eventJSON, err := json.Marshal(e)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if err = db.QueryRowContext(ctx, insertDeadLetterSQL, nil, eventJSON, override, processErr.Error(), 1).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to insert dead letter of event %q: %w", e, err)
+	}
+	log.Println(fmt.Sprintf("Event %q is saved as dead letter %d", e, id))
+
+	return id, nil
+}
+
+// deadLettersHandler responds dead letters not replayed yet, or all of them with ?all=true, up to ?limit=
+func deadLettersHandler(ctx context.Context, cfg config, db *sql.DB) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(cfg, w, r) {
+			return
+		}
+
+		all := r.URL.Query().Get("all") == "true"
+		limit := defaultDeadLettersLimit
+		if s := r.URL.Query().Get("limit"); s != "" {
+			var err error
+			if limit, err = strconv.Atoi(s); err != nil || limit < 1 || limit > maxDeadLettersLimit {
+				respondError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q, number from 1 to %d expected", s, maxDeadLettersLimit))
+				return
+			}
+		}
+
+		deadLetters, err := selectDeadLetters(ctx, db, all, limit)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		respondDeadLetter(w, deadLetters)
+	}
+}
+
+func selectDeadLetters(ctx context.Context, db *sql.DB, all bool, limit int) (deadLetters []deadLetter, err error) {
+	rows, err := db.QueryContext(ctx, selectDeadLettersSQL, all, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select dead letters: %w", err)
+	}
+	defer closeIt("rows", rows)
+
+	deadLetters = []deadLetter{}
+	for rows.Next() {
+		d, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		deadLetters = append(deadLetters, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate dead letters: %w", err)
+	}
+
+	return deadLetters, nil
+}
+
+// deadLetterHandler responds the dead letter
+func deadLetterHandler(ctx context.Context, cfg config, db *sql.DB) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(cfg, w, r) {
+			return
+		}
+
+		id, ok := deadLetterID(w, r)
+		if !ok {
+			return
+		}
+
+		d, err := scanDeadLetter(db.QueryRowContext(ctx, selectDeadLetterSQL, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, fmt.Errorf("dead letter %d is not found", id))
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to select dead letter %d: %w", id, err))
+			return
+		}
+
+		respondDeadLetter(w, d)
+	}
+}
+
+// editDeadLetterHandler replaces the event of the dead letter not replayed yet with the valid event given, e.g. to fix a typo before the replay
+func editDeadLetterHandler(ctx context.Context, cfg config, db *sql.DB) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(cfg, w, r) {
+			return
+		}
+
+		id, ok := deadLetterID(w, r)
+		if !ok {
+			return
+		}
+
+		var event event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to decode JSON: %w", err))
+			return
+		}
+
+		if event.League == "" {
+			event.League = defaultLeague
+		}
+
+		if err := event.validate(cfg.leagues); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to validate event: %w", err))
+			return
+		}
+
+		eventJSON, err := json.Marshal(event)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to marshal event: %w", err))
+			return
+		}
+
+		d, err := scanDeadLetter(db.QueryRowContext(ctx, updateDeadLetterEventSQL, id, eventJSON))
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, fmt.Errorf("dead letter %d is not found or already replayed", id))
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to update dead letter %d: %w", id, err))
+			return
+		}
+		log.Println(fmt.Sprintf("Dead letter %d is edited to event %q", id, event))
+
+		respondDeadLetter(w, d)
+	}
+}
+
+// replayDeadLetterHandler processes the event of the dead letter as a new one, and records the result.
+// The dead letter replayed successfully is kept, and its queued event is marked processed.
+func replayDeadLetterHandler(ctx context.Context, cfg config, db *sql.DB, stmts preparedStatements, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(cfg, w, r) {
+			return
+		}
+
+		id, ok := deadLetterID(w, r)
+		if !ok {
+			return
+		}
+
+		d, err := replayDeadLetter(ctx, cfg, db, stmts, id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondError(w, http.StatusNotFound, fmt.Errorf("dead letter %d is not found", id))
+			return
+		case errors.Is(err, errDeadLetterReplayed):
+			respondError(w, http.StatusConflict, fmt.Errorf("failed to replay dead letter %d: %w", id, err))
+			return
+		case errors.Is(err, errInvalidEvent):
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to replay dead letter %d: %w", id, err))
+			return
+		case err != nil:
+			respondError(w, processingStatusCode(err), fmt.Errorf("failed to replay dead letter %d: %w", id, err))
+			return
+		}
+		log.Println(fmt.Sprintf("Dead letter %d replayed successfully", id))
+
+		if err := updateCaches(ctx, db, stmts, rdb); err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		respondDeadLetter(w, d)
+	}
+}
+
+// replayDeadLetter processes the event of the dead letter and records the result, which is returned as the error of processing, if any.
+// The dead letter is held until the result is recorded, so it's replayed once by concurrent requests.
+func replayDeadLetter(ctx context.Context, cfg config, db *sql.DB, stmts preparedStatements, id int64) (d deadLetter, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return deadLetter{}, fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			err = errors.Join(err, rollbackErr)
+		}
+	}()
+
+	d, err = scanDeadLetter(tx.QueryRowContext(ctx, selectDeadLetterForReplaySQL, id))
+	if err != nil {
+		return deadLetter{}, fmt.Errorf("failed to select dead letter: %w", err)
+	}
+	if d.ReplayedAt != nil {
+		return deadLetter{}, errDeadLetterReplayed
+	}
+
+	var e event
+	if err := json.Unmarshal(d.Event, &e); err != nil {
+		return deadLetter{}, fmt.Errorf("%w: %w", errInvalidEvent, err)
+	}
+	if e.League == "" {
+		e.League = defaultLeague
+	}
+	if err := e.validate(cfg.leagues); err != nil {
+		return deadLetter{}, fmt.Errorf("%w: %w", errInvalidEvent, err)
+	}
+
+	processErr := retryTransaction(ctx, fmt.Sprintf("event %q", e), func() error {
+		return processEvent(ctx, cfg, e, d.Override, db, stmts)
+	})
+
+	d.Attempts++
+	if processErr != nil {
+		if _, err = tx.ExecContext(ctx, updateDeadLetterFailedSQL, id, processErr.Error()); err != nil {
+			return deadLetter{}, fmt.Errorf("failed to update dead letter: %w", err)
+		}
+	} else {
+		if _, err = tx.ExecContext(ctx, updateDeadLetterReplayedSQL, id); err != nil {
+			return deadLetter{}, fmt.Errorf("failed to update dead letter: %w", err)
+		}
+		if d.QueueID != nil {
+			if _, err = tx.ExecContext(ctx, updateQueuedEventReplayedSQL, *d.QueueID); err != nil {
+				return deadLetter{}, fmt.Errorf("failed to update queued event %d: %w", *d.QueueID, err)
+			}
+		}
+		replayedAt := time.Now().UTC()
+		d.ReplayedAt = &replayedAt
+	}
+
+	if err = tx.Commit(); err != nil {
+		return deadLetter{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return d, processErr
+}
+
+// deadLetterID parses the identifier of the dead letter in the path, and responds 400 Bad Request if it's invalid
+func deadLetterID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Errorf("invalid dead letter identifier %q", r.PathValue("id")))
+		return 0, false
+	}
+
+	return id, true
+}
+
+// respondDeadLetter writes dead letters in JSON
+func respondDeadLetter(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println(fmt.Errorf("failed to write dead letters: %w", err))
+	}
+}
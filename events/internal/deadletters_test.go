@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var deadLetterColumnNames = []string{"id", "queue_id", "event", "override", "error", "attempts", "failed_at", "replayed_at"}
+
+func TestDeadLetterHandlers_AdminOnly(t *testing.T) {
+	cfg := config{adminToken: "secret"}
+	for name, handler := range map[string]http.HandlerFunc{
+		"list":   deadLettersHandler(t.Context(), cfg, nil),
+		"get":    deadLetterHandler(t.Context(), cfg, nil),
+		"edit":   editDeadLetterHandler(t.Context(), cfg, nil),
+		"replay": replayDeadLetterHandler(t.Context(), cfg, nil, preparedStatements{}, nil),
+	} {
+		for _, token := range []string{"", "wrong"} {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/admin/dead-letters", nil)
+			r.Header.Set(adminTokenHeader, token)
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("%s with token %q: expected status code %d, got %d", name, token, http.StatusForbidden, w.Code)
+			}
+		}
+	}
+}
+
+func TestDeadLetterHandler(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	failedAt := time.Date(2025, time.March, 15, 18, 45, 0, 0, time.UTC)
+	eventJSON := []byte(`{"player":"lebron-james","team":"lakers","event":"foul"}`)
+	mock.ExpectQuery(`SELECT "id", "queue_id"`).WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows(deadLetterColumnNames).AddRow(42, nil, eventJSON, false, "fouled out", 1, failedAt, nil))
+	mock.ExpectQuery(`SELECT "id", "queue_id"`).WithArgs(int64(43)).WillReturnError(sql.ErrNoRows)
+
+	cfg := config{adminToken: "secret"}
+	handler := deadLetterHandler(t.Context(), cfg, db)
+	// dead letters are requested in the order of the expected queries
+	for _, tc := range []struct {
+		id                 string
+		expectedStatusCode int
+	}{
+		{"42", http.StatusOK},
+		{"43", http.StatusNotFound},
+		{"x", http.StatusBadRequest},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/admin/dead-letters/"+tc.id, nil)
+		r.SetPathValue("id", tc.id)
+		r.Header.Set(adminTokenHeader, cfg.adminToken)
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != tc.expectedStatusCode {
+			t.Errorf("expected status code %d for dead letter %s, got %d", tc.expectedStatusCode, tc.id, w.Code)
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var d deadLetter
+		if err := json.NewDecoder(w.Body).Decode(&d); err != nil {
+			t.Fatalf("failed to decode dead letter: %v", err)
+		}
+		if d.ID != 42 || d.QueueID != nil || string(d.Event) != string(eventJSON) || d.Error != "fouled out" || d.Attempts != 1 || d.ReplayedAt != nil {
+			t.Errorf("unexpected dead letter %+v", d)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestEditDeadLetterHandler_InvalidEvent(t *testing.T) {
+	cfg := config{adminToken: "secret", leagues: defaultLeagues}
+	r := httptest.NewRequest(http.MethodPut, "/api/v1/admin/dead-letters/42", strings.NewReader(`{"player":"lebron-james","team":"lakers","event":"dunk"}`))
+	r.SetPathValue("id", "42")
+	r.Header.Set(adminTokenHeader, cfg.adminToken)
+	w := httptest.NewRecorder()
+	// the event is rejected before the database is touched
+	editDeadLetterHandler(t.Context(), cfg, nil)(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestReplayDeadLetter_Replayed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	failedAt := time.Date(2025, time.March, 15, 18, 45, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "id", "queue_id", .* FOR UPDATE`).WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows(deadLetterColumnNames).AddRow(42, 7, []byte(`{}`), false, "fouled out", 2, failedAt, failedAt.Add(time.Hour)))
+	mock.ExpectRollback()
+
+	cfg := config{adminToken: "secret"}
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/admin/dead-letters/42/replay", nil)
+	r.SetPathValue("id", "42")
+	r.Header.Set(adminTokenHeader, cfg.adminToken)
+	w := httptest.NewRecorder()
+	replayDeadLetterHandler(t.Context(), cfg, db, preparedStatements{}, nil)(w, r)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status code %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 )
 
@@ -10,6 +11,7 @@ type eventType string
 
 const (
 	eventShot     eventType = "shot"
+	eventMiss     eventType = "miss" // a missed shot, for attempts and possessions
 	eventRebound  eventType = "rebound"
 	eventAssist   eventType = "assist"
 	eventSteal    eventType = "steal"
@@ -20,8 +22,24 @@ const (
 	eventExit     eventType = "exit"  // "sub out", for Minutes Played calculation
 )
 
+// game lifecycle events of a team, given without a player
+const (
+	eventGameStart   eventType = "game_start" // tip-off, i.e. the start of the first period
+	eventPeriodStart eventType = "period_start"
+	eventPeriodEnd   eventType = "period_end"
+	eventGameEnd     eventType = "game_end"
+)
+
+var gameEventTypes = map[eventType]bool{
+	eventGameStart:   true,
+	eventPeriodStart: true,
+	eventPeriodEnd:   true,
+	eventGameEnd:     true,
+}
+
 var eventTypes = map[eventType]bool{
 	eventShot:     true,
+	eventMiss:     true,
 	eventRebound:  true,
 	eventAssist:   true,
 	eventSteal:    true,
@@ -37,14 +55,25 @@ type event struct {
 	Team      string    `json:"team"`
 	Timestamp time.Time `json:"timestamp"`
 	Event     eventType `json:"event"`
-	Points    int       `json:"points"` // only relevant for `eventShot` event type
+	Points    int       `json:"points"`    // only relevant for `eventShot` and `eventMiss` event types, the value of the attempt for the latter
+	Offensive bool      `json:"offensive"` // only relevant for `eventRebound` event type
+	HomeTeam  string    `json:"homeTeam"`  // optional, the team of the venue defining the local game date
+	League    string    `json:"league"`    // optional, the default league unless specified
+	// synthetic events are recorded by the game lifecycle rather than given, i.e. 'exit' events closing on-court intervals at the end of a period
+	// and 'enter' events reopening them at the start of the next one. They are kept apart from given events of the player at the same time.
+	synthetic bool
 }
 
-func (e event) validate() error {
-	if e.Player == "" {
+// validate checks the event against the rules of the league it belongs to
+func (e event) validate(leagues leagues) error {
+	if e.Player == "" && !gameEventTypes[e.Event] {
 		return errors.New("'player' is not specified")
 	}
 
+	if e.Player != "" && gameEventTypes[e.Event] {
+		return fmt.Errorf("'player' is not applicable to %q event", e.Event)
+	}
+
 	if e.Team == "" {
 		return errors.New("'team' is not specified")
 	}
@@ -57,44 +86,58 @@ func (e event) validate() error {
 		return errors.New("'event' is not specified")
 	}
 
-	if !eventTypes[e.Event] {
+	if !eventTypes[e.Event] && !gameEventTypes[e.Event] {
 		return fmt.Errorf("unknown 'event': %q", e.Event)
 	}
 
-	if e.Event == eventShot && (e.Points < 1 || e.Points > 3) ||
-		e.Event != eventShot && e.Points != 0 {
+	league, ok := leagues[e.League]
+	if !ok {
+		return fmt.Errorf("unknown 'league': %q", e.League)
+	}
+
+	attempt := e.Event == eventShot || e.Event == eventMiss
+	if attempt && !slices.Contains(league.ShotValues, e.Points) ||
+		!attempt && e.Points != 0 {
 		return fmt.Errorf("invalid 'points' value: %d", e.Points)
 	}
 
+	if e.Offensive && e.Event != eventRebound {
+		return fmt.Errorf("'offensive' is not applicable to %q event", e.Event)
+	}
+
 	return nil
 }
 
 func (e event) String() string {
-	return fmt.Sprintf("%s, %s, %s, %s, %d", e.Player, e.Team, e.Timestamp, e.Event, e.Points)
+	return fmt.Sprintf("%s, %s, %s, %s, %d, %s", e.Player, e.Team, e.Timestamp, e.Event, e.Points, e.League)
 }
 
-// value returns 0 for 'enter' and 'exit' events; number of points for 'shot' event and 1 for other event types
+// venueTeam returns the team of the venue: the home team if specified, the team of the event otherwise
+func (e event) venueTeam() string {
+	if e.HomeTeam != "" {
+		return e.HomeTeam
+	}
+	return e.Team
+}
+
+// value returns 0 for 'enter' and 'exit' events; number of points for 'shot' and 'miss' events and 1 for other event types
 func (e event) value() int {
 	switch e.Event {
 	case eventEnter, eventExit:
 		return 0
-	case eventShot:
+	case eventShot, eventMiss:
 		return e.Points
 	default:
 		return 1
 	}
 }
 
-// gameDate returns the date of the game in format "2006-01-02"
-func (e event) gameDate() string {
-	return e.Timestamp.Format(time.DateOnly)
+// gameDate returns the date of the game in the venue location in format "2006-01-02"
+func (e event) gameDate(venue *time.Location) string {
+	return e.Timestamp.In(venue).Format(time.DateOnly)
 }
 
-// season returns the NBA season in the format "2006-07"
-func (e event) season() string {
-	startYear := e.Timestamp.Year()
-	if e.Timestamp.Month() < time.October {
-		startYear -= 1
-	}
-	return fmt.Sprintf("%d-%02d", startYear, (startYear+1)%100)
+// season returns the season of the league the game in the venue location belongs to
+func (e event) season(league league, venue *time.Location) string {
+	return league.season(e.Timestamp.In(venue))
 }
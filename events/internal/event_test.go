@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvent_GameDate_VenueLocal(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	// a game tipping off at 7:30pm PT
+	tipOff := event{Timestamp: time.Date(2025, time.March, 16, 2, 30, 0, 0, time.UTC)}
+	lateInGame := event{Timestamp: time.Date(2025, time.March, 16, 4, 45, 0, 0, time.UTC)}
+
+	for _, e := range []event{tipOff, lateInGame} {
+		if gameDate := e.gameDate(losAngeles); gameDate != "2025-03-15" {
+			t.Errorf("gameDate of %s: expected 2025-03-15, got %s", e.Timestamp, gameDate)
+		}
+	}
+
+	if gameDate := lateInGame.gameDate(time.UTC); gameDate != "2025-03-16" {
+		t.Errorf("gameDate in UTC: expected 2025-03-16, got %s", gameDate)
+	}
+}
+
+func TestEvent_Validate_Attempts(t *testing.T) {
+	timestamp := time.Date(2025, time.March, 15, 19, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		e     event
+		valid bool
+	}{
+		// a missed shot is given the value of the attempt
+		{event{Player: leBronJamesID, Team: losAngelesLakersID, Timestamp: timestamp, Event: eventMiss, Points: 3, League: defaultLeague}, true},
+		{event{Player: leBronJamesID, Team: losAngelesLakersID, Timestamp: timestamp, Event: eventMiss, Points: 1, League: defaultLeague}, true},
+		{event{Player: leBronJamesID, Team: losAngelesLakersID, Timestamp: timestamp, Event: eventMiss, League: defaultLeague}, false},
+		{event{Player: leBronJamesID, Team: losAngelesLakersID, Timestamp: timestamp, Event: eventRebound, Offensive: true, League: defaultLeague}, true},
+		{event{Player: leBronJamesID, Team: losAngelesLakersID, Timestamp: timestamp, Event: eventSteal, Offensive: true, League: defaultLeague}, false},
+	} {
+		if err := tc.e.validate(defaultLeagues); (err == nil) != tc.valid {
+			t.Errorf("event %q: expected valid %v, got %v", tc.e, tc.valid, err)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"slices"
+	"time"
+)
+
+type gameState string
+
+// states of a game of a team
+const (
+	gameStateScheduled    gameState = "scheduled" // no lifecycle event yet, events of players are accepted anyway
+	gameStateLive         gameState = "live"      // a period is played
+	gameStateIntermission gameState = "intermission"
+	gameStateFinal        gameState = "final"
+)
+
+// gameTransition is the change of the state of a game on a lifecycle event
+type gameTransition struct {
+	from []gameState
+	to   gameState
+}
+
+// gameTransitions define the state machine of a game. The game ends either after the end of the last period or during it.
+var gameTransitions = map[eventType]gameTransition{
+	eventGameStart:   {[]gameState{gameStateScheduled}, gameStateLive},
+	eventPeriodEnd:   {[]gameState{gameStateLive}, gameStateIntermission},
+	eventPeriodStart: {[]gameState{gameStateIntermission}, gameStateLive},
+	eventGameEnd:     {[]gameState{gameStateLive, gameStateIntermission}, gameStateFinal},
+}
+
+var errInvalidGameTransition = errors.New("invalid game transition")
+
+// selectTeamGameStateSQL is an SQL statement to select the state of the game and hold it until the transition is recorded
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+const selectTeamGameStateSQL = `SELECT "state", "period" FROM "team_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3 FOR UPDATE`
+
+// updateTeamGameStateSQL is an SQL statement to record the transition of the game, with the same parameters and
+// $4: the new state
+// $5: the current period
+// $6: the start of the game, or NULL
+// $7: the end of the game, or NULL
+const updateTeamGameStateSQL = `UPDATE "team_games" SET "state" = $4, "period" = $5,
+	"started_at" = COALESCE("started_at", $6), "ends_at" = COALESCE($7, "ends_at"), "processed" = false
+WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3`
+
+// selectSubstitutionsSQL is an SQL statement to select the latest 'enter' or 'exit' event of every player of the team at the time given by $4,
+// with the same parameters. Events are selected from the partitions of the timestamps the game date in any timezone spans.
+const selectSubstitutionsSQL = `SELECT DISTINCT ON ("player") "player", "event", "synthetic" FROM "events"
+WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3 AND "event" IN ('enter', 'exit') AND "timestamp" <= $4
+	AND "timestamp" >= $3::date - 1 AND "timestamp" < $3::date + 2
+ORDER BY "player", "timestamp" DESC, "synthetic" AND "event" = 'enter', "synthetic" AND "event" = 'exit' DESC`
+
+// updatePlayersGamesFinalSQL is an SQL statement to mark per-game rows of the team final, with the same parameters
+const updatePlayersGamesFinalSQL = `UPDATE "players_by_games" SET "final" = true WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3`
+
+// updatePlayerGameFinalSQL is an SQL statement to mark the per-game row recalculated after the end of the game final again
+// Parameter placeholders are intended for:
+// $1: league
+// $2: player
+// $3: game date in format "2006-01-02"
+// $4: season
+const updatePlayerGameFinalSQL = `UPDATE "players_by_games" SET "final" = true WHERE "league" = $1 AND "player" = $2 AND "game_date" = $3 AND "season" = $4`
+
+// SQL statements to copy the state of games to Redis
+const (
+	selectUnprocessedTeamGamesSQL = `SELECT "league", "team", "game_date", "state", "period", "started_at", "ends_at",
+	COALESCE("home_team", "team"), COALESCE("opponent", ''), COALESCE("season", ''),
+	"opponent" IS NOT NULL AND ("state" = 'final' OR "finalized_at" IS NOT NULL) FROM "team_games"
+WHERE "processed" = false FOR UPDATE SKIP LOCKED`
+	updateUnprocessedTeamGameSQL = `UPDATE "team_games" SET "processed" = true WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3`
+)
+
+// gameStatus is the state of a game of a team stored in Redis
+type gameStatus struct {
+	League    string     `json:"league"`
+	Team      string     `json:"team"`
+	GameDate  string     `json:"gameDate"`
+	State     gameState  `json:"state"`
+	Period    int        `json:"period"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`  // the end given by 'game_end', or the latest event of a game finalized without it
+	HomeTeam  string     `json:"homeTeam"`           // the team itself unless the game is paired with its home team
+	Opponent  string     `json:"opponent,omitempty"` // unknown until the game is paired
+	Score     score      `json:"score"`
+}
+
+// gameKey returns the Redis key of the state of the game of the team in the league on the date in format "2006-01-02"
+func gameKey(league, team, gameDate string) string {
+	return fmt.Sprintf("%s:game:%s:%s", league, team, gameDate)
+}
+
+// substitution is the latest 'enter' or 'exit' event of a player of the team in the game
+type substitution struct {
+	player    string
+	event     eventType
+	synthetic bool
+}
+
+// applyGameEvent moves the game of the team to the next state in the transaction.
+// Open 'enter' intervals of players on the court are closed by synthetic 'exit' events at the end of a period, so minutes played are counted until then,
+// and reopened by synthetic 'enter' events at the start of the next period. Per-game rows of the team are marked final at the end of the game.
+func applyGameEvent(ctx context.Context, tx *sql.Tx, cfg config, event event, override bool, stmts preparedStatements) error {
+	event.Timestamp = event.Timestamp.UTC()
+
+	var err error
+	if event.Team, err = resolve(ctx, tx, stmts, subjectTeam, event.Team); err != nil {
+		return fmt.Errorf("failed to resolve team: %w", err)
+	}
+
+	if event.HomeTeam != "" {
+		if event.HomeTeam, err = resolve(ctx, tx, stmts, subjectTeam, event.HomeTeam); err != nil {
+			return fmt.Errorf("failed to resolve home team: %w", err)
+		}
+	}
+	venue, err := venueLocation(ctx, tx, stmts, event.venueTeam())
+	if err != nil {
+		return fmt.Errorf("failed to get venue location: %w", err)
+	}
+	game := teamGame{event.League, event.Team, event.gameDate(venue)}
+	league := cfg.leagues[event.League]
+	season := event.season(league, venue)
+
+	substitutions, err := lockGame(ctx, tx, stmts, event, game)
+	if err != nil {
+		return err
+	}
+
+	if err = validateNotPurged(ctx, tx, stmts, game); err != nil {
+		return fmt.Errorf("failed to validate game: %w", err)
+	}
+
+	// the game is tracked from its first event, a lifecycle one included
+	if _, err = updateWatermark(ctx, tx, stmts, cfg, event, game.gameDate); err != nil {
+		return err
+	}
+	if err = pairGame(ctx, tx, stmts, event, game.gameDate, season, league.Calendar.seasonType(season, game.gameDate)); err != nil {
+		return err
+	}
+
+	var state gameState
+	var period int
+	if err = tx.QueryRowContext(ctx, selectTeamGameStateSQL, game.league, game.team, game.gameDate).Scan(&state, &period); err != nil {
+		return fmt.Errorf("failed to select state of game %s: %w", game, err)
+	}
+
+	transition := gameTransitions[event.Event]
+	if !slices.Contains(transition.from, state) {
+		return fmt.Errorf("%w: %q event of game %s in %q state", errInvalidGameTransition, event.Event, game, state)
+	}
+
+	var startedAt, endedAt any // NULL unless the game starts or ends
+	switch event.Event {
+	case eventGameStart:
+		period, startedAt = 1, event.Timestamp
+	case eventPeriodStart:
+		period++
+	case eventGameEnd:
+		// overtime periods are played after the regulation ones, but the game doesn't end before them
+		if periods := league.Periods; period < periods {
+			return fmt.Errorf("%w: game %s ended in period %d of %d", errInvalidGameTransition, game, period, periods)
+		}
+		endedAt = event.Timestamp
+	}
+
+	if event.Event == eventPeriodEnd || event.Event == eventPeriodStart || event.Event == eventGameEnd && state == gameStateLive {
+		if err = updateOnCourtIntervals(ctx, tx, cfg, event, override, stmts, substitutions); err != nil {
+			return err
+		}
+	}
+
+	if event.Event == eventGameEnd {
+		if _, err = tx.ExecContext(ctx, updatePlayersGamesFinalSQL, game.league, game.team, game.gameDate); err != nil {
+			return fmt.Errorf("failed to mark per-game rows of game %s final: %w", game, err)
+		}
+	}
+
+	// the game ended during the intermission ended its last period already
+	if event.Event != eventGameEnd || state == gameStateLive {
+		if err = recordPeriod(ctx, tx, stmts, game, event, period); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, updateTeamGameStateSQL, game.league, game.team, game.gameDate, transition.to, period, startedAt, endedAt); err != nil {
+		return fmt.Errorf("failed to update state of game %s: %w", game, err)
+	}
+	log.Println(fmt.Sprintf("Game %s is %s in period %d on %q event", game, transition.to, period, event.Event))
+
+	return nil
+}
+
+// lockGame takes the advisory locks of the team and of its players whose on-court intervals the lifecycle event may change, in the order of their keys
+// as lockAggregates does, since changing the intervals updates the aggregates of the players. It returns the latest substitutions of the players
+// selected again once the team is locked, as no events of the team are stored meanwhile. A player substituted before the team is locked
+// is locked after it, and the transaction failing on a deadlock then is retried.
+func lockGame(ctx context.Context, tx *sql.Tx, stmts preparedStatements, event event, game teamGame) ([]substitution, error) {
+	teamKey := aggregateKey(game.league, subjectTeam, game.team)
+	if event.Event == eventGameStart {
+		if err := lockKeys(ctx, tx, stmts, teamKey); err != nil {
+			return nil, fmt.Errorf("failed to lock team %q: %w", game.team, err)
+		}
+		return nil, nil
+	}
+
+	locked, err := selectSubstitutions(ctx, tx, event, game)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{teamKey}
+	for _, s := range locked {
+		keys = append(keys, aggregateKey(game.league, subjectPlayer, s.player))
+	}
+	if err = lockKeys(ctx, tx, stmts, keys...); err != nil {
+		return nil, fmt.Errorf("failed to lock game %s: %w", game, err)
+	}
+
+	substitutions, err := selectSubstitutions(ctx, tx, event, game)
+	if err != nil {
+		return nil, err
+	}
+	keys = nil
+	for _, s := range substitutions {
+		if !slices.ContainsFunc(locked, func(l substitution) bool { return l.player == s.player }) {
+			keys = append(keys, aggregateKey(game.league, subjectPlayer, s.player))
+		}
+	}
+	if err = lockKeys(ctx, tx, stmts, keys...); err != nil {
+		return nil, fmt.Errorf("failed to lock game %s: %w", game, err)
+	}
+
+	return substitutions, nil
+}
+
+// selectSubstitutions returns the latest substitutions of the players of the team in the game at the time of the event
+func selectSubstitutions(ctx context.Context, tx *sql.Tx, event event, game teamGame) ([]substitution, error) {
+	rows, err := tx.QueryContext(ctx, selectSubstitutionsSQL, game.league, game.team, game.gameDate, event.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select substitutions of game %s: %w", game, err)
+	}
+	var substitutions []substitution
+	for rows.Next() {
+		var s substitution
+		if err := rows.Scan(&s.player, &s.event, &s.synthetic); err != nil {
+			closeIt("rows", rows)
+			return nil, fmt.Errorf("failed to scan substitution of game %s: %w", game, err)
+		}
+		substitutions = append(substitutions, s)
+	}
+	closeIt("rows", rows)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to select substitutions of game %s: %w", game, err)
+	}
+
+	return substitutions, nil
+}
+
+// changedIntervals returns the synthetic event the lifecycle event changes on-court intervals by, and the players whose intervals it changes
+// given their latest substitutions: players on the court at the end of a period are exited, and players exited so are entered again
+// at the start of the next period. Players substituted meanwhile are left as they are.
+func changedIntervals(e eventType, substitutions []substitution) (eventType, []string) {
+	var players []string
+	if e == eventPeriodStart {
+		for _, s := range substitutions {
+			if s.event == eventExit && s.synthetic {
+				players = append(players, s.player)
+			}
+		}
+		return eventEnter, players
+	}
+
+	for _, s := range substitutions {
+		if s.event == eventEnter {
+			players = append(players, s.player)
+		}
+	}
+	return eventExit, players
+}
+
+// updateOnCourtIntervals applies a synthetic event closing or reopening the interval of every player the lifecycle event changes, so their minutes are counted.
+// Synthetic events are kept apart from given events of the players at the same time, so they don't replace each other.
+func updateOnCourtIntervals(ctx context.Context, tx *sql.Tx, cfg config, event event, override bool, stmts preparedStatements, substitutions []substitution) error {
+	eventType, players := changedIntervals(event.Event, substitutions)
+	for _, player := range players {
+		e := event
+		e.Player, e.Event, e.synthetic = player, eventType, true
+		if err := applyEvent(ctx, tx, cfg, e, override, stmts); err != nil {
+			return fmt.Errorf("failed to apply %q of %q on %q event: %w", eventType, player, event.Event, err)
+		}
+	}
+
+	return nil
+}
+
+// updateGamesCache copies the state and the score of games changed since the last update to Redis,
+// adding each game to the scoreboard of its date by its home team. Standings of the seasons of changed final games are recalculated.
+func updateGamesCache(ctx context.Context, db *sql.DB, stmts preparedStatements, rdb *redis.Client) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	rows, err := tx.StmtContext(ctx, stmts.selectUnprocessedTeamGames).QueryContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to query unprocessed games: %w", err)
+	}
+
+	// all the claimed rows are read before further statements are executed in the same transaction
+	var claimed []gameStatus
+	var seasons []leagueSeason // of final games
+	for rows.Next() {
+		var g gameStatus
+		var gameDate time.Time
+		var startedAt, endedAt sql.NullTime
+		var season string
+		var final bool
+		if err = rows.Scan(&g.League, &g.Team, &gameDate, &g.State, &g.Period, &startedAt, &endedAt, &g.HomeTeam, &g.Opponent, &season, &final); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan unprocessed game: %w", err)
+		}
+		g.GameDate = gameDate.Format(time.DateOnly)
+		if startedAt.Valid {
+			g.StartedAt = &startedAt.Time
+		}
+		if endedAt.Valid {
+			g.EndedAt = &endedAt.Time
+		}
+		claimed = append(claimed, g)
+		if final && season != "" && !slices.Contains(seasons, leagueSeason{g.League, season}) {
+			seasons = append(seasons, leagueSeason{g.League, season})
+		}
+	}
+	closeIt("rows", rows)
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to query unprocessed games: %w", err)
+	}
+
+	for _, g := range claimed {
+		if g.Score, err = selectScore(ctx, tx, stmts, teamGame{g.League, g.Team, g.GameDate}); err != nil {
+			return err
+		}
+
+		valueJSON, err := json.Marshal(g)
+		if err != nil {
+			return fmt.Errorf("failed to marshal game: %w", err)
+		}
+
+		if err := rdb.Set(ctx, gameKey(g.League, g.Team, g.GameDate), valueJSON, 0).Err(); err != nil {
+			return fmt.Errorf("failed to set to Redis state of game of %q in %s on %s: %w", g.Team, g.League, g.GameDate, err)
+		}
+		if err := rdb.SAdd(ctx, scoreboardKey(g.League, g.GameDate), g.HomeTeam).Err(); err != nil {
+			return fmt.Errorf("failed to add to Redis scoreboard game of %q in %s on %s: %w", g.Team, g.League, g.GameDate, err)
+		}
+
+		if err := txExec(ctx, tx, stmts.updateUnprocessedTeamGame, g.League, g.Team, g.GameDate); err != nil {
+			return fmt.Errorf("failed to update processed game of %q in %s on %s: %w", g.Team, g.League, g.GameDate, err)
+		}
+	}
+
+	for _, s := range seasons {
+		if err = updateStandingsCache(ctx, tx, rdb, s.league, s.season); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
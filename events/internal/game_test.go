@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testApplyGameEvent applies the lifecycle event to the game of the Lakers in the given state and period,
+// expecting the transition to be recorded unless an error is expected
+func testApplyGameEvent(t *testing.T, e event, state gameState, period int, expectedPeriod int, expectedErr error) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	registryExpectedPrepares, registryStmts := prepareRegistryMockStmts(t, db, mock)
+	lockKeyExpectedPrepare, lockKeyStmt := prepareMockStmt(t, db, mock, lockKeySQL)
+	timezoneExpectedPrepare, timezoneStmt := prepareMockStmt(t, db, mock, selectTeamTimezoneSQL)
+	purgedExpectedPrepare, purgedStmt := prepareMockStmt(t, db, mock, selectTeamGamePurgedSQL)
+	watermarkExpectedPrepare, watermarkStmt := prepareMockStmt(t, db, mock, upsertTeamGameWatermarkSQL)
+	deleteScoresExpectedPrepare, deleteScoresStmt := prepareMockStmt(t, db, mock, deleteTeamScoresSQL)
+	insertScoresExpectedPrepare, insertScoresStmt := prepareMockStmt(t, db, mock, insertTeamScoresSQL)
+	stmts := preparedStatements{
+		forRegistryBySubject:    registryStmts,
+		lockKey:                 lockKeyStmt,
+		selectTeamTimezone:      timezoneStmt,
+		selectTeamGamePurged:    purgedStmt,
+		upsertTeamGameWatermark: watermarkStmt,
+		deleteTeamScores:        deleteScoresStmt,
+		insertTeamScores:        insertScoresStmt,
+	}
+
+	const gameDate = "2025-03-15"
+	mock.ExpectBegin()
+	registryExpectedPrepares[subjectTeam][operationSelectID].ExpectQuery().WithArgs(losAngelesLakersID).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(losAngelesLakersID))
+	timezoneExpectedPrepare.ExpectQuery().WithArgs(losAngelesLakersID).WillReturnRows(sqlmock.NewRows([]string{"timezone"}).AddRow(""))
+	// players of the team without substitutions yet aren't locked
+	if e.Event != eventGameStart {
+		mock.ExpectQuery(esc(selectSubstitutionsSQL)).WithArgs(e.League, losAngelesLakersID, gameDate, e.Timestamp).
+			WillReturnRows(sqlmock.NewRows([]string{"player", "event", "synthetic"}))
+	}
+	lockKeyExpectedPrepare.ExpectExec().WithArgs(e.League + ":team:" + losAngelesLakersID).WillReturnResult(driver.RowsAffected(1))
+	if e.Event != eventGameStart {
+		mock.ExpectQuery(esc(selectSubstitutionsSQL)).WithArgs(e.League, losAngelesLakersID, gameDate, e.Timestamp).
+			WillReturnRows(sqlmock.NewRows([]string{"player", "event", "synthetic"}))
+	}
+	purgedExpectedPrepare.ExpectQuery().WithArgs(e.League, losAngelesLakersID, gameDate).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	watermarkExpectedPrepare.ExpectQuery().WithArgs(e.League, losAngelesLakersID, gameDate, e.Timestamp, 0.0).
+		WillReturnRows(sqlmock.NewRows([]string{"late", "finalized_at", "state"}).AddRow(false, nil, state))
+	mock.ExpectQuery(`SELECT "state", "period" FROM "team_games"`).WithArgs(e.League, losAngelesLakersID, gameDate).
+		WillReturnRows(sqlmock.NewRows([]string{"state", "period"}).AddRow(state, period))
+	if expectedErr == nil {
+		var startedAt driver.Value
+		if e.Event == eventGameStart {
+			startedAt = e.Timestamp
+		}
+		// the started period is recorded and the score is recalculated by periods
+		mock.ExpectExec(`INSERT INTO "game_periods"`).WithArgs(e.League, losAngelesLakersID, gameDate, expectedPeriod, e.Timestamp).
+			WillReturnResult(driver.RowsAffected(1))
+		deleteScoresExpectedPrepare.ExpectExec().WithArgs(e.League, losAngelesLakersID, gameDate).WillReturnResult(driver.RowsAffected(1))
+		insertScoresExpectedPrepare.ExpectExec().WithArgs(e.League, losAngelesLakersID, gameDate).WillReturnResult(driver.RowsAffected(1))
+		mock.ExpectExec(`UPDATE "team_games" SET "state"`).
+			WithArgs(e.League, losAngelesLakersID, gameDate, gameTransitions[e.Event].to, expectedPeriod, startedAt, nil).
+			WillReturnResult(driver.RowsAffected(1))
+	}
+
+	tx, err := db.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = applyGameEvent(t.Context(), tx, config{leagues: defaultLeagues}, e, false, stmts)
+	if !errors.Is(err, expectedErr) {
+		t.Errorf("expected error %v, got %v", expectedErr, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestApplyGameEvent_GameStart(t *testing.T) {
+	e := event{Team: losAngelesLakersID, Timestamp: time.Date(2025, time.March, 15, 19, 30, 0, 0, time.UTC), Event: eventGameStart, League: defaultLeague}
+	testApplyGameEvent(t, e, gameStateScheduled, 0, 1, nil)
+}
+
+func TestApplyGameEvent_PeriodStart(t *testing.T) {
+	e := event{Team: losAngelesLakersID, Timestamp: time.Date(2025, time.March, 15, 20, 0, 0, 0, time.UTC), Event: eventPeriodStart, League: defaultLeague}
+	testApplyGameEvent(t, e, gameStateIntermission, 1, 2, nil)
+}
+
+func TestApplyGameEvent_InvalidTransition(t *testing.T) {
+	e := event{Team: losAngelesLakersID, Timestamp: time.Date(2025, time.March, 15, 19, 30, 0, 0, time.UTC), Event: eventPeriodStart, League: defaultLeague}
+	testApplyGameEvent(t, e, gameStateScheduled, 0, 0, errInvalidGameTransition)
+}
+
+func TestApplyGameEvent_EndBeforeRegulation(t *testing.T) {
+	e := event{Team: losAngelesLakersID, Timestamp: time.Date(2025, time.March, 15, 21, 0, 0, 0, time.UTC), Event: eventGameEnd, League: defaultLeague}
+	testApplyGameEvent(t, e, gameStateIntermission, 3, 3, errInvalidGameTransition)
+}
+
+func TestChangedIntervals(t *testing.T) {
+	substitutions := []substitution{
+		{"anthony-davis", eventEnter, false},
+		{"austin-reaves", eventExit, false},
+		{leBronJamesID, eventExit, true},
+		{"rui-hachimura", eventEnter, true},
+	}
+	for _, tc := range []struct {
+		e                 eventType
+		expectedEventType eventType
+		expectedPlayers   []string
+	}{
+		// players on the court are exited, whether entered by given or synthetic events
+		{eventPeriodEnd, eventExit, []string{"anthony-davis", "rui-hachimura"}},
+		{eventGameEnd, eventExit, []string{"anthony-davis", "rui-hachimura"}},
+		// only players exited at the end of the period are entered again, not the ones substituted
+		{eventPeriodStart, eventEnter, []string{leBronJamesID}},
+	} {
+		eventType, players := changedIntervals(tc.e, substitutions)
+		if eventType != tc.expectedEventType || !slices.Equal(players, tc.expectedPlayers) {
+			t.Errorf("%q: expected %q of %v, got %q of %v", tc.e, tc.expectedEventType, tc.expectedPlayers, eventType, players)
+		}
+	}
+}
+
+func TestEvent_Validate_GameEvents(t *testing.T) {
+	timestamp := time.Date(2025, time.March, 15, 19, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		e     event
+		valid bool
+	}{
+		{event{Team: losAngelesLakersID, Timestamp: timestamp, Event: eventGameStart, League: defaultLeague}, true},
+		{event{Team: losAngelesLakersID, Timestamp: timestamp, Event: eventPeriodEnd, League: defaultLeague}, true},
+		{event{Player: leBronJamesID, Team: losAngelesLakersID, Timestamp: timestamp, Event: eventGameEnd, League: defaultLeague}, false},
+		{event{Team: losAngelesLakersID, Timestamp: timestamp, Event: eventRebound, League: defaultLeague}, false},
+	} {
+		if err := tc.e.validate(defaultLeagues); (err == nil) != tc.valid {
+			t.Errorf("event %q: expected valid %v, got %v", tc.e, tc.valid, err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package internal
+
+import "fmt"
+
+// Redis sorted sets with all the members having the same score, so they are ordered lexicographically.
+// They let the statistics service list and search by prefix players, teams and seasons known to the system.
+// Players and teams are indexed by their identifiers which are derived from their names.
+// Indexes of players, teams and seasons are kept per league, see indexKey.
+const (
+	indexPlayers = "index:players"
+	indexTeams   = "index:teams"
+	indexSeasons = "index:seasons"
+	indexLeagues = "index:leagues"
+)
+
+// indexKey returns the Redis key of the index of the league
+func indexKey(league, index string) string {
+	return fmt.Sprintf("%s:%s", league, index)
+}
+
+var indexesBySubjects = map[subject]string{
+	subjectPlayer: indexPlayers,
+	subjectTeam:   indexTeams,
+}
@@ -0,0 +1,273 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ingestionMode string
+
+const (
+	ingestionModeSync  ingestionMode = "sync"  // events are processed before the response
+	ingestionModeAsync ingestionMode = "async" // events are queued, acknowledged with 202 Accepted, and processed by workers
+)
+
+// defaultIngestionWorkers is the number of workers processing queued events, unless INGESTION_WORKERS environment variable is specified
+const defaultIngestionWorkers = 4
+
+// ingestionPollInterval is the interval of polling the queue by an idle worker
+const ingestionPollInterval = 200 * time.Millisecond
+
+type queueState string
+
+// states of queued events
+const (
+	queueStateQueued    queueState = "queued"
+	queueStateProcessed queueState = "processed"
+	queueStateFailed    queueState = "failed" // the event is rejected, or moved to dead letters
+)
+
+const (
+	tableIngestionQueue table = "ingestion_queue"
+	tableDeadLetters    table = "dead_letters"
+)
+
+// insertQueuedEventSQL is an SQL statement to queue an event
+// Parameter placeholders are intended for:
+// $1: the event in JSON
+// $2: whether the event is given on admin override
+// $3: key of the order of processing, i.e. of the game given by the venue team and the game date in the league
+const insertQueuedEventSQL = `INSERT INTO "ingestion_queue" ("event", "override", "game_key") VALUES ($1, $2, $3) RETURNING "id"`
+
+// claimQueuedEventSQL is an SQL statement to claim the first queued event without earlier queued events of the same game.
+// An event claimed by another worker is skipped, and so are later events of its game, as the claimed one stays queued until processed.
+const claimQueuedEventSQL = `SELECT "id", "event", "override", "attempts" FROM "ingestion_queue" q
+WHERE "state" = 'queued' AND NOT EXISTS (
+	SELECT 1 FROM "ingestion_queue" p WHERE p."game_key" = q."game_key" AND p."state" = 'queued' AND p."id" < q."id"
+)
+ORDER BY "id"
+LIMIT 1
+FOR UPDATE SKIP LOCKED`
+
+// updateQueuedEventSQL is an SQL statement to record the result of processing the queued event given by $1 with the state $2 and the error $3
+const updateQueuedEventSQL = `UPDATE "ingestion_queue"
+SET "state" = $2, "error" = $3, "attempts" = "attempts" + 1, "processed_at" = NOW() AT TIME ZONE 'UTC'
+WHERE "id" = $1`
+
+// insertDeadLetterSQL is an SQL statement to store the event failed to be processed
+// Parameter placeholders are intended for:
+// $1: identifier of the queued event, or NULL
+// $2: the event in JSON
+// $3: whether the event is given on admin override
+// $4: the error
+// $5: number of attempts
+const insertDeadLetterSQL = `INSERT INTO "dead_letters" ("queue_id", "event", "override", "error", "attempts") VALUES ($1, $2, $3, $4, $5) RETURNING "id"`
+
+// selectQueuedEventSQL is an SQL statement to select the state of the queued event given by $1
+const selectQueuedEventSQL = `SELECT "state", COALESCE("error", ''), "attempts", "received_at", "processed_at" FROM "ingestion_queue" WHERE "id" = $1`
+
+// accepted is the response to an event queued in the asynchronous ingestion mode
+type accepted struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"` // URL of the status of processing
+}
+
+// ingestionStatus is the state of processing of a queued event
+type ingestionStatus struct {
+	ID          int64      `json:"id"`
+	State       queueState `json:"state"`
+	Error       string     `json:"error,omitempty"`
+	Attempts    int        `json:"attempts"`
+	ReceivedAt  time.Time  `json:"receivedAt"`
+	ProcessedAt *time.Time `json:"processedAt,omitempty"`
+}
+
+// enqueueEvent stores the validated event durably to be processed by a worker, and returns its identifier.
+// Events are ordered per game, i.e. by the venue team and the game date local to the venue, as the games of both teams are paired by them.
+// Teams are resolved to order events of the same game regardless of their names given,
+// and events of a visiting team without the home team are ordered as a game of their own, as they are unpaired anyway.
+func enqueueEvent(ctx context.Context, db *sql.DB, stmts preparedStatements, e event, override bool) (id int64, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	venueTeam, err := resolve(ctx, tx, stmts, subjectTeam, e.venueTeam())
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve venue team: %w", err)
+	}
+	venue, err := venueLocation(ctx, tx, stmts, venueTeam)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get venue location: %w", err)
+	}
+	queueKey := gameKey(e.League, venueTeam, e.gameDate(venue))
+
+	// events of the same game are queued one by one, so their identifiers follow the order of commits
+	if err = txExec(ctx, tx, stmts.lockKey, fmt.Sprintf("%s:%s", tableIngestionQueue, queueKey)); err != nil {
+		return 0, fmt.Errorf("failed to lock queue of %q: %w", queueKey, err)
+	}
+
+	eventJSON, err := json.Marshal(e)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if err = tx.QueryRowContext(ctx, insertQueuedEventSQL, eventJSON, override, queueKey).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to queue event: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// respondAccepted acknowledges the queued event with 202 Accepted and its identifier
+func respondAccepted(w http.ResponseWriter, id int64) {
+	status := fmt.Sprintf("/api/v1/event/%d/status", id)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", status)
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(accepted{ID: id, Status: status}); err != nil {
+		log.Println(fmt.Errorf("failed to write response of queued event %d: %w", id, err))
+	}
+}
+
+// ingestionStatusHandler responds the state of processing of the queued event
+func ingestionStatusHandler(ctx context.Context, db *sql.DB) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid event identifier %q", r.PathValue("id")))
+			return
+		}
+
+		status := ingestionStatus{ID: id}
+		var processedAt sql.NullTime
+		err = db.QueryRowContext(ctx, selectQueuedEventSQL, id).Scan(&status.State, &status.Error, &status.Attempts, &status.ReceivedAt, &processedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, fmt.Errorf("event %d is not found", id))
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to select event %d: %w", id, err))
+			return
+		}
+		if processedAt.Valid {
+			status.ProcessedAt = &processedAt.Time
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			log.Println(fmt.Errorf("failed to write status of event %d: %w", id, err))
+		}
+	}
+}
+
+// startIngestionWorkers starts the configured number of workers processing queued events until the context is done
+func startIngestionWorkers(ctx context.Context, cfg config, db *sql.DB, stmts preparedStatements, rdb *redis.Client) {
+	for range cfg.ingestionWorkers {
+		go func() {
+			for {
+				claimed, err := processQueuedEvent(ctx, cfg, db, stmts, rdb)
+				if err != nil {
+					log.Println(fmt.Errorf("failed to process queued event: %w", err))
+				}
+				if claimed && err == nil {
+					continue
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(ingestionPollInterval):
+				}
+			}
+		}()
+	}
+	log.Println(fmt.Sprintf("%d ingestion workers are started", cfg.ingestionWorkers))
+}
+
+// processQueuedEvent claims a queued event, processes it, and records the result. The event failed to be processed is moved to dead letters,
+// while the event rejected as an invalid one is only marked failed.
+// The claim is held until the result is recorded, so the event stays queued if the worker stops in between.
+func processQueuedEvent(ctx context.Context, cfg config, db *sql.DB, stmts preparedStatements, rdb *redis.Client) (claimed bool, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to open transaction: %w", err)
+	}
+	// the transaction is rolled back unless committed, e.g. when no event is claimed
+	defer func() {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			err = errors.Join(err, rollbackErr)
+		}
+	}()
+
+	var id int64
+	var eventJSON []byte
+	var override bool
+	var attempts int
+	err = tx.QueryRowContext(ctx, claimQueuedEventSQL).Scan(&id, &eventJSON, &override, &attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim queued event: %w", err)
+	}
+
+	var e event
+	processErr := json.Unmarshal(eventJSON, &e)
+	if processErr == nil {
+		processErr = retryTransaction(ctx, fmt.Sprintf("event %q", e), func() error {
+			return processEvent(ctx, cfg, e, override, db, stmts)
+		})
+	}
+
+	switch {
+	case processErr != nil && rejected(processErr):
+		if _, err = tx.ExecContext(ctx, updateQueuedEventSQL, id, queueStateFailed, processErr.Error()); err != nil {
+			return true, fmt.Errorf("failed to update queued event %d: %w", id, err)
+		}
+		log.Println(fmt.Sprintf("Queued event %d is rejected: %v", id, processErr))
+	case processErr != nil:
+		var deadLetterID int64
+		if err = tx.QueryRowContext(ctx, insertDeadLetterSQL, id, eventJSON, override, processErr.Error(), attempts+1).Scan(&deadLetterID); err != nil {
+			return true, fmt.Errorf("failed to insert dead letter of event %d: %w", id, err)
+		}
+		if _, err = tx.ExecContext(ctx, updateQueuedEventSQL, id, queueStateFailed, processErr.Error()); err != nil {
+			return true, fmt.Errorf("failed to update queued event %d: %w", id, err)
+		}
+		log.Println(fmt.Sprintf("Queued event %d is moved to dead letter %d: %v", id, deadLetterID, processErr))
+	default:
+		if _, err = tx.ExecContext(ctx, updateQueuedEventSQL, id, queueStateProcessed, nil); err != nil {
+			return true, fmt.Errorf("failed to update queued event %d: %w", id, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return true, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if processErr != nil {
+		return true, nil
+	}
+	log.Println(fmt.Sprintf("Queued event %d %q processed successfully", id, e))
+
+	return true, updateCaches(ctx, db, stmts, rdb)
+}
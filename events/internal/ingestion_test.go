@@ -0,0 +1,197 @@
+package internal
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIngestionStatusHandler(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	receivedAt := time.Date(2025, time.March, 15, 18, 45, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT "state"`).WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows([]string{"state", "error", "attempts", "received_at", "processed_at"}).AddRow("failed", "fouled out", 1, receivedAt, receivedAt.Add(time.Second)))
+	mock.ExpectQuery(`SELECT "state"`).WithArgs(int64(43)).WillReturnError(sql.ErrNoRows)
+
+	handler := ingestionStatusHandler(t.Context(), db)
+	// statuses are requested in the order of the expected queries
+	for _, tc := range []struct {
+		id                 string
+		expectedStatusCode int
+	}{
+		{"42", http.StatusOK},
+		{"43", http.StatusNotFound},
+		{"x", http.StatusBadRequest},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/event/"+tc.id+"/status", nil)
+		r.SetPathValue("id", tc.id)
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != tc.expectedStatusCode {
+			t.Errorf("expected status code %d for event %s, got %d", tc.expectedStatusCode, tc.id, w.Code)
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var status ingestionStatus
+		if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+			t.Fatalf("failed to decode status: %v", err)
+		}
+		if status.ID != 42 || status.State != queueStateFailed || status.Error != "fouled out" || status.Attempts != 1 || status.ProcessedAt == nil {
+			t.Errorf("unexpected status %+v", status)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestProcessQueuedEvent_Empty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "id", "event", "override", "attempts" FROM "ingestion_queue"`).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	claimed, err := processQueuedEvent(t.Context(), config{}, db, preparedStatements{}, nil)
+	if claimed || err != nil {
+		t.Errorf("expected no claimed event, got %v, %v", claimed, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestProcessQueuedEvent_DeadLetter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	// the event can't be processed as it's not a JSON object
+	eventJSON := []byte(`[]`)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "id", "event", "override", "attempts" FROM "ingestion_queue"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event", "override", "attempts"}).AddRow(7, eventJSON, false, 0))
+	mock.ExpectQuery(`INSERT INTO "dead_letters"`).WithArgs(int64(7), eventJSON, false, sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`UPDATE "ingestion_queue"`).WithArgs(int64(7), queueStateFailed, sqlmock.AnyArg()).WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectCommit()
+
+	claimed, err := processQueuedEvent(t.Context(), config{}, db, preparedStatements{}, nil)
+	if !claimed || err != nil {
+		t.Errorf("expected claimed event, got %v, %v", claimed, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestProcessQueuedEvent_Rejected(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	_, registryStmts := prepareRegistryMockStmts(t, db, mock)
+	_, lockKeyStmt := prepareMockStmt(t, db, mock, lockKeySQL)
+	_, timezoneStmt := prepareMockStmt(t, db, mock, selectTeamTimezoneSQL)
+	_, purgedStmt := prepareMockStmt(t, db, mock, selectTeamGamePurgedSQL)
+	stmts := preparedStatements{
+		forRegistryBySubject: registryStmts,
+		lockKey:              lockKeyStmt,
+		selectTeamTimezone:   timezoneStmt,
+		selectTeamGamePurged: purgedStmt,
+	}
+
+	// the event of the game with purged events is rejected rather than moved to dead letters
+	e := event{Team: losAngelesLakersID, Timestamp: time.Date(2025, time.March, 15, 19, 30, 0, 0, time.UTC), Event: eventGameStart, League: defaultLeague}
+	eventJSON, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "id", "event", "override", "attempts" FROM "ingestion_queue"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event", "override", "attempts"}).AddRow(7, eventJSON, false, 0))
+	// the event is processed on another connection, which the statements are prepared on again
+	mock.ExpectBegin()
+	mock.ExpectPrepare(esc(registrySQLs(subjectTeam)[operationSelectID])).
+		ExpectQuery().WithArgs(losAngelesLakersID).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(losAngelesLakersID))
+	mock.ExpectPrepare(esc(selectTeamTimezoneSQL)).ExpectQuery().WithArgs(losAngelesLakersID).WillReturnRows(sqlmock.NewRows([]string{"timezone"}).AddRow(""))
+	mock.ExpectPrepare(esc(lockKeySQL)).ExpectExec().WithArgs(defaultLeague + ":team:" + losAngelesLakersID).WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectPrepare(esc(selectTeamGamePurgedSQL)).ExpectQuery().WithArgs(defaultLeague, losAngelesLakersID, "2025-03-15").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+	mock.ExpectExec(`UPDATE "ingestion_queue"`).WithArgs(int64(7), queueStateFailed, sqlmock.AnyArg()).WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectCommit()
+
+	claimed, err := processQueuedEvent(t.Context(), config{leagues: defaultLeagues}, db, stmts, nil)
+	if !claimed || err != nil {
+		t.Errorf("expected claimed event, got %v, %v", claimed, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestEnqueueEvent_OrderedPerGame(t *testing.T) {
+	ctx := t.Context()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	expectedPrepares, registryStmts := prepareRegistryMockStmts(t, db, mock)
+	teams := expectedPrepares[subjectTeam]
+	expectedSelectTimezone, selectTeamTimezone := prepareMockStmt(t, db, mock, selectTeamTimezoneSQL)
+	expectedLockKey, lockKey := prepareMockStmt(t, db, mock, lockKeySQL)
+	stmts := preparedStatements{forRegistryBySubject: registryStmts, selectTeamTimezone: selectTeamTimezone, lockKey: lockKey}
+
+	// a late game in Los Angeles is on the local date of the venue for both teams
+	timestamp := time.Date(2025, time.March, 16, 4, 30, 0, 0, time.UTC)
+	queueKey := gameKey(defaultLeague, losAngelesLakersID, "2025-03-15")
+	for id, e := range []event{
+		{Player: leBronJamesID, Team: losAngelesLakersID, Timestamp: timestamp, Event: eventRebound, League: defaultLeague},
+		{Player: "jayson-tatum", Team: bostonCelticsID, HomeTeam: losAngelesLakersID, Timestamp: timestamp, Event: eventRebound, League: defaultLeague},
+	} {
+		mock.ExpectBegin()
+		teams[operationSelectID].ExpectQuery().WithArgs(losAngelesLakersID).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(losAngelesLakersID))
+		expectedSelectTimezone.ExpectQuery().WithArgs(losAngelesLakersID).WillReturnRows(sqlmock.NewRows([]string{"timezone"}).AddRow("America/Los_Angeles"))
+		expectedLockKey.ExpectExec().WithArgs("ingestion_queue:" + queueKey).WillReturnResult(driver.RowsAffected(1))
+		mock.ExpectQuery(`INSERT INTO "ingestion_queue"`).WithArgs(sqlmock.AnyArg(), false, queueKey).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id + 1))
+		mock.ExpectCommit()
+
+		if _, err := enqueueEvent(ctx, db, stmts, e, false); err != nil {
+			t.Fatalf("failed to enqueue event of %q: %v", e.Team, err)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+type seasonFormat string
+
+// naming of seasons of a league
+const (
+	seasonFormatSplit seasonFormat = "split" // seasons spanning two calendar years, e.g. "2024-25"
+	seasonFormatYear  seasonFormat = "year"  // calendar-year seasons, e.g. "2025"
+)
+
+// defaultLeague is the league of events not specifying one
+const defaultLeague = "nba"
+
+// league defines the season boundary, the game format and the scoring rules of a competition
+type league struct {
+	SeasonStartMonth time.Month     `json:"seasonStartMonth"` // games before the month belong to the previous season
+	SeasonFormat     seasonFormat   `json:"seasonFormat"`
+	Periods          int            `json:"periods"`
+	PeriodMinutes    float64        `json:"periodMinutes"`
+	FoulOutLimit     int            `json:"foulOutLimit"`
+	ShotValues       []int          `json:"shotValues"`
+	Calendar         seasonCalendar `json:"calendar"` // optional, seasons missing in the calendar are regular seasons
+}
+
+// leagues are keyed by their identifiers used in events, tables and Redis keys
+type leagues map[string]league
+
+// defaultLeagues are used unless LEAGUES_CONFIG environment variable points to a JSON file with other leagues
+var defaultLeagues = leagues{
+	"nba": {
+		SeasonStartMonth: time.October,
+		SeasonFormat:     seasonFormatSplit,
+		Periods:          4,
+		PeriodMinutes:    12,
+		FoulOutLimit:     6,
+		ShotValues:       []int{1, 2, 3},
+		Calendar:         nbaSeasonCalendar,
+	},
+	"wnba": {
+		SeasonStartMonth: time.May,
+		SeasonFormat:     seasonFormatYear,
+		Periods:          4,
+		PeriodMinutes:    10,
+		FoulOutLimit:     6,
+		ShotValues:       []int{1, 2, 3},
+		Calendar:         wnbaSeasonCalendar,
+	},
+	"fiba": {
+		SeasonStartMonth: time.September,
+		SeasonFormat:     seasonFormatSplit,
+		Periods:          4,
+		PeriodMinutes:    10,
+		FoulOutLimit:     5,
+		ShotValues:       []int{1, 2, 3},
+	},
+}
+
+// errGameLimitExceeded is returned when per-game statistics of a player break the rules of the league
+var errGameLimitExceeded = errors.New("game limit of the league exceeded")
+
+// loadLeagues reads the leagues from the JSON file replacing the default ones, e.g.
+//
+//	{"wnba": {"seasonStartMonth": 5, "seasonFormat": "year", "periods": 4, "periodMinutes": 10, "foulOutLimit": 6, "shotValues": [1, 2, 3]}}
+func loadLeagues(path string) (leagues, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read leagues: %w", err)
+	}
+
+	var result leagues
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal leagues: %w", err)
+	}
+
+	for id, league := range result {
+		if !idPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid league identifier %q: lower-cased letters and digits separated by dashes expected", id)
+		}
+		if err := league.validate(); err != nil {
+			return nil, fmt.Errorf("failed to validate league %q: %w", id, err)
+		}
+	}
+
+	return result, nil
+}
+
+// validate checks the league configuration is complete and consistent
+func (l league) validate() error {
+	if l.SeasonStartMonth < time.January || l.SeasonStartMonth > time.December {
+		return fmt.Errorf("invalid 'seasonStartMonth': %d", l.SeasonStartMonth)
+	}
+
+	if l.SeasonFormat != seasonFormatSplit && l.SeasonFormat != seasonFormatYear {
+		return fmt.Errorf("invalid 'seasonFormat' %q, %q or %q expected", l.SeasonFormat, seasonFormatSplit, seasonFormatYear)
+	}
+
+	if l.Periods < 1 || l.PeriodMinutes <= 0 {
+		return fmt.Errorf("invalid game format: %d periods of %v minutes", l.Periods, l.PeriodMinutes)
+	}
+
+	if l.FoulOutLimit < 1 {
+		return fmt.Errorf("invalid 'foulOutLimit': %d", l.FoulOutLimit)
+	}
+
+	if len(l.ShotValues) == 0 || slices.Min(l.ShotValues) < 1 {
+		return fmt.Errorf("invalid 'shotValues': %v", l.ShotValues)
+	}
+
+	if err := l.Calendar.validate(); err != nil {
+		return fmt.Errorf("invalid 'calendar': %w", err)
+	}
+
+	return nil
+}
+
+// season returns the season of the date in the format of the league, "2006-07" or "2006"
+func (l league) season(date time.Time) string {
+	startYear := date.Year()
+	if date.Month() < l.SeasonStartMonth {
+		startYear -= 1
+	}
+
+	if l.SeasonFormat == seasonFormatYear {
+		return fmt.Sprintf("%d", startYear)
+	}
+	return fmt.Sprintf("%d-%02d", startYear, (startYear+1)%100)
+}
+
+// regulationMinutes returns the duration of a game without overtimes
+func (l league) regulationMinutes() float64 {
+	return float64(l.Periods) * l.PeriodMinutes
+}
+
+// validateGame checks per-game fouls and minutes played of a player against the limits of the league
+func (l league) validateGame(fouls int, minutesPlayed float64) error {
+	if fouls > l.FoulOutLimit {
+		return fmt.Errorf("%w: %d fouls, fouled out at %d", errGameLimitExceeded, fouls, l.FoulOutLimit)
+	}
+
+	if minutesPlayed > l.regulationMinutes() {
+		return fmt.Errorf("%w: %.1f minutes played of %v", errGameLimitExceeded, minutesPlayed, l.regulationMinutes())
+	}
+
+	return nil
+}
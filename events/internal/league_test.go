@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLeague_Season(t *testing.T) {
+	for _, tc := range []struct {
+		league   string
+		date     time.Time
+		expected string
+	}{
+		{"nba", time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC), "2024-25"},
+		{"nba", time.Date(2025, time.September, 30, 0, 0, 0, 0, time.UTC), "2024-25"},
+		{"nba", time.Date(1999, time.December, 25, 0, 0, 0, 0, time.UTC), "1999-00"},
+		{"wnba", time.Date(2025, time.May, 16, 0, 0, 0, 0, time.UTC), "2025"},
+		{"wnba", time.Date(2025, time.October, 10, 0, 0, 0, 0, time.UTC), "2025"},
+		{"wnba", time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC), "2025"},
+		{"fiba", time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC), "2025-26"},
+	} {
+		if season := defaultLeagues[tc.league].season(tc.date); season != tc.expected {
+			t.Errorf("season of %s in %s: expected %s, got %s", tc.date, tc.league, tc.expected, season)
+		}
+	}
+}
+
+func TestLeague_Validate(t *testing.T) {
+	for id, league := range defaultLeagues {
+		if err := league.validate(); err != nil {
+			t.Errorf("default league %q is invalid: %v", id, err)
+		}
+	}
+
+	valid := defaultLeagues["fiba"]
+	for name, modify := range map[string]func(l *league){
+		"month":       func(l *league) { l.SeasonStartMonth = 13 },
+		"format":      func(l *league) { l.SeasonFormat = "quarter" },
+		"periods":     func(l *league) { l.Periods = 0 },
+		"foul-out":    func(l *league) { l.FoulOutLimit = 0 },
+		"shot values": func(l *league) { l.ShotValues = []int{0, 2, 3} },
+		"calendar":    func(l *league) { l.Calendar = seasonCalendar{"2025-26": {"finals": "2026-06-01"}} },
+	} {
+		invalid := valid
+		modify(&invalid)
+		if err := invalid.validate(); err == nil {
+			t.Errorf("expected error for invalid %s", name)
+		}
+	}
+}
+
+func TestLeague_ValidateGame(t *testing.T) {
+	nba, wnba, fiba := defaultLeagues["nba"], defaultLeagues["wnba"], defaultLeagues["fiba"]
+
+	if err := nba.validateGame(6, 48); err != nil {
+		t.Errorf("unexpected error for NBA game: %v", err)
+	}
+	if err := wnba.validateGame(6, 45); !errors.Is(err, errGameLimitExceeded) {
+		t.Errorf("expected minutes limit exceeded for WNBA game, got %v", err)
+	}
+	if err := fiba.validateGame(6, 30); !errors.Is(err, errGameLimitExceeded) {
+		t.Errorf("expected foul-out limit exceeded for FIBA game, got %v", err)
+	}
+}
+
+func TestEvent_Validate_League(t *testing.T) {
+	shot := event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.UTC), Event: eventShot, Points: 3, League: "wnba"}
+	if err := shot.validate(defaultLeagues); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	shot.League = "euroleague"
+	if err := shot.validate(defaultLeagues); err == nil {
+		t.Error("expected error for unknown league")
+	}
+
+	// a league with a 4-point line
+	leagues := leagues{"big3": {SeasonStartMonth: time.June, SeasonFormat: seasonFormatYear, Periods: 1, PeriodMinutes: 60, FoulOutLimit: 5, ShotValues: []int{1, 2, 3, 4}}}
+	shot.League, shot.Points = "big3", 4
+	if err := shot.validate(leagues); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	shot.League = "nba"
+	if err := shot.validate(defaultLeagues); err == nil {
+		t.Error("expected error for 4-point shot in NBA")
+	}
+}
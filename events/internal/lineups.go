@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// lineupSize is the number of players of a team on the court
+const lineupSize = 5
+
+// lineupSizes are the sizes of units lineups are cached by, i.e. five-man units, and trios and pairs within them
+var lineupSizes = []int{5, 3, 2}
+
+// selectCourtEventsSQL is an SQL statement to select the substitutions of the team and the shots of both teams in the game in the order of their timestamps.
+// Shots go before substitutions at the same timestamp, so a shot at the moment of a substitution counts for the players leaving the court,
+// and substitutions of a player are in the order of substitutionsOrder.
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+// $4: opponent
+//
+// Events are selected from the partitions of the timestamps the game date in any timezone spans.
+const selectCourtEventsSQL = `SELECT "team", "player", "event", "timestamp", "value" FROM "events"
+WHERE "league" = $1 AND "game_date" = $3 AND ("team" = $2 OR "team" = $4 AND "event" = 'shot') AND "event" IN ('enter', 'exit', 'shot')
+	AND "timestamp" >= $3::date - 1 AND "timestamp" < $3::date + 2
+ORDER BY "timestamp", "event" <> 'shot', "synthetic" AND "event" = 'enter' DESC, "synthetic" AND "event" = 'exit', "player"`
+
+// SQL statements to replace the lineups of the team in the game
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+// $4: players of the unit
+// $5: season
+// $6: season type
+// $7: minutes
+// $8: points for
+// $9: points against
+const (
+	deleteGameLineupsSQL = `DELETE FROM "lineups_by_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3`
+	insertGameLineupSQL  = `INSERT INTO "lineups_by_games" ("league", "team", "game_date", "players", "season", "season_type", "minutes", "points_for", "points_against")
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+)
+
+// selectSeasonLineupsSQL is an SQL statement to select the five-man units of the team in the season of the type, summed over the games
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: season
+// $4: season type
+const selectSeasonLineupsSQL = `SELECT "players", SUM("minutes"), SUM("points_for"), SUM("points_against") FROM "lineups_by_games"
+WHERE "league" = $1 AND "team" = $2 AND "season" = $3 AND "season_type" = $4
+GROUP BY "players"`
+
+// courtEvent is a substitution of the team or a shot of either team
+type courtEvent struct {
+	team, player string
+	event        eventType
+	timestamp    time.Time
+	value        int
+}
+
+// lineup is a unit of players of the team on the court together, with the points scored by the team and its opponent meanwhile
+type lineup struct {
+	Players       []string `json:"players"`
+	Minutes       float64  `json:"minutes"`
+	PointsFor     int      `json:"pointsFor"`
+	PointsAgainst int      `json:"pointsAgainst"`
+}
+
+// lineupsKey returns the Redis key of the units of the size of the team in the league for the season of the given type
+func lineupsKey(league, team, season string, st seasonType, size int) string {
+	return fmt.Sprintf("%s:lineups:%d", statisticsKey(league, subjectTeam, team, season, st), size)
+}
+
+// newGameLineups reconstructs the five-man units of the team from the events of the game in the order of their timestamps.
+// A player is on the court from an 'enter' event until an 'exit' event, and intervals with other numbers of players on the court aren't counted.
+// Shots after the last substitution count for the unit on the court, while its minutes are counted until the substitution ending them.
+func newGameLineups(team string, events []courtEvent) []lineup {
+	onCourt := map[string]bool{}
+	byUnits := map[string]*lineup{}
+	var since time.Time
+
+	unit := func() *lineup {
+		if len(onCourt) != lineupSize {
+			return nil
+		}
+		players := slices.Sorted(maps.Keys(onCourt))
+		key := strings.Join(players, ",")
+		l, ok := byUnits[key]
+		if !ok {
+			l = &lineup{Players: players}
+			byUnits[key] = l
+		}
+		return l
+	}
+
+	for _, e := range events {
+		switch {
+		case e.event == eventShot:
+			if l := unit(); l != nil {
+				if e.team == team {
+					l.PointsFor += e.value
+				} else {
+					l.PointsAgainst += e.value
+				}
+			}
+			continue
+		case e.team != team:
+			continue
+		}
+
+		if l := unit(); l != nil {
+			l.Minutes += e.timestamp.Sub(since).Minutes()
+		}
+		since = e.timestamp
+		if e.event == eventEnter {
+			onCourt[e.player] = true
+		} else {
+			delete(onCourt, e.player)
+		}
+	}
+
+	lineups := make([]lineup, 0, len(byUnits))
+	for _, l := range byUnits {
+		lineups = append(lineups, *l)
+	}
+	slices.SortFunc(lineups, func(a, b lineup) int { return slices.Compare(a.Players, b.Players) })
+
+	return lineups
+}
+
+// combinations returns the combinations of the size of the sorted players, in lexicographic order
+func combinations(players []string, size int) [][]string {
+	if size == 0 {
+		return [][]string{{}}
+	}
+
+	var result [][]string
+	for i := 0; i <= len(players)-size; i++ {
+		for _, rest := range combinations(players[i+1:], size-1) {
+			result = append(result, append([]string{players[i]}, rest...))
+		}
+	}
+
+	return result
+}
+
+// newLineups sums the five-man units by their units of the size, ordered by minutes
+func newLineups(units []lineup, size int) []lineup {
+	byUnits := map[string]*lineup{}
+	for _, u := range units {
+		for _, players := range combinations(u.Players, size) {
+			key := strings.Join(players, ",")
+			l, ok := byUnits[key]
+			if !ok {
+				l = &lineup{Players: players}
+				byUnits[key] = l
+			}
+			l.Minutes += u.Minutes
+			l.PointsFor += u.PointsFor
+			l.PointsAgainst += u.PointsAgainst
+		}
+	}
+
+	lineups := make([]lineup, 0, len(byUnits))
+	for _, l := range byUnits {
+		lineups = append(lineups, *l)
+	}
+	slices.SortFunc(lineups, func(a, b lineup) int {
+		return cmp.Or(cmp.Compare(b.Minutes, a.Minutes), slices.Compare(a.Players, b.Players))
+	})
+
+	return lineups
+}
+
+// selectCourtEvents selects the substitutions of the team and the shots of both teams in the game
+func selectCourtEvents(ctx context.Context, tx *sql.Tx, game pairedGame) ([]courtEvent, error) {
+	rows, err := tx.QueryContext(ctx, selectCourtEventsSQL, game.league, game.team, game.gameDate, game.opponent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select events of game %s: %w", game, err)
+	}
+	defer closeIt("rows", rows)
+
+	var events []courtEvent
+	for rows.Next() {
+		var e courtEvent
+		if err := rows.Scan(&e.team, &e.player, &e.event, &e.timestamp, &e.value); err != nil {
+			return nil, fmt.Errorf("failed to scan event of game %s: %w", game, err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to select events of game %s: %w", game, err)
+	}
+
+	return events, nil
+}
+
+// updateLineups reconstructs the lineups of the team from the events of the game. The lineups of the team in the season are cached by cacheLineups.
+func updateLineups(ctx context.Context, tx *sql.Tx, game pairedGame, events []courtEvent) error {
+	if _, err := tx.ExecContext(ctx, deleteGameLineupsSQL, game.league, game.team, game.gameDate); err != nil {
+		return fmt.Errorf("failed to delete lineups of game %s: %w", game, err)
+	}
+	for _, l := range newGameLineups(game.team, events) {
+		if _, err := tx.ExecContext(ctx, insertGameLineupSQL,
+			game.league, game.team, game.gameDate, pq.Array(l.Players), game.season, game.seasonType, l.Minutes, l.PointsFor, l.PointsAgainst,
+		); err != nil {
+			return fmt.Errorf("failed to insert lineup %q of game %s: %w", l.Players, game, err)
+		}
+	}
+
+	return nil
+}
+
+// cacheLineups copies the lineups of the team in the season to Redis
+func cacheLineups(ctx context.Context, tx *sql.Tx, rdb *redis.Client, c seasonCache) error {
+	rows, err := tx.QueryContext(ctx, selectSeasonLineupsSQL, c.league, c.id, c.season, c.seasonType)
+	if err != nil {
+		return fmt.Errorf("failed to select lineups of %q in %s season %s: %w", c.id, c.league, c.season, err)
+	}
+
+	var units []lineup
+	for rows.Next() {
+		var l lineup
+		if err := rows.Scan(pq.Array(&l.Players), &l.Minutes, &l.PointsFor, &l.PointsAgainst); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan lineup of %q in %s season %s: %w", c.id, c.league, c.season, err)
+		}
+		units = append(units, l)
+	}
+	closeIt("rows", rows)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to select lineups of %q in %s season %s: %w", c.id, c.league, c.season, err)
+	}
+
+	if _, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, size := range lineupSizes {
+			valueJSON, err := json.Marshal(newLineups(units, size))
+			if err != nil {
+				return fmt.Errorf("failed to marshal lineups: %w", err)
+			}
+			pipe.Set(ctx, lineupsKey(c.league, c.id, c.season, c.seasonType, size), valueJSON, 0)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to set to Redis lineups of %q in %s season %s: %w", c.id, c.league, c.season, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNewGameLineups(t *testing.T) {
+	start := time.Date(2025, time.March, 15, 19, 30, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	var events []courtEvent
+	for _, player := range []string{"p1", "p2", "p3", "p4", "p5"} {
+		events = append(events, courtEvent{team: losAngelesLakersID, player: player, event: eventEnter, timestamp: at(0)})
+	}
+	events = append(events,
+		courtEvent{team: losAngelesLakersID, player: "p1", event: eventShot, timestamp: at(1), value: 2},
+		courtEvent{team: bostonCelticsID, player: "b1", event: eventShot, timestamp: at(2), value: 3},
+		// a shot at the moment of a substitution counts for the unit leaving the court
+		courtEvent{team: losAngelesLakersID, player: "p2", event: eventShot, timestamp: at(4), value: 2},
+		courtEvent{team: losAngelesLakersID, player: "p5", event: eventExit, timestamp: at(4)},
+		courtEvent{team: losAngelesLakersID, player: "p6", event: eventEnter, timestamp: at(4)},
+		courtEvent{team: losAngelesLakersID, player: "p6", event: eventShot, timestamp: at(6), value: 3},
+		// intervals with four players on the court aren't counted
+		courtEvent{team: losAngelesLakersID, player: "p1", event: eventExit, timestamp: at(10)},
+		courtEvent{team: bostonCelticsID, player: "b1", event: eventShot, timestamp: at(11), value: 2},
+	)
+
+	lineups := newGameLineups(losAngelesLakersID, events)
+	expected := []lineup{
+		{Players: []string{"p1", "p2", "p3", "p4", "p5"}, Minutes: 4, PointsFor: 4, PointsAgainst: 3},
+		{Players: []string{"p1", "p2", "p3", "p4", "p6"}, Minutes: 6, PointsFor: 3},
+	}
+	if !slices.EqualFunc(lineups, expected, func(a, b lineup) bool {
+		return slices.Equal(a.Players, b.Players) && a.Minutes == b.Minutes && a.PointsFor == b.PointsFor && a.PointsAgainst == b.PointsAgainst
+	}) {
+		t.Fatalf("expected %+v, got %+v", expected, lineups)
+	}
+
+	// trios and pairs are summed over the units they are part of, ordered by minutes
+	trios := newLineups(lineups, 3)
+	if len(trios) != 16 {
+		t.Fatalf("expected 16 trios, got %d", len(trios))
+	}
+	if top := trios[0]; !slices.Equal(top.Players, []string{"p1", "p2", "p3"}) || top.Minutes != 10 || top.PointsFor != 7 || top.PointsAgainst != 3 {
+		t.Errorf("unexpected top trio %+v", top)
+	}
+	if pairs := newLineups(lineups, 2); len(pairs) != 14 {
+		t.Errorf("expected 14 pairs, got %d", len(pairs))
+	}
+}
+
+func TestCombinations(t *testing.T) {
+	players := []string{"a", "b", "c", "d"}
+	actual := combinations(players, 2)
+	expected := [][]string{{"a", "b"}, {"a", "c"}, {"a", "d"}, {"b", "c"}, {"b", "d"}, {"c", "d"}}
+	if !slices.EqualFunc(actual, expected, slices.Equal) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
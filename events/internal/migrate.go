@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// migrationsFS holds numbered migrations "{version}_{name}.up.sql" and "{version}_{name}.down.sql" applied in the order of their versions
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var errUnknownSchemaVersion = errors.New("unknown schema version")
+
+// migrationsLockID is the key of the Postgres advisory lock, so only one of the replicas starting at once migrates the schema
+const migrationsLockID int64 = 0x4e4241_6d6967 // "NBA", "mig"
+
+const (
+	createTableSchemaMigrationsSQL = `CREATE TABLE IF NOT EXISTS "public"."schema_migrations" (
+"version" int4 NOT NULL,
+"name" text NOT NULL,
+"applied_at" timestamp NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+PRIMARY KEY ("version"));`
+	selectSchemaVersionsSQL = `SELECT "version" FROM "schema_migrations" ORDER BY "version"`
+	insertSchemaVersionSQL  = `INSERT INTO "schema_migrations" ("version", "name") VALUES ($1, $2)`
+	deleteSchemaVersionSQL  = `DELETE FROM "schema_migrations" WHERE "version" = $1`
+	lockMigrationsSQL       = `SELECT pg_advisory_lock($1)`
+	unlockMigrationsSQL     = `SELECT pg_advisory_unlock($1)`
+)
+
+type migration struct {
+	version  int
+	name     string
+	up, down string
+}
+
+// loadMigrations reads the embedded migrations, which must have both up and down parts and consecutive versions starting from 1
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*migration{}
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		}
+		if m.name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %q and %q", version, m.name, match[2])
+		}
+
+		data, err := fs.ReadFile(fsys, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+		if match[3] == "up" {
+			m.up = string(data)
+		} else {
+			m.down = string(data)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for version := 1; version <= len(byVersion); version++ {
+		m, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("migration %d is missing", version)
+		}
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d %q misses up or down part", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	return migrations, nil
+}
+
+// migrate applies up or down migrations to reach the target version holding the advisory lock.
+// It refuses to migrate the schema of a version it doesn't know, e.g. migrated by a newer release.
+func migrate(ctx context.Context, db *sql.DB, migrations []migration, target int) error {
+	if target < 0 || target > len(migrations) {
+		return fmt.Errorf("invalid target version %d, from 0 to %d expected", target, len(migrations))
+	}
+
+	// the session-level advisory lock must be released on the same connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer closeIt("connection", conn)
+
+	if _, err := conn.ExecContext(ctx, lockMigrationsSQL, migrationsLockID); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), unlockMigrationsSQL, migrationsLockID); err != nil {
+			log.Println(fmt.Errorf("failed to unlock migrations: %w", err))
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, createTableSchemaMigrationsSQL); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	current, err := schemaVersion(ctx, conn, migrations)
+	if err != nil {
+		return err
+	}
+
+	for i := current; i < target; i++ {
+		m := migrations[i]
+		if err := applyMigration(ctx, conn, m.up, insertSchemaVersionSQL, m.version, m.name); err != nil {
+			return fmt.Errorf("failed to apply migration %d %q: %w", m.version, m.name, err)
+		}
+		log.Println(fmt.Sprintf("Migration %d %q is applied", m.version, m.name))
+	}
+
+	for i := current - 1; i >= target; i-- {
+		m := migrations[i]
+		if err := applyMigration(ctx, conn, m.down, deleteSchemaVersionSQL, m.version); err != nil {
+			return fmt.Errorf("failed to revert migration %d %q: %w", m.version, m.name, err)
+		}
+		log.Println(fmt.Sprintf("Migration %d %q is reverted", m.version, m.name))
+	}
+
+	log.Println(fmt.Sprintf("Schema version is %d", target))
+	return nil
+}
+
+// schemaVersion returns the version of the schema, i.e. the number of applied migrations, which all must be known
+func schemaVersion(ctx context.Context, conn *sql.Conn, migrations []migration) (int, error) {
+	rows, err := conn.QueryContext(ctx, selectSchemaVersionsSQL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select schema versions: %w", err)
+	}
+	defer closeIt("rows", rows)
+
+	var versions []int
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return 0, fmt.Errorf("failed to scan schema version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to select schema versions: %w", err)
+	}
+
+	// applied versions are consecutive as migrations are applied and reverted in order
+	for i, version := range versions {
+		if version != i+1 || version > len(migrations) {
+			return 0, fmt.Errorf("%w %d, versions from 1 to %d are known", errUnknownSchemaVersion, slices.Max(versions), len(migrations))
+		}
+	}
+
+	return len(versions), nil
+}
+
+// applyMigration executes the migration SQL and records the change of the version in the same transaction
+func applyMigration(ctx context.Context, conn *sql.Conn, migrationSQL, versionSQL string, versionArgs ...any) (err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, migrationSQL); err != nil {
+		return fmt.Errorf("failed to execute migration: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, versionSQL, versionArgs...); err != nil {
+		return fmt.Errorf("failed to update schema version: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+DROP VIEW IF EXISTS "public"."players_by_games_all";
+DROP TABLE IF EXISTS "public"."players_teams_statistics_history";
+DROP TABLE IF EXISTS "public"."teams_statistics_history";
+DROP TABLE IF EXISTS "public"."players_statistics_history";
+DROP TABLE IF EXISTS "public"."players_by_games_history";
+DROP FUNCTION IF EXISTS "reject_closed_season_change"();
+DROP TABLE IF EXISTS "public"."closed_seasons";
+DROP TABLE IF EXISTS "public"."players_teams_statistics";
+DROP TABLE IF EXISTS "public"."teams_statistics";
+DROP TABLE IF EXISTS "public"."players_statistics";
+DROP TABLE IF EXISTS "public"."players_by_games";
+DROP TABLE IF EXISTS "public"."events";
+DROP TABLE IF EXISTS "public"."team_games";
+DROP TABLE IF EXISTS "public"."rosters";
+DROP TABLE IF EXISTS "public"."teams_aliases";
+DROP TABLE IF EXISTS "public"."players_aliases";
+DROP TABLE IF EXISTS "public"."teams";
+DROP TABLE IF EXISTS "public"."players";
@@ -0,0 +1,268 @@
+-- Initial schema: the registry, rosters, retention of raw events, events partitioned by months,
+-- per-game rows partitioned by seasons, statistics, and history of closed seasons.
+--
+-- Databases created by the baseline release before migrations hold unpartitioned events, per-game rows and statistics
+-- without leagues and season types, keyed by names of players and teams. Their tables are renamed out of the way first,
+-- and their data is converted into the new tables at the end.
+
+DO $$
+BEGIN
+	IF EXISTS (
+		SELECT 1 FROM "pg_class" c JOIN "pg_namespace" n ON n."oid" = c."relnamespace"
+		WHERE n."nspname" = 'public' AND c."relname" = 'events' AND c."relkind" = 'r'
+	) THEN
+		ALTER TABLE "public"."events" RENAME TO "baseline_events";
+		ALTER INDEX "public"."events_pkey" RENAME TO "baseline_events_pkey";
+		ALTER TABLE "public"."players_by_games" RENAME TO "baseline_players_by_games";
+		ALTER INDEX "public"."players_by_games_pkey" RENAME TO "baseline_players_by_games_pkey";
+		ALTER TABLE "public"."players_statistics" RENAME TO "baseline_players_statistics";
+		ALTER INDEX "public"."players_statistics_pkey" RENAME TO "baseline_players_statistics_pkey";
+		ALTER TABLE "public"."teams_statistics" RENAME TO "baseline_teams_statistics";
+		ALTER INDEX "public"."teams_statistics_pkey" RENAME TO "baseline_teams_statistics_pkey";
+	END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS "public"."players" (
+"id" text NOT NULL CHECK (id ~ '^[a-z0-9]+(-[a-z0-9]+)*$'),
+"name" text NOT NULL,
+PRIMARY KEY ("id"));
+
+CREATE TABLE IF NOT EXISTS "public"."teams" (
+"id" text NOT NULL CHECK (id ~ '^[a-z0-9]+(-[a-z0-9]+)*$'),
+"name" text NOT NULL,
+"timezone" text,
+PRIMARY KEY ("id"));
+
+CREATE TABLE IF NOT EXISTS "public"."players_aliases" (
+"alias" text NOT NULL,
+"player" text NOT NULL REFERENCES "players" ("id"),
+"processed" bool NOT NULL DEFAULT false,
+PRIMARY KEY ("alias"));
+
+CREATE TABLE IF NOT EXISTS "public"."teams_aliases" (
+"alias" text NOT NULL,
+"team" text NOT NULL REFERENCES "teams" ("id"),
+"processed" bool NOT NULL DEFAULT false,
+PRIMARY KEY ("alias"));
+
+CREATE TABLE IF NOT EXISTS "public"."rosters" (
+"team" text NOT NULL REFERENCES "teams" ("id"),
+"player" text NOT NULL REFERENCES "players" ("id"),
+"valid_from" date NOT NULL,
+"valid_to" date CHECK (valid_to >= valid_from),
+PRIMARY KEY ("team", "player", "valid_from"));
+
+CREATE TABLE IF NOT EXISTS "public"."team_games" (
+"league" text NOT NULL DEFAULT 'nba',
+"team" text NOT NULL,
+"game_date" date NOT NULL,
+"finalized_at" timestamp,
+"purged_at" timestamp CHECK (purged_at IS NULL OR finalized_at IS NOT NULL),
+"archive" text,
+"archived_events" int4,
+PRIMARY KEY ("league", "team", "game_date"));
+
+CREATE TABLE IF NOT EXISTS "public"."events" (
+"league" text NOT NULL DEFAULT 'nba',
+"player" text NOT NULL,
+"team" text NOT NULL,
+"timestamp" timestamp NOT NULL,
+"event" text NOT NULL CHECK (event IN ('shot', 'rebound', 'assist', 'steal', 'block', 'foul', 'turnover', 'enter', 'exit')),
+"game_date" date NOT NULL,
+"value" int2 NOT NULL DEFAULT 0,
+"home_team" text,
+PRIMARY KEY ("player", "timestamp")
+) PARTITION BY RANGE ("timestamp");
+CREATE TABLE IF NOT EXISTS "public"."events_default" PARTITION OF "events" DEFAULT;
+CREATE INDEX IF NOT EXISTS "events_player_game_idx" ON "events" ("league", "player", "team", "game_date", "event");
+CREATE INDEX IF NOT EXISTS "events_team_game_idx" ON "events" ("league", "team", "game_date");
+
+CREATE TABLE IF NOT EXISTS "public"."players_by_games" (
+"league" text NOT NULL DEFAULT 'nba',
+"player" text NOT NULL,
+"team" text NOT NULL,
+"game_date" date NOT NULL,
+"season" text NOT NULL,
+"season_type" text NOT NULL DEFAULT 'regular' CHECK (season_type IN ('preseason', 'regular', 'playin', 'playoffs')),
+"points" int4 NOT NULL DEFAULT 0 CHECK (points >= 0),
+"rebounds" int4 NOT NULL DEFAULT 0 CHECK (rebounds >= 0),
+"assists" int4 NOT NULL DEFAULT 0 CHECK (assists >= 0),
+"steals" int4 NOT NULL DEFAULT 0 CHECK (steals >= 0),
+"blocks" int4 NOT NULL DEFAULT 0 CHECK (blocks >= 0),
+"fouls" int2 NOT NULL DEFAULT '0'::smallint CHECK (fouls >= 0),
+"turnovers" int4 NOT NULL DEFAULT 0 CHECK (turnovers >= 0),
+"minutes_played" float4 NOT NULL DEFAULT 0 CHECK (minutes_played >= (0.0)::double precision),
+"entered" timestamp,
+PRIMARY KEY ("league", "player", "game_date", "season")
+) PARTITION BY LIST ("season");
+CREATE TABLE IF NOT EXISTS "public"."players_by_games_default" PARTITION OF "players_by_games" DEFAULT;
+CREATE INDEX IF NOT EXISTS "players_by_games_team_idx" ON "players_by_games" ("league", "team", "season", "season_type");
+
+CREATE TABLE IF NOT EXISTS "public"."players_statistics" (
+"league" text NOT NULL DEFAULT 'nba',
+"player" text NOT NULL,
+"season" text NOT NULL,
+"season_type" text NOT NULL DEFAULT 'regular' CHECK (season_type IN ('preseason', 'regular', 'playin', 'playoffs')),
+"points" float4 NOT NULL DEFAULT 0 CHECK (points >= (0.0)::double precision),
+"rebounds" float4 NOT NULL DEFAULT 0 CHECK (rebounds >= (0.0)::double precision),
+"assists" float4 NOT NULL DEFAULT 0 CHECK (assists >= (0.0)::double precision),
+"steals" float4 NOT NULL DEFAULT 0 CHECK (steals >= (0.0)::double precision),
+"blocks" float4 NOT NULL DEFAULT 0 CHECK (blocks >= (0.0)::double precision),
+"fouls" float4 NOT NULL DEFAULT 0 CHECK (fouls >= (0.0)::double precision),
+"turnovers" float4 NOT NULL DEFAULT 0 CHECK (turnovers >= (0.0)::double precision),
+"minutes_played" float4 NOT NULL DEFAULT 0 CHECK (minutes_played >= (0.0)::double precision),
+"processed" bool NOT NULL DEFAULT false,
+PRIMARY KEY ("league", "player", "season", "season_type"));
+
+CREATE TABLE IF NOT EXISTS "public"."teams_statistics" (
+"league" text NOT NULL DEFAULT 'nba',
+"team" text NOT NULL,
+"season" text NOT NULL,
+"season_type" text NOT NULL DEFAULT 'regular' CHECK (season_type IN ('preseason', 'regular', 'playin', 'playoffs')),
+"points" float4 NOT NULL DEFAULT 0 CHECK (points >= (0.0)::double precision),
+"rebounds" float4 NOT NULL DEFAULT 0 CHECK (rebounds >= (0.0)::double precision),
+"assists" float4 NOT NULL DEFAULT 0 CHECK (assists >= (0.0)::double precision),
+"steals" float4 NOT NULL DEFAULT 0 CHECK (steals >= (0.0)::double precision),
+"blocks" float4 NOT NULL DEFAULT 0 CHECK (blocks >= (0.0)::double precision),
+"fouls" float4 NOT NULL DEFAULT 0 CHECK (fouls >= (0.0)::double precision),
+"turnovers" float4 NOT NULL DEFAULT 0 CHECK (turnovers >= (0.0)::double precision),
+"minutes_played" float4 NOT NULL DEFAULT 0 CHECK (minutes_played >= (0.0)::double precision),
+"processed" bool NOT NULL DEFAULT false,
+PRIMARY KEY ("league", "team", "season", "season_type"));
+
+CREATE TABLE IF NOT EXISTS "public"."players_teams_statistics" (
+"league" text NOT NULL DEFAULT 'nba',
+"player" text NOT NULL,
+"team" text NOT NULL,
+"season" text NOT NULL,
+"season_type" text NOT NULL DEFAULT 'regular' CHECK (season_type IN ('preseason', 'regular', 'playin', 'playoffs')),
+"points" float4 NOT NULL DEFAULT 0 CHECK (points >= (0.0)::double precision),
+"rebounds" float4 NOT NULL DEFAULT 0 CHECK (rebounds >= (0.0)::double precision),
+"assists" float4 NOT NULL DEFAULT 0 CHECK (assists >= (0.0)::double precision),
+"steals" float4 NOT NULL DEFAULT 0 CHECK (steals >= (0.0)::double precision),
+"blocks" float4 NOT NULL DEFAULT 0 CHECK (blocks >= (0.0)::double precision),
+"fouls" float4 NOT NULL DEFAULT 0 CHECK (fouls >= (0.0)::double precision),
+"turnovers" float4 NOT NULL DEFAULT 0 CHECK (turnovers >= (0.0)::double precision),
+"minutes_played" float4 NOT NULL DEFAULT 0 CHECK (minutes_played >= (0.0)::double precision),
+"processed" bool NOT NULL DEFAULT false,
+PRIMARY KEY ("league", "player", "team", "season", "season_type"));
+
+CREATE TABLE IF NOT EXISTS "public"."closed_seasons" (
+"league" text NOT NULL,
+"season" text NOT NULL,
+"closed_at" timestamp NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+PRIMARY KEY ("league", "season"));
+
+CREATE TABLE IF NOT EXISTS "public"."players_by_games_history" (LIKE "players_by_games" INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS "public"."players_statistics_history" (LIKE "players_statistics" INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS "public"."teams_statistics_history" (LIKE "teams_statistics" INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS "public"."players_teams_statistics_history" (LIKE "players_teams_statistics" INCLUDING ALL);
+
+CREATE OR REPLACE FUNCTION "reject_closed_season_change"() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'statistics of closed seasons are immutable, % on %', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER "immutable" BEFORE UPDATE OR DELETE ON "players_statistics_history" FOR EACH ROW EXECUTE FUNCTION "reject_closed_season_change"();
+CREATE OR REPLACE TRIGGER "immutable" BEFORE UPDATE OR DELETE ON "teams_statistics_history" FOR EACH ROW EXECUTE FUNCTION "reject_closed_season_change"();
+CREATE OR REPLACE TRIGGER "immutable" BEFORE UPDATE OR DELETE ON "players_teams_statistics_history" FOR EACH ROW EXECUTE FUNCTION "reject_closed_season_change"();
+
+CREATE OR REPLACE VIEW "public"."players_by_games_all" AS
+SELECT * FROM "players_by_games"
+UNION ALL
+SELECT * FROM "players_by_games_history" h
+WHERE NOT EXISTS (
+	SELECT 1 FROM "players_by_games" p WHERE p."league" = h."league" AND p."player" = h."player" AND p."game_date" = h."game_date"
+);
+
+-- Baseline data belongs to the default league and the regular season. Names of players and teams are registered as by ingestion:
+-- aliases are normalized names, and identifiers are derived from them, suffixed by "-2", "-3", etc. on collisions.
+-- Partitions of the months and the seasons of the data are created, so the default partitions stay empty,
+-- and statistics are calculated from the per-game rows, to be cached with the keys of the league.
+DO $$
+DECLARE
+	partition_month timestamp;
+	partition_season text;
+BEGIN
+	IF to_regclass('"public"."baseline_events"') IS NULL THEN
+		RETURN;
+	END IF;
+
+	CREATE TEMPORARY TABLE "baseline_registry" ON COMMIT DROP AS
+	WITH "names" AS (
+		SELECT 'player' AS "subject", "player" AS "name" FROM "baseline_events"
+		UNION SELECT 'team', "team" FROM "baseline_events"
+		UNION SELECT 'player', "player" FROM "baseline_players_by_games"
+		UNION SELECT 'team', "team" FROM "baseline_players_by_games"
+	), "aliases" AS (
+		SELECT "subject", "name", trim(regexp_replace(lower("name"), '\s+', ' ', 'g')) AS "alias" FROM "names"
+	), "slugs" AS (
+		SELECT "subject", "alias", MIN(regexp_replace("name", '^\s+|\s+$', '', 'g')) AS "display_name",
+			COALESCE(NULLIF(trim(BOTH '-' FROM regexp_replace("alias", '[^a-z0-9]+', '-', 'g')), ''), "subject") AS "slug"
+		FROM "aliases"
+		GROUP BY "subject", "alias"
+	), "ids" AS (
+		SELECT "subject", "alias", "display_name",
+			"slug" || CASE WHEN ROW_NUMBER() OVER "same_slug" > 1 THEN '-' || ROW_NUMBER() OVER "same_slug" ELSE '' END AS "id"
+		FROM "slugs"
+		WINDOW "same_slug" AS (PARTITION BY "subject", "slug" ORDER BY "alias")
+	)
+	SELECT a."subject", a."name", i."alias", i."display_name", i."id"
+	FROM "aliases" a JOIN "ids" i ON i."subject" = a."subject" AND i."alias" = a."alias";
+
+	INSERT INTO "players" ("id", "name") SELECT DISTINCT "id", "display_name" FROM "baseline_registry" WHERE "subject" = 'player';
+	INSERT INTO "players_aliases" ("alias", "player") SELECT DISTINCT "alias", "id" FROM "baseline_registry" WHERE "subject" = 'player';
+	INSERT INTO "teams" ("id", "name") SELECT DISTINCT "id", "display_name" FROM "baseline_registry" WHERE "subject" = 'team';
+	INSERT INTO "teams_aliases" ("alias", "team") SELECT DISTINCT "alias", "id" FROM "baseline_registry" WHERE "subject" = 'team';
+
+	FOR partition_month IN SELECT DISTINCT date_trunc('month', "timestamp") FROM "baseline_events" LOOP
+		EXECUTE format('CREATE TABLE IF NOT EXISTS "public".%I PARTITION OF "events" FOR VALUES FROM (%L) TO (%L)',
+			'events_' || to_char(partition_month, 'YYYY_MM'), partition_month::date, (partition_month + interval '1 month')::date);
+	END LOOP;
+
+	FOR partition_season IN SELECT DISTINCT "season" FROM "baseline_players_by_games" LOOP
+		EXECUTE format('CREATE TABLE IF NOT EXISTS "public".%I PARTITION OF "players_by_games" FOR VALUES IN (%L)',
+			'players_by_games_' || replace(partition_season, '-', '_'), partition_season);
+	END LOOP;
+
+	-- names differing by case or whitespaces only are the same player, so their events at the same time are merged
+	INSERT INTO "events" ("league", "player", "team", "timestamp", "event", "game_date", "value")
+	SELECT 'nba', p."id", t."id", e."timestamp", e."event", e."game_date", e."value"
+	FROM "baseline_events" e
+	JOIN "baseline_registry" p ON p."subject" = 'player' AND p."name" = e."player"
+	JOIN "baseline_registry" t ON t."subject" = 'team' AND t."name" = e."team"
+	ON CONFLICT DO NOTHING;
+
+	INSERT INTO "players_by_games" ("league", "player", "team", "game_date", "season", "season_type",
+		"points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played", "entered")
+	SELECT 'nba', p."id", t."id", g."game_date", g."season", 'regular',
+		g."points", g."rebounds", g."assists", g."steals", g."blocks", g."fouls", g."turnovers", g."minutes_played", g."entered"
+	FROM "baseline_players_by_games" g
+	JOIN "baseline_registry" p ON p."subject" = 'player' AND p."name" = g."player"
+	JOIN "baseline_registry" t ON t."subject" = 'team' AND t."name" = g."team"
+	ON CONFLICT DO NOTHING;
+
+	INSERT INTO "players_statistics" ("league", "player", "season", "season_type",
+		"points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played")
+	SELECT "league", "player", "season", "season_type",
+		AVG("points"), AVG("rebounds"), AVG("assists"), AVG("steals"), AVG("blocks"), AVG("fouls"), AVG("turnovers"), AVG("minutes_played")
+	FROM "players_by_games"
+	GROUP BY "league", "player", "season", "season_type";
+
+	INSERT INTO "teams_statistics" ("league", "team", "season", "season_type",
+		"points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played")
+	SELECT "league", "team", "season", "season_type",
+		AVG("points"), AVG("rebounds"), AVG("assists"), AVG("steals"), AVG("blocks"), AVG("fouls"), AVG("turnovers"), AVG("minutes_played")
+	FROM "players_by_games"
+	GROUP BY "league", "team", "season", "season_type";
+
+	INSERT INTO "players_teams_statistics" ("league", "player", "team", "season", "season_type",
+		"points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played")
+	SELECT "league", "player", "team", "season", "season_type",
+		AVG("points"), AVG("rebounds"), AVG("assists"), AVG("steals"), AVG("blocks"), AVG("fouls"), AVG("turnovers"), AVG("minutes_played")
+	FROM "players_by_games"
+	GROUP BY "league", "player", "team", "season", "season_type";
+
+	DROP TABLE "baseline_events", "baseline_players_by_games", "baseline_players_statistics", "baseline_teams_statistics";
+END $$;
@@ -0,0 +1,67 @@
+-- Sums of statistics are dropped, so statistics are updated by averaging per-game rows of seasons again.
+
+ALTER TABLE "players_teams_statistics_history"
+DROP COLUMN IF EXISTS "games",
+DROP COLUMN IF EXISTS "points_sum",
+DROP COLUMN IF EXISTS "rebounds_sum",
+DROP COLUMN IF EXISTS "assists_sum",
+DROP COLUMN IF EXISTS "steals_sum",
+DROP COLUMN IF EXISTS "blocks_sum",
+DROP COLUMN IF EXISTS "fouls_sum",
+DROP COLUMN IF EXISTS "turnovers_sum",
+DROP COLUMN IF EXISTS "minutes_played_sum";
+
+ALTER TABLE "teams_statistics_history"
+DROP COLUMN IF EXISTS "games",
+DROP COLUMN IF EXISTS "points_sum",
+DROP COLUMN IF EXISTS "rebounds_sum",
+DROP COLUMN IF EXISTS "assists_sum",
+DROP COLUMN IF EXISTS "steals_sum",
+DROP COLUMN IF EXISTS "blocks_sum",
+DROP COLUMN IF EXISTS "fouls_sum",
+DROP COLUMN IF EXISTS "turnovers_sum",
+DROP COLUMN IF EXISTS "minutes_played_sum";
+
+ALTER TABLE "players_statistics_history"
+DROP COLUMN IF EXISTS "games",
+DROP COLUMN IF EXISTS "points_sum",
+DROP COLUMN IF EXISTS "rebounds_sum",
+DROP COLUMN IF EXISTS "assists_sum",
+DROP COLUMN IF EXISTS "steals_sum",
+DROP COLUMN IF EXISTS "blocks_sum",
+DROP COLUMN IF EXISTS "fouls_sum",
+DROP COLUMN IF EXISTS "turnovers_sum",
+DROP COLUMN IF EXISTS "minutes_played_sum";
+
+ALTER TABLE "players_teams_statistics"
+DROP COLUMN IF EXISTS "games",
+DROP COLUMN IF EXISTS "points_sum",
+DROP COLUMN IF EXISTS "rebounds_sum",
+DROP COLUMN IF EXISTS "assists_sum",
+DROP COLUMN IF EXISTS "steals_sum",
+DROP COLUMN IF EXISTS "blocks_sum",
+DROP COLUMN IF EXISTS "fouls_sum",
+DROP COLUMN IF EXISTS "turnovers_sum",
+DROP COLUMN IF EXISTS "minutes_played_sum";
+
+ALTER TABLE "teams_statistics"
+DROP COLUMN IF EXISTS "games",
+DROP COLUMN IF EXISTS "points_sum",
+DROP COLUMN IF EXISTS "rebounds_sum",
+DROP COLUMN IF EXISTS "assists_sum",
+DROP COLUMN IF EXISTS "steals_sum",
+DROP COLUMN IF EXISTS "blocks_sum",
+DROP COLUMN IF EXISTS "fouls_sum",
+DROP COLUMN IF EXISTS "turnovers_sum",
+DROP COLUMN IF EXISTS "minutes_played_sum";
+
+ALTER TABLE "players_statistics"
+DROP COLUMN IF EXISTS "games",
+DROP COLUMN IF EXISTS "points_sum",
+DROP COLUMN IF EXISTS "rebounds_sum",
+DROP COLUMN IF EXISTS "assists_sum",
+DROP COLUMN IF EXISTS "steals_sum",
+DROP COLUMN IF EXISTS "blocks_sum",
+DROP COLUMN IF EXISTS "fouls_sum",
+DROP COLUMN IF EXISTS "turnovers_sum",
+DROP COLUMN IF EXISTS "minutes_played_sum";
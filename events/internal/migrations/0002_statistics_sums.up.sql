@@ -0,0 +1,231 @@
+-- Season sums and numbers of per-game rows kept along with the averages of statistics,
+-- so a new event updates the statistics incrementally rather than by averaging all the per-game rows of the season.
+-- Sums of existing statistics, the frozen history included, are calculated from the per-game rows.
+
+ALTER TABLE "players_statistics"
+ADD COLUMN IF NOT EXISTS "games" int4 NOT NULL DEFAULT 0 CHECK (games >= 0),
+ADD COLUMN IF NOT EXISTS "points_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "rebounds_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "assists_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "steals_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "blocks_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "fouls_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "turnovers_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "minutes_played_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "teams_statistics"
+ADD COLUMN IF NOT EXISTS "games" int4 NOT NULL DEFAULT 0 CHECK (games >= 0),
+ADD COLUMN IF NOT EXISTS "points_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "rebounds_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "assists_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "steals_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "blocks_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "fouls_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "turnovers_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "minutes_played_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_teams_statistics"
+ADD COLUMN IF NOT EXISTS "games" int4 NOT NULL DEFAULT 0 CHECK (games >= 0),
+ADD COLUMN IF NOT EXISTS "points_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "rebounds_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "assists_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "steals_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "blocks_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "fouls_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "turnovers_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "minutes_played_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_statistics_history"
+ADD COLUMN IF NOT EXISTS "games" int4 NOT NULL DEFAULT 0 CHECK (games >= 0),
+ADD COLUMN IF NOT EXISTS "points_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "rebounds_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "assists_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "steals_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "blocks_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "fouls_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "turnovers_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "minutes_played_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "teams_statistics_history"
+ADD COLUMN IF NOT EXISTS "games" int4 NOT NULL DEFAULT 0 CHECK (games >= 0),
+ADD COLUMN IF NOT EXISTS "points_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "rebounds_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "assists_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "steals_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "blocks_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "fouls_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "turnovers_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "minutes_played_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_teams_statistics_history"
+ADD COLUMN IF NOT EXISTS "games" int4 NOT NULL DEFAULT 0 CHECK (games >= 0),
+ADD COLUMN IF NOT EXISTS "points_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "rebounds_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "assists_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "steals_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "blocks_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "fouls_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "turnovers_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "minutes_played_sum" float8 NOT NULL DEFAULT 0;
+
+UPDATE "players_statistics" s SET
+	"games" = g."games",
+	"points_sum" = g."points_sum",
+	"rebounds_sum" = g."rebounds_sum",
+	"assists_sum" = g."assists_sum",
+	"steals_sum" = g."steals_sum",
+	"blocks_sum" = g."blocks_sum",
+	"fouls_sum" = g."fouls_sum",
+	"turnovers_sum" = g."turnovers_sum",
+	"minutes_played_sum" = g."minutes_played_sum"
+FROM (
+	SELECT "league", "player", "season", "season_type",
+		COUNT(*) AS "games",
+		SUM("points") AS "points_sum",
+		SUM("rebounds") AS "rebounds_sum",
+		SUM("assists") AS "assists_sum",
+		SUM("steals") AS "steals_sum",
+		SUM("blocks") AS "blocks_sum",
+		SUM("fouls") AS "fouls_sum",
+		SUM("turnovers") AS "turnovers_sum",
+		SUM("minutes_played") AS "minutes_played_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "player", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."player" = g."player" AND s."season" = g."season" AND s."season_type" = g."season_type";
+
+UPDATE "teams_statistics" s SET
+	"games" = g."games",
+	"points_sum" = g."points_sum",
+	"rebounds_sum" = g."rebounds_sum",
+	"assists_sum" = g."assists_sum",
+	"steals_sum" = g."steals_sum",
+	"blocks_sum" = g."blocks_sum",
+	"fouls_sum" = g."fouls_sum",
+	"turnovers_sum" = g."turnovers_sum",
+	"minutes_played_sum" = g."minutes_played_sum"
+FROM (
+	SELECT "league", "team", "season", "season_type",
+		COUNT(*) AS "games",
+		SUM("points") AS "points_sum",
+		SUM("rebounds") AS "rebounds_sum",
+		SUM("assists") AS "assists_sum",
+		SUM("steals") AS "steals_sum",
+		SUM("blocks") AS "blocks_sum",
+		SUM("fouls") AS "fouls_sum",
+		SUM("turnovers") AS "turnovers_sum",
+		SUM("minutes_played") AS "minutes_played_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "team", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."team" = g."team" AND s."season" = g."season" AND s."season_type" = g."season_type";
+
+UPDATE "players_teams_statistics" s SET
+	"games" = g."games",
+	"points_sum" = g."points_sum",
+	"rebounds_sum" = g."rebounds_sum",
+	"assists_sum" = g."assists_sum",
+	"steals_sum" = g."steals_sum",
+	"blocks_sum" = g."blocks_sum",
+	"fouls_sum" = g."fouls_sum",
+	"turnovers_sum" = g."turnovers_sum",
+	"minutes_played_sum" = g."minutes_played_sum"
+FROM (
+	SELECT "league", "player", "team", "season", "season_type",
+		COUNT(*) AS "games",
+		SUM("points") AS "points_sum",
+		SUM("rebounds") AS "rebounds_sum",
+		SUM("assists") AS "assists_sum",
+		SUM("steals") AS "steals_sum",
+		SUM("blocks") AS "blocks_sum",
+		SUM("fouls") AS "fouls_sum",
+		SUM("turnovers") AS "turnovers_sum",
+		SUM("minutes_played") AS "minutes_played_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "player", "team", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."player" = g."player" AND s."team" = g."team" AND s."season" = g."season" AND s."season_type" = g."season_type";
+
+ALTER TABLE "players_statistics_history" DISABLE TRIGGER "immutable";
+UPDATE "players_statistics_history" s SET
+	"games" = g."games",
+	"points_sum" = g."points_sum",
+	"rebounds_sum" = g."rebounds_sum",
+	"assists_sum" = g."assists_sum",
+	"steals_sum" = g."steals_sum",
+	"blocks_sum" = g."blocks_sum",
+	"fouls_sum" = g."fouls_sum",
+	"turnovers_sum" = g."turnovers_sum",
+	"minutes_played_sum" = g."minutes_played_sum"
+FROM (
+	SELECT "league", "player", "season", "season_type",
+		COUNT(*) AS "games",
+		SUM("points") AS "points_sum",
+		SUM("rebounds") AS "rebounds_sum",
+		SUM("assists") AS "assists_sum",
+		SUM("steals") AS "steals_sum",
+		SUM("blocks") AS "blocks_sum",
+		SUM("fouls") AS "fouls_sum",
+		SUM("turnovers") AS "turnovers_sum",
+		SUM("minutes_played") AS "minutes_played_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "player", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."player" = g."player" AND s."season" = g."season" AND s."season_type" = g."season_type";
+ALTER TABLE "players_statistics_history" ENABLE TRIGGER "immutable";
+
+ALTER TABLE "teams_statistics_history" DISABLE TRIGGER "immutable";
+UPDATE "teams_statistics_history" s SET
+	"games" = g."games",
+	"points_sum" = g."points_sum",
+	"rebounds_sum" = g."rebounds_sum",
+	"assists_sum" = g."assists_sum",
+	"steals_sum" = g."steals_sum",
+	"blocks_sum" = g."blocks_sum",
+	"fouls_sum" = g."fouls_sum",
+	"turnovers_sum" = g."turnovers_sum",
+	"minutes_played_sum" = g."minutes_played_sum"
+FROM (
+	SELECT "league", "team", "season", "season_type",
+		COUNT(*) AS "games",
+		SUM("points") AS "points_sum",
+		SUM("rebounds") AS "rebounds_sum",
+		SUM("assists") AS "assists_sum",
+		SUM("steals") AS "steals_sum",
+		SUM("blocks") AS "blocks_sum",
+		SUM("fouls") AS "fouls_sum",
+		SUM("turnovers") AS "turnovers_sum",
+		SUM("minutes_played") AS "minutes_played_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "team", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."team" = g."team" AND s."season" = g."season" AND s."season_type" = g."season_type";
+ALTER TABLE "teams_statistics_history" ENABLE TRIGGER "immutable";
+
+ALTER TABLE "players_teams_statistics_history" DISABLE TRIGGER "immutable";
+UPDATE "players_teams_statistics_history" s SET
+	"games" = g."games",
+	"points_sum" = g."points_sum",
+	"rebounds_sum" = g."rebounds_sum",
+	"assists_sum" = g."assists_sum",
+	"steals_sum" = g."steals_sum",
+	"blocks_sum" = g."blocks_sum",
+	"fouls_sum" = g."fouls_sum",
+	"turnovers_sum" = g."turnovers_sum",
+	"minutes_played_sum" = g."minutes_played_sum"
+FROM (
+	SELECT "league", "player", "team", "season", "season_type",
+		COUNT(*) AS "games",
+		SUM("points") AS "points_sum",
+		SUM("rebounds") AS "rebounds_sum",
+		SUM("assists") AS "assists_sum",
+		SUM("steals") AS "steals_sum",
+		SUM("blocks") AS "blocks_sum",
+		SUM("fouls") AS "fouls_sum",
+		SUM("turnovers") AS "turnovers_sum",
+		SUM("minutes_played") AS "minutes_played_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "player", "team", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."player" = g."player" AND s."team" = g."team" AND s."season" = g."season" AND s."season_type" = g."season_type";
+ALTER TABLE "players_teams_statistics_history" ENABLE TRIGGER "immutable";
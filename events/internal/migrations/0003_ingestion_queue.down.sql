@@ -0,0 +1,4 @@
+-- Queued and dead-lettered events are dropped with their tables.
+
+DROP TABLE IF EXISTS "public"."dead_letters";
+DROP TABLE IF EXISTS "public"."ingestion_queue";
@@ -0,0 +1,25 @@
+-- Queue of events accepted in the asynchronous ingestion mode, processed in order per game,
+-- and the dead-letter store of events failed to be processed.
+
+CREATE TABLE IF NOT EXISTS "public"."ingestion_queue" (
+"id" bigserial NOT NULL,
+"event" jsonb NOT NULL,
+"override" bool NOT NULL DEFAULT false,
+"game_key" text NOT NULL,
+"state" text NOT NULL DEFAULT 'queued' CHECK (state IN ('queued', 'processed', 'failed')),
+"attempts" int4 NOT NULL DEFAULT 0,
+"error" text,
+"received_at" timestamp NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+"processed_at" timestamp,
+PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "ingestion_queue_queued_idx" ON "ingestion_queue" ("game_key", "id") WHERE "state" = 'queued';
+
+CREATE TABLE IF NOT EXISTS "public"."dead_letters" (
+"id" bigserial NOT NULL,
+"queue_id" int8 REFERENCES "ingestion_queue" ("id"),
+"event" jsonb NOT NULL,
+"override" bool NOT NULL DEFAULT false,
+"error" text NOT NULL,
+"attempts" int4 NOT NULL DEFAULT 1,
+"failed_at" timestamp NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+PRIMARY KEY ("id"));
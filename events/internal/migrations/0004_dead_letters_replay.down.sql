@@ -0,0 +1,4 @@
+-- Times of replays of dead letters are dropped.
+
+DROP INDEX IF EXISTS "dead_letters_pending_idx";
+ALTER TABLE "dead_letters" DROP COLUMN IF EXISTS "replayed_at";
@@ -0,0 +1,4 @@
+-- Dead letters replayed successfully are kept for audit with the time of the replay.
+
+ALTER TABLE "dead_letters" ADD COLUMN IF NOT EXISTS "replayed_at" timestamp;
+CREATE INDEX IF NOT EXISTS "dead_letters_pending_idx" ON "dead_letters" ("id") WHERE "replayed_at" IS NULL;
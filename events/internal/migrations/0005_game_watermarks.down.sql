@@ -0,0 +1,10 @@
+-- Per-game watermarks and the record of events arrived after finalization are dropped.
+
+DROP TABLE IF EXISTS "late_events";
+
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "late_events";
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "settled_at";
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "ends_at";
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "last_received_at";
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "watermark";
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "latest_event_at";
@@ -0,0 +1,40 @@
+-- Per-game watermarks: the latest event time seen, the watermark trailing it by the lateness tolerance, the game end,
+-- and the time the watermark passed the game end, until which statistics of the game are provisional.
+-- Events arrived after finalization of their games are recorded in "late_events".
+
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "latest_event_at" timestamp;
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "watermark" timestamp;
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "last_received_at" timestamp;
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "ends_at" timestamp;
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "settled_at" timestamp;
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "late_events" int4 NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "public"."late_events" (
+"id" bigserial NOT NULL,
+"league" text NOT NULL,
+"team" text NOT NULL,
+"game_date" date NOT NULL,
+"player" text NOT NULL,
+"timestamp" timestamp NOT NULL,
+"event" text NOT NULL,
+"finalized_at" timestamp NOT NULL,
+"received_at" timestamp NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "late_events_game_idx" ON "late_events" ("league", "game_date", "team");
+
+-- games are tracked from their stored events, and finalized ones end at their latest event and are settled
+INSERT INTO "team_games" ("league", "team", "game_date", "latest_event_at", "watermark")
+SELECT "league", "team", "game_date", MAX("timestamp"), MAX("timestamp") FROM "events" GROUP BY "league", "team", "game_date"
+ON CONFLICT ("league", "team", "game_date") DO UPDATE SET "latest_event_at" = EXCLUDED."latest_event_at", "watermark" = EXCLUDED."watermark";
+
+UPDATE "team_games" SET
+	"latest_event_at" = COALESCE("latest_event_at", "finalized_at"),
+	"watermark" = COALESCE("watermark", "finalized_at"),
+	"ends_at" = COALESCE("latest_event_at", "finalized_at"),
+	"settled_at" = NOW() AT TIME ZONE 'UTC'
+WHERE "finalized_at" IS NOT NULL;
+
+-- statistics are cached again with the provisional flag
+UPDATE "players_statistics" SET "processed" = false;
+UPDATE "teams_statistics" SET "processed" = false;
+UPDATE "players_teams_statistics" SET "processed" = false;
@@ -0,0 +1,9 @@
+-- State of games and final per-game rows are dropped.
+
+ALTER TABLE "players_by_games_history" DROP COLUMN IF EXISTS "final";
+ALTER TABLE "players_by_games" DROP COLUMN IF EXISTS "final";
+
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "processed";
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "started_at";
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "period";
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "state";
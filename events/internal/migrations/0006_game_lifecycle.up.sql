@@ -0,0 +1,10 @@
+-- State of games driven by game lifecycle events, cached to Redis unless processed,
+-- and per-game rows marked final at the end of the game.
+
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "state" text NOT NULL DEFAULT 'scheduled' CHECK (state IN ('scheduled', 'live', 'intermission', 'final'));
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "period" int2 NOT NULL DEFAULT 0 CHECK (period >= 0);
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "started_at" timestamp;
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "processed" bool NOT NULL DEFAULT false;
+
+ALTER TABLE "players_by_games" ADD COLUMN IF NOT EXISTS "final" bool NOT NULL DEFAULT false;
+ALTER TABLE "players_by_games_history" ADD COLUMN IF NOT EXISTS "final" bool NOT NULL DEFAULT false;
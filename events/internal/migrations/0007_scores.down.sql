@@ -0,0 +1,7 @@
+-- Scores, periods and opponents of games are dropped.
+
+DROP TABLE IF EXISTS "team_scores";
+DROP TABLE IF EXISTS "game_periods";
+
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "opponent";
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "home_team";
@@ -0,0 +1,39 @@
+-- Live scores of games by periods: the opponents of games given by home teams of events, the periods started and ended by lifecycle events,
+-- and the points of every team by periods. Points scored before the first period started are kept as period 0.
+
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "home_team" text;
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "opponent" text;
+
+CREATE TABLE IF NOT EXISTS "public"."game_periods" (
+"league" text NOT NULL,
+"team" text NOT NULL,
+"game_date" date NOT NULL,
+"period" int2 NOT NULL CHECK (period > 0),
+"started_at" timestamp NOT NULL,
+"ended_at" timestamp CHECK (ended_at >= started_at),
+PRIMARY KEY ("league", "team", "game_date", "period"));
+
+CREATE TABLE IF NOT EXISTS "public"."team_scores" (
+"league" text NOT NULL,
+"team" text NOT NULL,
+"game_date" date NOT NULL,
+"period" int2 NOT NULL CHECK (period >= 0),
+"points" int4 NOT NULL DEFAULT 0 CHECK (points >= 0),
+PRIMARY KEY ("league", "team", "game_date", "period"));
+
+-- scores of stored events are kept as period 0, as their periods are unknown
+INSERT INTO "team_scores" ("league", "team", "game_date", "period", "points")
+SELECT "league", "team", "game_date", 0, SUM("value") FROM "events" WHERE "event" = 'shot' GROUP BY "league", "team", "game_date"
+ON CONFLICT DO NOTHING;
+
+-- opponents are paired by the home teams of stored events of visiting teams
+UPDATE "team_games" g SET "home_team" = e."home_team", "opponent" = e."home_team"
+FROM (SELECT DISTINCT "league", "team", "game_date", "home_team" FROM "events" WHERE "home_team" IS NOT NULL AND "home_team" <> "team") e
+WHERE g."league" = e."league" AND g."team" = e."team" AND g."game_date" = e."game_date";
+
+UPDATE "team_games" g SET "home_team" = g."team", "opponent" = e."team"
+FROM (SELECT DISTINCT "league", "team", "game_date", "home_team" FROM "events" WHERE "home_team" IS NOT NULL AND "home_team" <> "team") e
+WHERE g."league" = e."league" AND g."team" = e."home_team" AND g."game_date" = e."game_date";
+
+-- games are cached again with their scores
+UPDATE "team_games" SET "processed" = false;
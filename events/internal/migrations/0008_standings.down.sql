@@ -0,0 +1,9 @@
+-- Seasons of games and groups of teams are dropped.
+
+DROP INDEX IF EXISTS "team_games_season";
+
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "season_type";
+ALTER TABLE "team_games" DROP COLUMN IF EXISTS "season";
+
+ALTER TABLE "teams" DROP COLUMN IF EXISTS "division";
+ALTER TABLE "teams" DROP COLUMN IF EXISTS "conference";
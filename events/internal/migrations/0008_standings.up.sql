@@ -0,0 +1,27 @@
+-- Standings of teams from the results of paired games: conferences and divisions of teams grouping standings,
+-- and seasons of games the results count towards.
+
+ALTER TABLE "teams" ADD COLUMN IF NOT EXISTS "conference" text;
+ALTER TABLE "teams" ADD COLUMN IF NOT EXISTS "division" text;
+
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "season" text;
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "season_type" text;
+
+CREATE INDEX IF NOT EXISTS "team_games_season" ON "team_games" ("league", "season") WHERE "opponent" IS NOT NULL;
+
+-- seasons of stored games are the ones of their per-game rows, closed seasons included
+UPDATE "team_games" g SET "season" = p."season", "season_type" = p."season_type"
+FROM (
+	SELECT DISTINCT "league", "team", "game_date", "season", "season_type" FROM "players_by_games"
+	UNION
+	SELECT DISTINCT "league", "team", "game_date", "season", "season_type" FROM "players_by_games_history"
+) p
+WHERE g."league" = p."league" AND g."team" = p."team" AND g."game_date" = p."game_date";
+
+-- opponents share the season of the game
+UPDATE "team_games" g SET "season" = o."season", "season_type" = o."season_type"
+FROM "team_games" o
+WHERE g."season" IS NULL AND o."season" IS NOT NULL AND o."league" = g."league" AND o."team" = g."opponent" AND o."game_date" = g."game_date";
+
+-- standings are cached with the games
+UPDATE "team_games" SET "processed" = false WHERE "opponent" IS NOT NULL;
@@ -0,0 +1,41 @@
+-- Plus-minus of players, i.e. the point differential of their team while they are on the court, per game and averaged and summed per season.
+-- Games are recalculated unless their plus-minus is processed, so the plus-minus of stored games is calculated once the service starts.
+-- The history of closed seasons keeps the columns in the same order, as rows are moved as a whole.
+
+ALTER TABLE "players_by_games" ADD COLUMN IF NOT EXISTS "plus_minus" int4 NOT NULL DEFAULT 0;
+ALTER TABLE "players_by_games_history" ADD COLUMN IF NOT EXISTS "plus_minus" int4 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_statistics"
+ADD COLUMN IF NOT EXISTS "plus_minus" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "plus_minus_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "teams_statistics"
+ADD COLUMN IF NOT EXISTS "plus_minus" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "plus_minus_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_teams_statistics"
+ADD COLUMN IF NOT EXISTS "plus_minus" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "plus_minus_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_statistics_history"
+ADD COLUMN IF NOT EXISTS "plus_minus" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "plus_minus_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "teams_statistics_history"
+ADD COLUMN IF NOT EXISTS "plus_minus" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "plus_minus_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_teams_statistics_history"
+ADD COLUMN IF NOT EXISTS "plus_minus" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "plus_minus_sum" float8 NOT NULL DEFAULT 0;
+
+-- the view of all per-game rows exposes the new columns, appended to the existing ones
+CREATE OR REPLACE VIEW "public"."players_by_games_all" AS
+SELECT * FROM "players_by_games"
+UNION ALL
+SELECT * FROM "players_by_games_history" h
+WHERE NOT EXISTS (
+	SELECT 1 FROM "players_by_games" p WHERE p."league" = h."league" AND p."player" = h."player" AND p."game_date" = h."game_date"
+);
+
+ALTER TABLE "team_games" ADD COLUMN IF NOT EXISTS "plus_minus_processed" bool NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+-- Lineups are dropped.
+
+DROP TABLE IF EXISTS "public"."lineups_by_games";
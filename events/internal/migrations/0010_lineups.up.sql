@@ -0,0 +1,18 @@
+-- Lineups of teams, i.e. five-man units on the court together, with their minutes and points for and against per game.
+-- Lineups are recalculated along with plus-minus from the same intervals and shots, so paired games are marked unprocessed to calculate them.
+
+CREATE TABLE IF NOT EXISTS "public"."lineups_by_games" (
+"league" text NOT NULL,
+"team" text NOT NULL,
+"game_date" date NOT NULL,
+"players" text[] NOT NULL, -- sorted identifiers of the players of the unit
+"season" text NOT NULL,
+"season_type" text NOT NULL CHECK (season_type IN ('preseason', 'regular', 'playin', 'playoffs')),
+"minutes" float8 NOT NULL DEFAULT 0 CHECK (minutes >= 0),
+"points_for" int4 NOT NULL DEFAULT 0 CHECK (points_for >= 0),
+"points_against" int4 NOT NULL DEFAULT 0 CHECK (points_against >= 0),
+PRIMARY KEY ("league", "team", "game_date", "players"));
+
+CREATE INDEX IF NOT EXISTS "lineups_by_games_season" ON "lineups_by_games" ("league", "team", "season", "season_type");
+
+UPDATE "team_games" SET "plus_minus_processed" = false WHERE "opponent" IS NOT NULL;
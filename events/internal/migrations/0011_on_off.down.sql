@@ -0,0 +1,3 @@
+-- On/off statistics are dropped.
+
+DROP TABLE IF EXISTS "public"."on_off_by_games";
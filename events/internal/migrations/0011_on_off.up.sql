@@ -0,0 +1,22 @@
+-- On/off statistics of players, i.e. minutes and points of their team and its opponent while the player is on the court,
+-- along with the totals of the game, the difference being off the court.
+-- They are recalculated along with plus-minus, so paired games are marked unprocessed to calculate them.
+
+CREATE TABLE IF NOT EXISTS "public"."on_off_by_games" (
+"league" text NOT NULL,
+"player" text NOT NULL,
+"team" text NOT NULL,
+"game_date" date NOT NULL,
+"season" text NOT NULL,
+"season_type" text NOT NULL CHECK (season_type IN ('preseason', 'regular', 'playin', 'playoffs')),
+"on_minutes" float8 NOT NULL DEFAULT 0 CHECK (on_minutes >= 0),
+"on_points_for" int4 NOT NULL DEFAULT 0 CHECK (on_points_for >= 0),
+"on_points_against" int4 NOT NULL DEFAULT 0 CHECK (on_points_against >= 0),
+"minutes" float8 NOT NULL DEFAULT 0 CHECK (minutes >= 0),
+"points_for" int4 NOT NULL DEFAULT 0 CHECK (points_for >= 0),
+"points_against" int4 NOT NULL DEFAULT 0 CHECK (points_against >= 0),
+PRIMARY KEY ("league", "player", "game_date", "team"));
+
+CREATE INDEX IF NOT EXISTS "on_off_by_games_game" ON "on_off_by_games" ("league", "team", "game_date");
+
+UPDATE "team_games" SET "plus_minus_processed" = false WHERE "opponent" IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Synthetic events are kept as given ones, except those at the time of a given event of the player, which replaces them.
+
+DELETE FROM "public"."events" s WHERE s."synthetic" AND EXISTS (
+	SELECT 1 FROM "public"."events" e WHERE e."player" = s."player" AND e."timestamp" = s."timestamp" AND NOT e."synthetic"
+);
+ALTER TABLE "public"."events" DROP CONSTRAINT IF EXISTS "events_pkey";
+ALTER TABLE "public"."events" DROP COLUMN IF EXISTS "synthetic";
+ALTER TABLE "public"."events" ADD PRIMARY KEY ("player", "timestamp");
@@ -0,0 +1,7 @@
+-- Events recorded by the game lifecycle rather than given, i.e. 'exit' events closing on-court intervals at the end of a period
+-- and 'enter' events reopening them at the start of the next one, are kept apart from given events of the player at the same time,
+-- so neither of them replaces the other.
+
+ALTER TABLE "public"."events" ADD COLUMN IF NOT EXISTS "synthetic" boolean NOT NULL DEFAULT false;
+ALTER TABLE "public"."events" DROP CONSTRAINT IF EXISTS "events_pkey";
+ALTER TABLE "public"."events" ADD PRIMARY KEY ("player", "timestamp", "synthetic");
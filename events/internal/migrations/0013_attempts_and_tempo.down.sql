@@ -0,0 +1,118 @@
+-- Tempo, attempts and offensive rebounds are dropped, along with missed shots, and the view of all per-game rows is recreated without them.
+
+DROP TABLE IF EXISTS "public"."tempo_by_games";
+
+DROP VIEW IF EXISTS "public"."players_by_games_all";
+
+ALTER TABLE "players_teams_statistics_history"
+DROP COLUMN IF EXISTS "field_goals_made_sum",
+DROP COLUMN IF EXISTS "field_goals_attempted_sum",
+DROP COLUMN IF EXISTS "three_pointers_made_sum",
+DROP COLUMN IF EXISTS "free_throws_made_sum",
+DROP COLUMN IF EXISTS "free_throws_attempted_sum",
+DROP COLUMN IF EXISTS "offensive_rebounds_sum",
+DROP COLUMN IF EXISTS "field_goals_made",
+DROP COLUMN IF EXISTS "field_goals_attempted",
+DROP COLUMN IF EXISTS "three_pointers_made",
+DROP COLUMN IF EXISTS "free_throws_made",
+DROP COLUMN IF EXISTS "free_throws_attempted",
+DROP COLUMN IF EXISTS "offensive_rebounds";
+
+ALTER TABLE "teams_statistics_history"
+DROP COLUMN IF EXISTS "field_goals_made_sum",
+DROP COLUMN IF EXISTS "field_goals_attempted_sum",
+DROP COLUMN IF EXISTS "three_pointers_made_sum",
+DROP COLUMN IF EXISTS "free_throws_made_sum",
+DROP COLUMN IF EXISTS "free_throws_attempted_sum",
+DROP COLUMN IF EXISTS "offensive_rebounds_sum",
+DROP COLUMN IF EXISTS "field_goals_made",
+DROP COLUMN IF EXISTS "field_goals_attempted",
+DROP COLUMN IF EXISTS "three_pointers_made",
+DROP COLUMN IF EXISTS "free_throws_made",
+DROP COLUMN IF EXISTS "free_throws_attempted",
+DROP COLUMN IF EXISTS "offensive_rebounds";
+
+ALTER TABLE "players_statistics_history"
+DROP COLUMN IF EXISTS "field_goals_made_sum",
+DROP COLUMN IF EXISTS "field_goals_attempted_sum",
+DROP COLUMN IF EXISTS "three_pointers_made_sum",
+DROP COLUMN IF EXISTS "free_throws_made_sum",
+DROP COLUMN IF EXISTS "free_throws_attempted_sum",
+DROP COLUMN IF EXISTS "offensive_rebounds_sum",
+DROP COLUMN IF EXISTS "field_goals_made",
+DROP COLUMN IF EXISTS "field_goals_attempted",
+DROP COLUMN IF EXISTS "three_pointers_made",
+DROP COLUMN IF EXISTS "free_throws_made",
+DROP COLUMN IF EXISTS "free_throws_attempted",
+DROP COLUMN IF EXISTS "offensive_rebounds";
+
+ALTER TABLE "players_teams_statistics"
+DROP COLUMN IF EXISTS "field_goals_made_sum",
+DROP COLUMN IF EXISTS "field_goals_attempted_sum",
+DROP COLUMN IF EXISTS "three_pointers_made_sum",
+DROP COLUMN IF EXISTS "free_throws_made_sum",
+DROP COLUMN IF EXISTS "free_throws_attempted_sum",
+DROP COLUMN IF EXISTS "offensive_rebounds_sum",
+DROP COLUMN IF EXISTS "field_goals_made",
+DROP COLUMN IF EXISTS "field_goals_attempted",
+DROP COLUMN IF EXISTS "three_pointers_made",
+DROP COLUMN IF EXISTS "free_throws_made",
+DROP COLUMN IF EXISTS "free_throws_attempted",
+DROP COLUMN IF EXISTS "offensive_rebounds";
+
+ALTER TABLE "teams_statistics"
+DROP COLUMN IF EXISTS "field_goals_made_sum",
+DROP COLUMN IF EXISTS "field_goals_attempted_sum",
+DROP COLUMN IF EXISTS "three_pointers_made_sum",
+DROP COLUMN IF EXISTS "free_throws_made_sum",
+DROP COLUMN IF EXISTS "free_throws_attempted_sum",
+DROP COLUMN IF EXISTS "offensive_rebounds_sum",
+DROP COLUMN IF EXISTS "field_goals_made",
+DROP COLUMN IF EXISTS "field_goals_attempted",
+DROP COLUMN IF EXISTS "three_pointers_made",
+DROP COLUMN IF EXISTS "free_throws_made",
+DROP COLUMN IF EXISTS "free_throws_attempted",
+DROP COLUMN IF EXISTS "offensive_rebounds";
+
+ALTER TABLE "players_statistics"
+DROP COLUMN IF EXISTS "field_goals_made_sum",
+DROP COLUMN IF EXISTS "field_goals_attempted_sum",
+DROP COLUMN IF EXISTS "three_pointers_made_sum",
+DROP COLUMN IF EXISTS "free_throws_made_sum",
+DROP COLUMN IF EXISTS "free_throws_attempted_sum",
+DROP COLUMN IF EXISTS "offensive_rebounds_sum",
+DROP COLUMN IF EXISTS "field_goals_made",
+DROP COLUMN IF EXISTS "field_goals_attempted",
+DROP COLUMN IF EXISTS "three_pointers_made",
+DROP COLUMN IF EXISTS "free_throws_made",
+DROP COLUMN IF EXISTS "free_throws_attempted",
+DROP COLUMN IF EXISTS "offensive_rebounds";
+
+ALTER TABLE "players_by_games_history"
+DROP COLUMN IF EXISTS "field_goals_made",
+DROP COLUMN IF EXISTS "field_goals_attempted",
+DROP COLUMN IF EXISTS "three_pointers_made",
+DROP COLUMN IF EXISTS "free_throws_made",
+DROP COLUMN IF EXISTS "free_throws_attempted",
+DROP COLUMN IF EXISTS "offensive_rebounds";
+
+ALTER TABLE "players_by_games"
+DROP COLUMN IF EXISTS "field_goals_made",
+DROP COLUMN IF EXISTS "field_goals_attempted",
+DROP COLUMN IF EXISTS "three_pointers_made",
+DROP COLUMN IF EXISTS "free_throws_made",
+DROP COLUMN IF EXISTS "free_throws_attempted",
+DROP COLUMN IF EXISTS "offensive_rebounds";
+
+CREATE VIEW "public"."players_by_games_all" AS
+SELECT * FROM "players_by_games"
+UNION ALL
+SELECT * FROM "players_by_games_history" h
+WHERE NOT EXISTS (
+	SELECT 1 FROM "players_by_games" p WHERE p."league" = h."league" AND p."player" = h."player" AND p."game_date" = h."game_date"
+);
+
+DELETE FROM "public"."events" WHERE "event" = 'miss';
+ALTER TABLE "public"."events" DROP CONSTRAINT IF EXISTS "events_event_check";
+ALTER TABLE "public"."events" ADD CONSTRAINT "events_event_check" CHECK (event IN ('shot', 'rebound', 'assist', 'steal', 'block', 'foul', 'turnover', 'enter', 'exit'));
+ALTER TABLE "public"."events" DROP COLUMN IF EXISTS "offensive";
@@ -0,0 +1,367 @@
+-- Missed shots and offensive rebounds, so made and attempted field goals and free throws and offensive rebounds are counted per game
+-- and averaged and summed per season, and possessions of teams are estimated per game from them along with turnovers.
+-- Misses weren't recorded before, so the attempts of stored games are their made shots, and their rebounds aren't offensive.
+-- The history of closed seasons keeps the columns in the same order, as rows are moved as a whole.
+
+ALTER TABLE "public"."events" ADD COLUMN IF NOT EXISTS "offensive" boolean NOT NULL DEFAULT false;
+ALTER TABLE "public"."events" DROP CONSTRAINT IF EXISTS "events_event_check";
+ALTER TABLE "public"."events" ADD CONSTRAINT "events_event_check" CHECK (event IN ('shot', 'miss', 'rebound', 'assist', 'steal', 'block', 'foul', 'turnover', 'enter', 'exit'));
+
+ALTER TABLE "players_by_games"
+ADD COLUMN IF NOT EXISTS "field_goals_made" int4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted" int4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made" int4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made" int4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted" int4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds" int4 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_by_games_history"
+ADD COLUMN IF NOT EXISTS "field_goals_made" int4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted" int4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made" int4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made" int4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted" int4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds" int4 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_statistics"
+ADD COLUMN IF NOT EXISTS "field_goals_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "teams_statistics"
+ADD COLUMN IF NOT EXISTS "field_goals_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_teams_statistics"
+ADD COLUMN IF NOT EXISTS "field_goals_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_statistics_history"
+ADD COLUMN IF NOT EXISTS "field_goals_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "teams_statistics_history"
+ADD COLUMN IF NOT EXISTS "field_goals_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_teams_statistics_history"
+ADD COLUMN IF NOT EXISTS "field_goals_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "field_goals_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "three_pointers_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_made_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "free_throws_attempted_sum" float8 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "offensive_rebounds_sum" float8 NOT NULL DEFAULT 0;
+
+-- the view of all per-game rows exposes the new columns, appended to the existing ones
+CREATE OR REPLACE VIEW "public"."players_by_games_all" AS
+SELECT * FROM "players_by_games"
+UNION ALL
+SELECT * FROM "players_by_games_history" h
+WHERE NOT EXISTS (
+	SELECT 1 FROM "players_by_games" p WHERE p."league" = h."league" AND p."player" = h."player" AND p."game_date" = h."game_date"
+);
+
+UPDATE "players_by_games" p SET
+	"field_goals_made" = e."field_goals_made",
+	"field_goals_attempted" = e."field_goals_made",
+	"three_pointers_made" = e."three_pointers_made",
+	"free_throws_made" = e."free_throws_made",
+	"free_throws_attempted" = e."free_throws_made"
+FROM (
+	SELECT "league", "player", "team", "game_date",
+		COUNT(*) FILTER (WHERE "value" > 1) AS "field_goals_made",
+		COUNT(*) FILTER (WHERE "value" = 3) AS "three_pointers_made",
+		COUNT(*) FILTER (WHERE "value" = 1) AS "free_throws_made"
+	FROM "events"
+	WHERE "event" = 'shot'
+	GROUP BY "league", "player", "team", "game_date"
+) e
+WHERE p."league" = e."league" AND p."player" = e."player" AND p."team" = e."team" AND p."game_date" = e."game_date";
+
+UPDATE "players_by_games_history" p SET
+	"field_goals_made" = e."field_goals_made",
+	"field_goals_attempted" = e."field_goals_made",
+	"three_pointers_made" = e."three_pointers_made",
+	"free_throws_made" = e."free_throws_made",
+	"free_throws_attempted" = e."free_throws_made"
+FROM (
+	SELECT "league", "player", "team", "game_date",
+		COUNT(*) FILTER (WHERE "value" > 1) AS "field_goals_made",
+		COUNT(*) FILTER (WHERE "value" = 3) AS "three_pointers_made",
+		COUNT(*) FILTER (WHERE "value" = 1) AS "free_throws_made"
+	FROM "events"
+	WHERE "event" = 'shot'
+	GROUP BY "league", "player", "team", "game_date"
+) e
+WHERE p."league" = e."league" AND p."player" = e."player" AND p."team" = e."team" AND p."game_date" = e."game_date";
+
+UPDATE "players_statistics" s SET
+	"field_goals_made" = g."field_goals_made",
+	"field_goals_attempted" = g."field_goals_attempted",
+	"three_pointers_made" = g."three_pointers_made",
+	"free_throws_made" = g."free_throws_made",
+	"free_throws_attempted" = g."free_throws_attempted",
+	"offensive_rebounds" = g."offensive_rebounds",
+	"field_goals_made_sum" = g."field_goals_made_sum",
+	"field_goals_attempted_sum" = g."field_goals_attempted_sum",
+	"three_pointers_made_sum" = g."three_pointers_made_sum",
+	"free_throws_made_sum" = g."free_throws_made_sum",
+	"free_throws_attempted_sum" = g."free_throws_attempted_sum",
+	"offensive_rebounds_sum" = g."offensive_rebounds_sum"
+FROM (
+	SELECT "league", "player", "season", "season_type",
+		AVG("field_goals_made") AS "field_goals_made",
+		AVG("field_goals_attempted") AS "field_goals_attempted",
+		AVG("three_pointers_made") AS "three_pointers_made",
+		AVG("free_throws_made") AS "free_throws_made",
+		AVG("free_throws_attempted") AS "free_throws_attempted",
+		AVG("offensive_rebounds") AS "offensive_rebounds",
+		SUM("field_goals_made") AS "field_goals_made_sum",
+		SUM("field_goals_attempted") AS "field_goals_attempted_sum",
+		SUM("three_pointers_made") AS "three_pointers_made_sum",
+		SUM("free_throws_made") AS "free_throws_made_sum",
+		SUM("free_throws_attempted") AS "free_throws_attempted_sum",
+		SUM("offensive_rebounds") AS "offensive_rebounds_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "player", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."player" = g."player" AND s."season" = g."season" AND s."season_type" = g."season_type";
+
+ALTER TABLE "players_statistics_history" DISABLE TRIGGER "immutable";
+UPDATE "players_statistics_history" s SET
+	"field_goals_made" = g."field_goals_made",
+	"field_goals_attempted" = g."field_goals_attempted",
+	"three_pointers_made" = g."three_pointers_made",
+	"free_throws_made" = g."free_throws_made",
+	"free_throws_attempted" = g."free_throws_attempted",
+	"offensive_rebounds" = g."offensive_rebounds",
+	"field_goals_made_sum" = g."field_goals_made_sum",
+	"field_goals_attempted_sum" = g."field_goals_attempted_sum",
+	"three_pointers_made_sum" = g."three_pointers_made_sum",
+	"free_throws_made_sum" = g."free_throws_made_sum",
+	"free_throws_attempted_sum" = g."free_throws_attempted_sum",
+	"offensive_rebounds_sum" = g."offensive_rebounds_sum"
+FROM (
+	SELECT "league", "player", "season", "season_type",
+		AVG("field_goals_made") AS "field_goals_made",
+		AVG("field_goals_attempted") AS "field_goals_attempted",
+		AVG("three_pointers_made") AS "three_pointers_made",
+		AVG("free_throws_made") AS "free_throws_made",
+		AVG("free_throws_attempted") AS "free_throws_attempted",
+		AVG("offensive_rebounds") AS "offensive_rebounds",
+		SUM("field_goals_made") AS "field_goals_made_sum",
+		SUM("field_goals_attempted") AS "field_goals_attempted_sum",
+		SUM("three_pointers_made") AS "three_pointers_made_sum",
+		SUM("free_throws_made") AS "free_throws_made_sum",
+		SUM("free_throws_attempted") AS "free_throws_attempted_sum",
+		SUM("offensive_rebounds") AS "offensive_rebounds_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "player", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."player" = g."player" AND s."season" = g."season" AND s."season_type" = g."season_type";
+ALTER TABLE "players_statistics_history" ENABLE TRIGGER "immutable";
+
+UPDATE "teams_statistics" s SET
+	"field_goals_made" = g."field_goals_made",
+	"field_goals_attempted" = g."field_goals_attempted",
+	"three_pointers_made" = g."three_pointers_made",
+	"free_throws_made" = g."free_throws_made",
+	"free_throws_attempted" = g."free_throws_attempted",
+	"offensive_rebounds" = g."offensive_rebounds",
+	"field_goals_made_sum" = g."field_goals_made_sum",
+	"field_goals_attempted_sum" = g."field_goals_attempted_sum",
+	"three_pointers_made_sum" = g."three_pointers_made_sum",
+	"free_throws_made_sum" = g."free_throws_made_sum",
+	"free_throws_attempted_sum" = g."free_throws_attempted_sum",
+	"offensive_rebounds_sum" = g."offensive_rebounds_sum"
+FROM (
+	SELECT "league", "team", "season", "season_type",
+		AVG("field_goals_made") AS "field_goals_made",
+		AVG("field_goals_attempted") AS "field_goals_attempted",
+		AVG("three_pointers_made") AS "three_pointers_made",
+		AVG("free_throws_made") AS "free_throws_made",
+		AVG("free_throws_attempted") AS "free_throws_attempted",
+		AVG("offensive_rebounds") AS "offensive_rebounds",
+		SUM("field_goals_made") AS "field_goals_made_sum",
+		SUM("field_goals_attempted") AS "field_goals_attempted_sum",
+		SUM("three_pointers_made") AS "three_pointers_made_sum",
+		SUM("free_throws_made") AS "free_throws_made_sum",
+		SUM("free_throws_attempted") AS "free_throws_attempted_sum",
+		SUM("offensive_rebounds") AS "offensive_rebounds_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "team", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."team" = g."team" AND s."season" = g."season" AND s."season_type" = g."season_type";
+
+ALTER TABLE "teams_statistics_history" DISABLE TRIGGER "immutable";
+UPDATE "teams_statistics_history" s SET
+	"field_goals_made" = g."field_goals_made",
+	"field_goals_attempted" = g."field_goals_attempted",
+	"three_pointers_made" = g."three_pointers_made",
+	"free_throws_made" = g."free_throws_made",
+	"free_throws_attempted" = g."free_throws_attempted",
+	"offensive_rebounds" = g."offensive_rebounds",
+	"field_goals_made_sum" = g."field_goals_made_sum",
+	"field_goals_attempted_sum" = g."field_goals_attempted_sum",
+	"three_pointers_made_sum" = g."three_pointers_made_sum",
+	"free_throws_made_sum" = g."free_throws_made_sum",
+	"free_throws_attempted_sum" = g."free_throws_attempted_sum",
+	"offensive_rebounds_sum" = g."offensive_rebounds_sum"
+FROM (
+	SELECT "league", "team", "season", "season_type",
+		AVG("field_goals_made") AS "field_goals_made",
+		AVG("field_goals_attempted") AS "field_goals_attempted",
+		AVG("three_pointers_made") AS "three_pointers_made",
+		AVG("free_throws_made") AS "free_throws_made",
+		AVG("free_throws_attempted") AS "free_throws_attempted",
+		AVG("offensive_rebounds") AS "offensive_rebounds",
+		SUM("field_goals_made") AS "field_goals_made_sum",
+		SUM("field_goals_attempted") AS "field_goals_attempted_sum",
+		SUM("three_pointers_made") AS "three_pointers_made_sum",
+		SUM("free_throws_made") AS "free_throws_made_sum",
+		SUM("free_throws_attempted") AS "free_throws_attempted_sum",
+		SUM("offensive_rebounds") AS "offensive_rebounds_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "team", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."team" = g."team" AND s."season" = g."season" AND s."season_type" = g."season_type";
+ALTER TABLE "teams_statistics_history" ENABLE TRIGGER "immutable";
+
+UPDATE "players_teams_statistics" s SET
+	"field_goals_made" = g."field_goals_made",
+	"field_goals_attempted" = g."field_goals_attempted",
+	"three_pointers_made" = g."three_pointers_made",
+	"free_throws_made" = g."free_throws_made",
+	"free_throws_attempted" = g."free_throws_attempted",
+	"offensive_rebounds" = g."offensive_rebounds",
+	"field_goals_made_sum" = g."field_goals_made_sum",
+	"field_goals_attempted_sum" = g."field_goals_attempted_sum",
+	"three_pointers_made_sum" = g."three_pointers_made_sum",
+	"free_throws_made_sum" = g."free_throws_made_sum",
+	"free_throws_attempted_sum" = g."free_throws_attempted_sum",
+	"offensive_rebounds_sum" = g."offensive_rebounds_sum"
+FROM (
+	SELECT "league", "player", "team", "season", "season_type",
+		AVG("field_goals_made") AS "field_goals_made",
+		AVG("field_goals_attempted") AS "field_goals_attempted",
+		AVG("three_pointers_made") AS "three_pointers_made",
+		AVG("free_throws_made") AS "free_throws_made",
+		AVG("free_throws_attempted") AS "free_throws_attempted",
+		AVG("offensive_rebounds") AS "offensive_rebounds",
+		SUM("field_goals_made") AS "field_goals_made_sum",
+		SUM("field_goals_attempted") AS "field_goals_attempted_sum",
+		SUM("three_pointers_made") AS "three_pointers_made_sum",
+		SUM("free_throws_made") AS "free_throws_made_sum",
+		SUM("free_throws_attempted") AS "free_throws_attempted_sum",
+		SUM("offensive_rebounds") AS "offensive_rebounds_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "player", "team", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."player" = g."player" AND s."team" = g."team" AND s."season" = g."season" AND s."season_type" = g."season_type";
+
+ALTER TABLE "players_teams_statistics_history" DISABLE TRIGGER "immutable";
+UPDATE "players_teams_statistics_history" s SET
+	"field_goals_made" = g."field_goals_made",
+	"field_goals_attempted" = g."field_goals_attempted",
+	"three_pointers_made" = g."three_pointers_made",
+	"free_throws_made" = g."free_throws_made",
+	"free_throws_attempted" = g."free_throws_attempted",
+	"offensive_rebounds" = g."offensive_rebounds",
+	"field_goals_made_sum" = g."field_goals_made_sum",
+	"field_goals_attempted_sum" = g."field_goals_attempted_sum",
+	"three_pointers_made_sum" = g."three_pointers_made_sum",
+	"free_throws_made_sum" = g."free_throws_made_sum",
+	"free_throws_attempted_sum" = g."free_throws_attempted_sum",
+	"offensive_rebounds_sum" = g."offensive_rebounds_sum"
+FROM (
+	SELECT "league", "player", "team", "season", "season_type",
+		AVG("field_goals_made") AS "field_goals_made",
+		AVG("field_goals_attempted") AS "field_goals_attempted",
+		AVG("three_pointers_made") AS "three_pointers_made",
+		AVG("free_throws_made") AS "free_throws_made",
+		AVG("free_throws_attempted") AS "free_throws_attempted",
+		AVG("offensive_rebounds") AS "offensive_rebounds",
+		SUM("field_goals_made") AS "field_goals_made_sum",
+		SUM("field_goals_attempted") AS "field_goals_attempted_sum",
+		SUM("three_pointers_made") AS "three_pointers_made_sum",
+		SUM("free_throws_made") AS "free_throws_made_sum",
+		SUM("free_throws_attempted") AS "free_throws_attempted_sum",
+		SUM("offensive_rebounds") AS "offensive_rebounds_sum"
+	FROM "players_by_games_all"
+	GROUP BY "league", "player", "team", "season", "season_type"
+) g
+WHERE s."league" = g."league" AND s."player" = g."player" AND s."team" = g."team" AND s."season" = g."season" AND s."season_type" = g."season_type";
+ALTER TABLE "players_teams_statistics_history" ENABLE TRIGGER "immutable";
+
+-- Possessions of teams per game, estimated from the attempts, offensive rebounds and turnovers of both teams,
+-- along with the minutes and the points of the game, to rate the pace and the offense and the defense per 100 possessions.
+-- They are recalculated along with plus-minus, so paired games are marked unprocessed to calculate them.
+CREATE TABLE IF NOT EXISTS "public"."tempo_by_games" (
+"league" text NOT NULL,
+"team" text NOT NULL,
+"game_date" date NOT NULL,
+"season" text NOT NULL,
+"season_type" text NOT NULL CHECK (season_type IN ('preseason', 'regular', 'playin', 'playoffs')),
+"opponent" text NOT NULL,
+"possessions" float8 NOT NULL DEFAULT 0 CHECK (possessions >= 0),
+"minutes" float8 NOT NULL DEFAULT 0 CHECK (minutes >= 0),
+"points_for" int4 NOT NULL DEFAULT 0 CHECK (points_for >= 0),
+"points_against" int4 NOT NULL DEFAULT 0 CHECK (points_against >= 0),
+PRIMARY KEY ("league", "team", "game_date"));
+
+UPDATE "team_games" SET "plus_minus_processed" = false WHERE "opponent" IS NOT NULL;
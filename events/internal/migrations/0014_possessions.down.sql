@@ -0,0 +1,21 @@
+-- Possessions of players are dropped, and the view of all per-game rows is recreated without them.
+
+DROP VIEW IF EXISTS "public"."players_by_games_all";
+
+ALTER TABLE "players_teams_statistics_history" DROP COLUMN IF EXISTS "possessions_sum", DROP COLUMN IF EXISTS "possessions";
+ALTER TABLE "teams_statistics_history" DROP COLUMN IF EXISTS "possessions_sum", DROP COLUMN IF EXISTS "possessions";
+ALTER TABLE "players_statistics_history" DROP COLUMN IF EXISTS "possessions_sum", DROP COLUMN IF EXISTS "possessions";
+ALTER TABLE "players_teams_statistics" DROP COLUMN IF EXISTS "possessions_sum", DROP COLUMN IF EXISTS "possessions";
+ALTER TABLE "teams_statistics" DROP COLUMN IF EXISTS "possessions_sum", DROP COLUMN IF EXISTS "possessions";
+ALTER TABLE "players_statistics" DROP COLUMN IF EXISTS "possessions_sum", DROP COLUMN IF EXISTS "possessions";
+
+ALTER TABLE "players_by_games_history" DROP COLUMN IF EXISTS "possessions";
+ALTER TABLE "players_by_games" DROP COLUMN IF EXISTS "possessions";
+
+CREATE VIEW "public"."players_by_games_all" AS
+SELECT * FROM "players_by_games"
+UNION ALL
+SELECT * FROM "players_by_games_history" h
+WHERE NOT EXISTS (
+	SELECT 1 FROM "players_by_games" p WHERE p."league" = h."league" AND p."player" = h."player" AND p."game_date" = h."game_date"
+);
@@ -0,0 +1,42 @@
+-- Possessions of players, i.e. the share of the possessions of their team in a game by their minutes played, per game and averaged and summed per season,
+-- so rates of players are normalized per 100 possessions.
+-- They are recalculated along with tempo, so paired games are marked unprocessed to calculate them. Games of closed seasons keep no possessions.
+-- The history of closed seasons keeps the columns in the same order, as rows are moved as a whole.
+
+ALTER TABLE "players_by_games" ADD COLUMN IF NOT EXISTS "possessions" float8 NOT NULL DEFAULT 0;
+ALTER TABLE "players_by_games_history" ADD COLUMN IF NOT EXISTS "possessions" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_statistics"
+ADD COLUMN IF NOT EXISTS "possessions" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "possessions_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "teams_statistics"
+ADD COLUMN IF NOT EXISTS "possessions" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "possessions_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_teams_statistics"
+ADD COLUMN IF NOT EXISTS "possessions" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "possessions_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_statistics_history"
+ADD COLUMN IF NOT EXISTS "possessions" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "possessions_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "teams_statistics_history"
+ADD COLUMN IF NOT EXISTS "possessions" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "possessions_sum" float8 NOT NULL DEFAULT 0;
+
+ALTER TABLE "players_teams_statistics_history"
+ADD COLUMN IF NOT EXISTS "possessions" float4 NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS "possessions_sum" float8 NOT NULL DEFAULT 0;
+
+-- the view of all per-game rows exposes the new column, appended to the existing ones
+CREATE OR REPLACE VIEW "public"."players_by_games_all" AS
+SELECT * FROM "players_by_games"
+UNION ALL
+SELECT * FROM "players_by_games_history" h
+WHERE NOT EXISTS (
+	SELECT 1 FROM "players_by_games" p WHERE p."league" = h."league" AND p."player" = h."player" AND p."game_date" = h."game_date"
+);
+
+UPDATE "team_games" SET "plus_minus_processed" = false WHERE "opponent" IS NOT NULL;
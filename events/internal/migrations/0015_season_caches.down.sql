@@ -0,0 +1,3 @@
+-- Season caches are copied to Redis in the transaction of the recalculation again, so their unprocessed state is dropped.
+
+DROP TABLE IF EXISTS "public"."season_caches";
@@ -0,0 +1,15 @@
+-- Season caches of lineups and tempo of teams, and of on/off statistics of players, recalculated along with plus-minus.
+-- They are marked unprocessed in the transaction of the recalculation, and copied to Redis after it's committed.
+-- Paired games are marked unprocessed, so the caches are copied again.
+CREATE TABLE IF NOT EXISTS "public"."season_caches" (
+"league" text NOT NULL,
+"subject" text NOT NULL CHECK (subject IN ('player', 'team')),
+"id" text NOT NULL, -- identifier of the player or the team
+"season" text NOT NULL,
+"season_type" text NOT NULL CHECK (season_type IN ('preseason', 'regular', 'playin', 'playoffs')),
+"processed" boolean NOT NULL DEFAULT false,
+PRIMARY KEY ("league", "subject", "id", "season", "season_type"));
+
+CREATE INDEX IF NOT EXISTS "season_caches_unprocessed" ON "season_caches" ("league") WHERE NOT "processed";
+
+UPDATE "team_games" SET "plus_minus_processed" = false WHERE "opponent" IS NOT NULL;
@@ -0,0 +1,191 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"maps"
+	"slices"
+	"time"
+)
+
+// SQL statements to replace the on/off statistics of the players of the team in the game
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+// $4: player
+// $5: season
+// $6: season type
+// $7, $8, $9: minutes, points for and points against while the player is on the court
+// $10, $11, $12: minutes, points for and points against of the game
+//
+// The deletion returns the players of the deleted rows, so players not on the court anymore, e.g. after a correction, are cached again.
+const (
+	deleteGameOnOffSQL = `DELETE FROM "on_off_by_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3 RETURNING "player"`
+	insertGameOnOffSQL = `INSERT INTO "on_off_by_games" ("league", "team", "game_date", "player", "season", "season_type",
+	"on_minutes", "on_points_for", "on_points_against", "minutes", "points_for", "points_against")
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+)
+
+// selectSeasonOnOffSQL is an SQL statement to select the on/off statistics of the player in the season of the type, summed over the games
+// Parameter placeholders are intended for:
+// $1: league
+// $2: player
+// $3: season
+// $4: season type
+const selectSeasonOnOffSQL = `SELECT SUM("on_minutes"), SUM("on_points_for"), SUM("on_points_against"),
+	SUM("minutes" - "on_minutes"), SUM("points_for" - "on_points_for"), SUM("points_against" - "on_points_against")
+FROM "on_off_by_games"
+WHERE "league" = $1 AND "player" = $2 AND "season" = $3 AND "season_type" = $4
+GROUP BY "player"`
+
+// courtTime is the minutes of a team, and the points of the team and its opponent scored meanwhile
+type courtTime struct {
+	Minutes       float64 `json:"minutes"`
+	PointsFor     int     `json:"pointsFor"`
+	PointsAgainst int     `json:"pointsAgainst"`
+}
+
+func (c *courtTime) add(other courtTime) {
+	c.Minutes += other.Minutes
+	c.PointsFor += other.PointsFor
+	c.PointsAgainst += other.PointsAgainst
+}
+
+// onOff is the court time of the team with the player on the court and off it
+type onOff struct {
+	On  courtTime `json:"on"`
+	Off courtTime `json:"off"`
+}
+
+// onOffKey returns the Redis key of the on/off statistics of the player in the league for the season of the given type
+func onOffKey(league, player, season string, st seasonType) string {
+	return fmt.Sprintf("%s:onoff", statisticsKey(league, subjectPlayer, player, season, st))
+}
+
+// newGameOnOff returns the court time of the team with every player of the team who entered the game on the court, and the court time of the game,
+// from the events of the game in the order of their timestamps. The game spans the time any player of the team is on the court,
+// counted from an 'enter' event until an 'exit' event as in `minutes_played`, and shots after the last substitution count for the players on the court.
+func newGameOnOff(team string, events []courtEvent) (map[string]courtTime, courtTime) {
+	onCourt := map[string]bool{}
+	players := map[string]courtTime{}
+	var game courtTime
+	var since time.Time
+
+	for _, e := range events {
+		switch {
+		case e.event == eventShot:
+			points := courtTime{PointsFor: e.value}
+			if e.team != team {
+				points = courtTime{PointsAgainst: e.value}
+			}
+			game.add(points)
+			for player := range onCourt {
+				p := players[player]
+				p.add(points)
+				players[player] = p
+			}
+			continue
+		case e.team != team:
+			continue
+		}
+
+		if len(onCourt) > 0 {
+			elapsed := courtTime{Minutes: e.timestamp.Sub(since).Minutes()}
+			game.add(elapsed)
+			for player := range onCourt {
+				p := players[player]
+				p.add(elapsed)
+				players[player] = p
+			}
+		}
+		since = e.timestamp
+		if e.event == eventEnter {
+			onCourt[e.player] = true
+			players[e.player] = players[e.player] // a player entering the game has on/off statistics even without court time
+		} else {
+			delete(onCourt, e.player)
+		}
+	}
+
+	return players, game
+}
+
+// updateOnOff recalculates the on/off statistics of the players of the team from the events of the game,
+// updateOnOff recalculates the on/off statistics of the players of the team from the events of the game,
+// and marks the season caches of the players unprocessed, including players not on the court anymore, e.g. after a correction
+func updateOnOff(ctx context.Context, tx *sql.Tx, game pairedGame, events []courtEvent) error {
+	rows, err := tx.QueryContext(ctx, deleteGameOnOffSQL, game.league, game.team, game.gameDate)
+	if err != nil {
+		return fmt.Errorf("failed to delete on/off statistics of game %s: %w", game, err)
+	}
+	var deleted []string
+	for rows.Next() {
+		var player string
+		if err := rows.Scan(&player); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan on/off statistics of game %s: %w", game, err)
+		}
+		deleted = append(deleted, player)
+	}
+	closeIt("rows", rows)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to delete on/off statistics of game %s: %w", game, err)
+	}
+
+	onCourt, total := newGameOnOff(game.team, events)
+	players := slices.Sorted(maps.Keys(onCourt))
+	for _, player := range players {
+		on := onCourt[player]
+		if _, err := tx.ExecContext(ctx, insertGameOnOffSQL, game.league, game.team, game.gameDate, player, game.season, game.seasonType,
+			on.Minutes, on.PointsFor, on.PointsAgainst, total.Minutes, total.PointsFor, total.PointsAgainst,
+		); err != nil {
+			return fmt.Errorf("failed to insert on/off statistics of %q in game %s: %w", player, game, err)
+		}
+	}
+
+	for _, player := range deleted {
+		if _, ok := onCourt[player]; !ok {
+			players = append(players, player)
+		}
+	}
+	for _, player := range players {
+		if err := markSeasonCacheUnprocessed(ctx, tx, seasonCache{game.league, subjectPlayer, player, game.season, game.seasonType}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cacheOnOff copies the on/off statistics of the player in the season to Redis. A player without games in the season anymore has none.
+func cacheOnOff(ctx context.Context, tx *sql.Tx, rdb *redis.Client, c seasonCache) error {
+	key := onOffKey(c.league, c.id, c.season, c.seasonType)
+
+	var o onOff
+	err := tx.QueryRowContext(ctx, selectSeasonOnOffSQL, c.league, c.id, c.season, c.seasonType).
+		Scan(&o.On.Minutes, &o.On.PointsFor, &o.On.PointsAgainst, &o.Off.Minutes, &o.Off.PointsFor, &o.Off.PointsAgainst)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := rdb.Del(ctx, key).Err(); err != nil {
+			return fmt.Errorf("failed to DEL %q key from Redis: %w", key, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to select on/off statistics of %q in %s season %s: %w", c.id, c.league, c.season, err)
+	}
+
+	valueJSON, err := json.Marshal(o)
+	if err != nil {
+		return fmt.Errorf("failed to marshal on/off statistics: %w", err)
+	}
+	if err := rdb.Set(ctx, key, valueJSON, 0).Err(); err != nil {
+		return fmt.Errorf("failed to SET %q key to Redis: %w", key, err)
+	}
+
+	return nil
+}
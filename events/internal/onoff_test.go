@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"testing"
+	"time"
+)
+
+func TestNewGameOnOff(t *testing.T) {
+	start := time.Date(2025, time.March, 15, 19, 30, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	events := []courtEvent{
+		{team: losAngelesLakersID, player: "p1", event: eventEnter, timestamp: at(0)},
+		{team: losAngelesLakersID, player: "p2", event: eventEnter, timestamp: at(0)},
+		{team: losAngelesLakersID, player: "p1", event: eventShot, timestamp: at(2), value: 3},
+		{team: losAngelesLakersID, player: "p2", event: eventExit, timestamp: at(5)},
+		{team: losAngelesLakersID, player: "p3", event: eventEnter, timestamp: at(5)},
+		{team: bostonCelticsID, player: "b1", event: eventShot, timestamp: at(7), value: 2},
+		{team: losAngelesLakersID, player: "p1", event: eventExit, timestamp: at(10)},
+		{team: losAngelesLakersID, player: "p3", event: eventExit, timestamp: at(12)},
+		// substitutions of the opponent don't change the court time of the team
+		{team: bostonCelticsID, player: "b1", event: eventExit, timestamp: at(15)},
+	}
+
+	players, game := newGameOnOff(losAngelesLakersID, events)
+	if expected := (courtTime{Minutes: 12, PointsFor: 3, PointsAgainst: 2}); game != expected {
+		t.Errorf("expected the game %+v, got %+v", expected, game)
+	}
+	for player, expected := range map[string]courtTime{
+		"p1": {Minutes: 10, PointsFor: 3, PointsAgainst: 2},
+		"p2": {Minutes: 5, PointsFor: 3},
+		"p3": {Minutes: 7, PointsAgainst: 2},
+	} {
+		if actual := players[player]; actual != expected {
+			t.Errorf("expected %+v on the court for %q, got %+v", expected, player, actual)
+		}
+	}
+	if len(players) != 3 {
+		t.Errorf("expected 3 players, got %+v", players)
+	}
+}
+
+func TestUpdateOnOff_MarksSeasonCachesUnprocessed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	season, seasonType := "2024-25", seasonTypeRegular
+	game := pairedGame{teamGame{defaultLeague, losAngelesLakersID, "2025-03-15"}, bostonCelticsID, season, seasonType}
+	start := time.Date(2025, time.March, 15, 19, 30, 0, 0, time.UTC)
+	events := []courtEvent{
+		{team: losAngelesLakersID, player: leBronJamesID, event: eventEnter, timestamp: start},
+		{team: losAngelesLakersID, player: leBronJamesID, event: eventExit, timestamp: start.Add(12 * time.Minute)},
+	}
+
+	mock.ExpectBegin()
+	// a player of the game before a correction isn't on the court anymore
+	mock.ExpectQuery(esc(deleteGameOnOffSQL)).WithArgs(defaultLeague, losAngelesLakersID, "2025-03-15").
+		WillReturnRows(sqlmock.NewRows([]string{"player"}).AddRow("anthony-davis").AddRow(leBronJamesID))
+	mock.ExpectExec(esc(insertGameOnOffSQL)).WithArgs(defaultLeague, losAngelesLakersID, "2025-03-15", leBronJamesID, season, seasonType,
+		12.0, 0, 0, 12.0, 0, 0).WillReturnResult(driver.RowsAffected(1))
+	// the season caches of both players are copied to Redis after the commit
+	mock.ExpectExec(esc(markSeasonCacheUnprocessedSQL)).WithArgs(defaultLeague, subjectPlayer, leBronJamesID, season, seasonType).WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectExec(esc(markSeasonCacheUnprocessedSQL)).WithArgs(defaultLeague, subjectPlayer, "anthony-davis", season, seasonType).WillReturnResult(driver.RowsAffected(1))
+
+	tx, err := db.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := updateOnOff(t.Context(), tx, game, events); err != nil {
+		t.Fatalf("failed to update on/off statistics: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
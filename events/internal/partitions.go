@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultPartitionMonthsAhead is the number of months partitions of events are created ahead of time for,
+// unless PARTITION_MONTHS_AHEAD environment variable is specified
+const defaultPartitionMonthsAhead = 3
+
+// partitionsInterval is the interval of creating partitions ahead of time while serving
+const partitionsInterval = 24 * time.Hour
+
+// patterns of partition keys given to detach-partitions command
+var (
+	monthPattern  = regexp.MustCompile(`^\d{4}-\d{2}$`)
+	seasonPattern = regexp.MustCompile(`^\d{4}(-\d{2})?$`)
+)
+
+// eventsPartition returns the name of the partition of events of the month, e.g. "events_2025_03"
+func eventsPartition(month time.Time) string {
+	return fmt.Sprintf("events_%s", month.Format("2006_01"))
+}
+
+// playersByGamesPartition returns the name of the partition of per-game rows of the season, e.g. "players_by_games_2024_25"
+func playersByGamesPartition(season string) string {
+	return fmt.Sprintf("players_by_games_%s", strings.ReplaceAll(season, "-", "_"))
+}
+
+// createEventsPartitionSQL returns an SQL statement to create the partition of events of the month
+func createEventsPartitionSQL(month time.Time) string {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "public"."%s" PARTITION OF "events" FOR VALUES FROM ('%s') TO ('%s')`,
+		eventsPartition(from), from.Format(time.DateOnly), from.AddDate(0, 1, 0).Format(time.DateOnly),
+	)
+}
+
+// createPlayersByGamesPartitionSQL returns an SQL statement to create the partition of per-game rows of the season
+func createPlayersByGamesPartitionSQL(season string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "public"."%s" PARTITION OF "players_by_games" FOR VALUES IN ('%s')`,
+		playersByGamesPartition(season), season,
+	)
+}
+
+// eventsPartitionRowsSQL returns an SQL condition of the rows of the partition of events of the month
+func eventsPartitionRowsSQL(month time.Time) string {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return fmt.Sprintf(`"timestamp" >= '%s' AND "timestamp" < '%s'`, from.Format(time.DateOnly), from.AddDate(0, 1, 0).Format(time.DateOnly))
+}
+
+// playersByGamesPartitionRowsSQL returns an SQL condition of the rows of the partition of per-game rows of the season
+func playersByGamesPartitionRowsSQL(season string) string {
+	return fmt.Sprintf(`"season" = '%s'`, season)
+}
+
+// defaultPartition returns the name of the default partition of the table, holding the rows of no other partition, e.g. "events_default"
+func defaultPartition(table table) string {
+	return fmt.Sprintf("%s_default", table)
+}
+
+// selectPartitionExistsSQL is an SQL statement to check whether the partition of the given name exists
+const selectPartitionExistsSQL = `SELECT to_regclass('"public".' || quote_ident($1)) IS NOT NULL`
+
+// selectDefaultPartitionRowsSQL returns an SQL statement to check whether the default partition of the table holds rows of the condition
+func selectDefaultPartitionRowsSQL(table table, rows string) string {
+	return fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM "%s" WHERE %s)`, defaultPartition(table), rows)
+}
+
+// moveDefaultPartitionRowsSQLs returns SQL statements to create a partition of the table with the rows of the condition held by its default partition,
+// as the partition can't be created while the default one holds its rows: the default partition is detached, the partition is created,
+// the rows are moved from the default partition into it through the table, and the default partition is attached again
+func moveDefaultPartitionRowsSQLs(table table, createSQL, rows string) []string {
+	return []string{
+		detachPartitionSQL(table, defaultPartition(table)),
+		createSQL,
+		fmt.Sprintf(`INSERT INTO "%s" SELECT * FROM "%s" WHERE %s`, table, defaultPartition(table), rows),
+		fmt.Sprintf(`DELETE FROM "%s" WHERE %s`, defaultPartition(table), rows),
+		fmt.Sprintf(`ALTER TABLE "%s" ATTACH PARTITION "%s" DEFAULT`, table, defaultPartition(table)),
+	}
+}
+
+// detachPartitionSQL returns an SQL statement to detach the partition from the table, so it stays as a standalone table
+func detachPartitionSQL(table table, partition string) string {
+	return fmt.Sprintf(`ALTER TABLE "%s" DETACH PARTITION "%s"`, table, partition)
+}
+
+// createPartition creates the partition of the table unless it exists. Rows of the partition stored in the default partition meanwhile,
+// e.g. events of a month beyond the partitions created ahead of time, are moved into it in the same transaction.
+func createPartition(ctx context.Context, db *sql.DB, table table, partition, createSQL, rows string) (err error) {
+	var exists, misplaced bool
+	if err := db.QueryRowContext(ctx, selectPartitionExistsSQL, partition).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to select partition %q: %w", partition, err)
+	}
+	if exists {
+		return nil
+	}
+	if err := db.QueryRowContext(ctx, selectDefaultPartitionRowsSQL(table, rows)).Scan(&misplaced); err != nil {
+		return fmt.Errorf("failed to select rows of partition %q in %q: %w", partition, defaultPartition(table), err)
+	}
+	if !misplaced {
+		if _, err := db.ExecContext(ctx, createSQL); err != nil {
+			return fmt.Errorf("failed to create partition %q: %w", partition, err)
+		}
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	for _, query := range moveDefaultPartitionRowsSQLs(table, createSQL, rows) {
+		if _, err = tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create partition %q with its rows in %q: %w", partition, defaultPartition(table), err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Println(fmt.Sprintf("Partition %q is created with its rows moved from %q", partition, defaultPartition(table)))
+
+	return nil
+}
+
+// createPartitions creates partitions of events from the previous month up to the configured number of months ahead,
+// and partitions of per-game rows of the current and the next seasons of every league.
+// A partition failed to create doesn't stop the others, and all the errors are returned.
+func createPartitions(ctx context.Context, cfg config, db *sql.DB, now time.Time) error {
+	var errs []error
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := -1; i <= cfg.partitionMonthsAhead; i++ {
+		m := month.AddDate(0, i, 0)
+		if err := createPartition(ctx, db, tableEvents, eventsPartition(m), createEventsPartitionSQL(m), eventsPartitionRowsSQL(m)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	seasons := map[string]bool{}
+	for _, league := range cfg.leagues {
+		seasons[league.season(now)] = true
+		seasons[league.season(now.AddDate(1, 0, 0))] = true
+	}
+	for _, season := range slices.Sorted(maps.Keys(seasons)) {
+		if err := createPartition(ctx, db, tablePlayersByGames, playersByGamesPartition(season),
+			createPlayersByGamesPartitionSQL(season), playersByGamesPartitionRowsSQL(season),
+		); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	log.Println(fmt.Sprintf("Partitions of %d months of events and %d seasons of per-game rows are created", cfg.partitionMonthsAhead+2, len(seasons)))
+	return nil
+}
+
+// createPartitionsPeriodically creates partitions ahead of time until the context is done
+func createPartitionsPeriodically(ctx context.Context, cfg config, db *sql.DB) {
+	ticker := time.NewTicker(partitionsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := createPartitions(ctx, cfg, db, now.UTC()); err != nil {
+				log.Println(fmt.Errorf("failed to create partitions: %w", err))
+			}
+		}
+	}
+}
+
+// detachPartition detaches the partition of events of the month in format "2006-01",
+// or the partition of per-game rows of the season, e.g. "2024-25" or "2025"
+func detachPartition(ctx context.Context, db *sql.DB, table table, key string) error {
+	var partition string
+	switch {
+	case table == tableEvents && monthPattern.MatchString(key):
+		month, err := time.Parse("2006-01", key)
+		if err != nil {
+			return fmt.Errorf("invalid month %q: %w", key, err)
+		}
+		partition = eventsPartition(month)
+	case table == tablePlayersByGames && seasonPattern.MatchString(key):
+		partition = playersByGamesPartition(key)
+	default:
+		return fmt.Errorf("invalid partition %q of %q: month of %q or season of %q expected", key, table, tableEvents, tablePlayersByGames)
+	}
+
+	if _, err := db.ExecContext(ctx, detachPartitionSQL(table, partition)); err != nil {
+		return fmt.Errorf("failed to detach partition %q: %w", partition, err)
+	}
+	log.Println(fmt.Sprintf("Partition %q is detached from %q", partition, table))
+
+	return nil
+}
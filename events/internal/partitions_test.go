@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateEventsPartitionSQL(t *testing.T) {
+	expected := `CREATE TABLE IF NOT EXISTS "public"."events_2025_12" PARTITION OF "events" FOR VALUES FROM ('2025-12-01') TO ('2026-01-01')`
+	if actual := createEventsPartitionSQL(time.Date(2025, time.December, 17, 23, 0, 0, 0, time.UTC)); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
+
+func TestCreatePlayersByGamesPartitionSQL(t *testing.T) {
+	for season, expected := range map[string]string{
+		"2024-25": `CREATE TABLE IF NOT EXISTS "public"."players_by_games_2024_25" PARTITION OF "players_by_games" FOR VALUES IN ('2024-25')`,
+		"2025":    `CREATE TABLE IF NOT EXISTS "public"."players_by_games_2025" PARTITION OF "players_by_games" FOR VALUES IN ('2025')`,
+	} {
+		if actual := createPlayersByGamesPartitionSQL(season); actual != expected {
+			t.Errorf("expected %s, got %s", expected, actual)
+		}
+	}
+}
+
+func TestCreatePartition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	month := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	partition, createSQL, rows := eventsPartition(month), createEventsPartitionSQL(month), eventsPartitionRowsSQL(month)
+
+	// an existing partition is kept
+	mock.ExpectQuery(esc(selectPartitionExistsSQL)).WithArgs(partition).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	// a partition without rows in the default partition is created
+	mock.ExpectQuery(esc(selectPartitionExistsSQL)).WithArgs(partition).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(esc(`SELECT EXISTS (SELECT 1 FROM "events_default" WHERE "timestamp" >= '2026-03-01' AND "timestamp" < '2026-04-01')`)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(esc(createSQL)).WillReturnResult(driver.RowsAffected(0))
+	// events of the month stored in the default partition before it's created are moved into it
+	mock.ExpectQuery(esc(selectPartitionExistsSQL)).WithArgs(partition).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(esc(selectDefaultPartitionRowsSQL(tableEvents, rows))).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectBegin()
+	mock.ExpectExec(esc(`ALTER TABLE "events" DETACH PARTITION "events_default"`)).WillReturnResult(driver.RowsAffected(0))
+	mock.ExpectExec(esc(createSQL)).WillReturnResult(driver.RowsAffected(0))
+	mock.ExpectExec(esc(`INSERT INTO "events" SELECT * FROM "events_default" WHERE "timestamp" >= '2026-03-01' AND "timestamp" < '2026-04-01'`)).
+		WillReturnResult(driver.RowsAffected(2))
+	mock.ExpectExec(esc(`DELETE FROM "events_default" WHERE "timestamp" >= '2026-03-01' AND "timestamp" < '2026-04-01'`)).
+		WillReturnResult(driver.RowsAffected(2))
+	mock.ExpectExec(esc(`ALTER TABLE "events" ATTACH PARTITION "events_default" DEFAULT`)).WillReturnResult(driver.RowsAffected(0))
+	mock.ExpectCommit()
+
+	for range 3 {
+		if err := createPartition(t.Context(), db, tableEvents, partition, createSQL, rows); err != nil {
+			t.Fatalf("failed to create partition: %v", err)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCreatePartition_RollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	season := "2026-27"
+	partition, createSQL, rows := playersByGamesPartition(season), createPlayersByGamesPartitionSQL(season), playersByGamesPartitionRowsSQL(season)
+
+	mock.ExpectQuery(esc(selectPartitionExistsSQL)).WithArgs(partition).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(esc(`SELECT EXISTS (SELECT 1 FROM "players_by_games_default" WHERE "season" = '2026-27')`)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectBegin()
+	mock.ExpectExec(esc(`ALTER TABLE "players_by_games" DETACH PARTITION "players_by_games_default"`)).WillReturnResult(driver.RowsAffected(0))
+	mock.ExpectExec(esc(createSQL)).WillReturnError(driver.ErrBadConn)
+	// the default partition stays attached with its rows
+	mock.ExpectRollback()
+
+	if err := createPartition(t.Context(), db, tablePlayersByGames, partition, createSQL, rows); err == nil {
+		t.Error("expected error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestDetachPartition_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		table table
+		key   string
+	}{
+		{tableEvents, "2024-25"},
+		{tableEvents, "2024-13"},
+		{tablePlayersByGames, "2024-09-01"},
+		{tablePlayersByGames, "2024_25\"; DROP TABLE \"events"},
+		{tablePlayersStatistics, "2024-25"},
+	} {
+		if err := detachPartition(t.Context(), nil, tc.table, tc.key); err == nil {
+			t.Errorf("expected error for partition %q of %q", tc.key, tc.table)
+		}
+	}
+}
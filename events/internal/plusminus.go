@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// markPlusMinusUnprocessedSQL is an SQL statement to mark the plus-minus of the game of the team unprocessed,
+// after an event changing on-court intervals or the score, so it's recalculated
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+const markPlusMinusUnprocessedSQL = `UPDATE "team_games" SET "plus_minus_processed" = false
+WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3 AND "plus_minus_processed"`
+
+// selectUnprocessedPlusMinusGamesSQL is an SQL statement to select paired games with unprocessed plus-minus of either team.
+// Plus-minus of a game isn't calculated until its opponent is known, as points of the opponent aren't,
+// and games with purged events of either team keep their plus-minus, as it can't be recalculated anymore.
+const selectUnprocessedPlusMinusGamesSQL = `SELECT g."league", g."team", g."game_date", g."opponent", COALESCE(g."season", ''), COALESCE(g."season_type", 'regular')
+FROM "team_games" g
+WHERE NOT g."plus_minus_processed" AND g."opponent" IS NOT NULL AND g."purged_at" IS NULL AND NOT EXISTS (
+	SELECT 1 FROM "team_games" o WHERE o."league" = g."league" AND o."team" = g."opponent" AND o."game_date" = g."game_date" AND o."purged_at" IS NOT NULL
+)
+ORDER BY g."league", g."game_date", g."team"`
+
+// updatePlayersPlusMinusSQL is an SQL statement to recalculate the plus-minus of the players of the team in the game, i.e. the points of the team
+// minus the points of the opponent scored while each player was on the court. A player is on the court from an 'enter' event until the next 'enter' or 'exit'
+// event of the player, or until the end of the game if there is none, and a shot at the moment of a substitution counts for the players leaving the court.
+// It returns the changes of the plus-minus of the per-game rows changed.
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+// $4: opponent
+//
+// Events are selected from the partitions of the timestamps the game date in any timezone spans.
+const updatePlayersPlusMinusSQL = `WITH "intervals" AS (
+	SELECT "player", "timestamp" AS "entered_at", COALESCE("next_timestamp", 'infinity') AS "exited_at"
+	FROM (
+		SELECT "player", "event", "timestamp", LEAD("timestamp") OVER (PARTITION BY "player" ORDER BY ` + substitutionsOrder + `) AS "next_timestamp"
+		FROM "events"
+		WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3 AND "event" IN ('enter', 'exit')
+			AND "timestamp" >= $3::date - 1 AND "timestamp" < $3::date + 2
+	)
+	WHERE "event" = 'enter'
+), "shots" AS (
+	SELECT "timestamp", CASE WHEN "team" = $2 THEN "value" ELSE -"value" END AS "differential"
+	FROM "events"
+	WHERE "league" = $1 AND "team" IN ($2, $4) AND "game_date" = $3 AND "event" = 'shot'
+		AND "timestamp" >= $3::date - 1 AND "timestamp" < $3::date + 2
+), "on_court" AS (
+	SELECT i."player", SUM(s."differential") AS "plus_minus"
+	FROM "intervals" i JOIN "shots" s ON s."timestamp" > i."entered_at" AND s."timestamp" <= i."exited_at"
+	GROUP BY i."player"
+), "previous" AS (
+	SELECT "player", "season", "plus_minus" FROM "players_by_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+)
+UPDATE "players_by_games" g SET "plus_minus" = COALESCE(c."plus_minus", 0)
+FROM "previous" p LEFT JOIN "on_court" c ON c."player" = p."player"
+WHERE g."league" = $1 AND g."player" = p."player" AND g."game_date" = $3 AND g."season" = p."season" AND g."plus_minus" <> COALESCE(c."plus_minus", 0)
+RETURNING g."player", g."season", g."season_type", g."plus_minus" - p."plus_minus"`
+
+// updatePlusMinusProcessedSQL is an SQL statement to mark the plus-minus of the game of both teams processed, with the same parameters
+const updatePlusMinusProcessedSQL = `UPDATE "team_games" SET "plus_minus_processed" = true WHERE "league" = $1 AND "game_date" = $3 AND "team" IN ($2, $4)`
+
+// pairedGame is a game of the team with its opponent in the season
+type pairedGame struct {
+	teamGame
+	opponent   string
+	season     string
+	seasonType seasonType
+}
+
+// against returns the same game of the opponent
+func (g pairedGame) against() pairedGame {
+	return pairedGame{teamGame{g.league, g.opponent, g.gameDate}, g.team, g.season, g.seasonType}
+}
+
+// plusMinusChange is a change of the plus-minus of the per-game row of a player
+type plusMinusChange struct {
+	player, team string
+	season       string
+	seasonType   seasonType
+	change       int
+}
+
+// markPlusMinusUnprocessed marks the plus-minus of the game unprocessed after an event changing it
+func markPlusMinusUnprocessed(ctx context.Context, tx *sql.Tx, stmts preparedStatements, game teamGame) error {
+	if err := txExec(ctx, tx, stmts.markPlusMinusUnprocessed, game.league, game.team, game.gameDate); err != nil {
+		return fmt.Errorf("failed to mark plus-minus of game %s unprocessed: %w", game, err)
+	}
+
+	return nil
+}
+
+// updatePlusMinus recalculates the plus-minus and the lineups of the games with unprocessed plus-minus of either team, each game in its own transaction
+func updatePlusMinus(ctx context.Context, db *sql.DB, stmts preparedStatements) error {
+	rows, err := stmts.selectUnprocessedPlusMinusGames.QueryContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to query games with unprocessed plus-minus: %w", err)
+	}
+
+	var games []pairedGame
+	for rows.Next() {
+		var g pairedGame
+		var gameDate time.Time
+		if err := rows.Scan(&g.league, &g.team, &gameDate, &g.opponent, &g.season, &g.seasonType); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan game with unprocessed plus-minus: %w", err)
+		}
+		g.gameDate = gameDate.Format(time.DateOnly)
+
+		// a game is recalculated once for both teams
+		if !slices.Contains(games, g.against()) {
+			games = append(games, g)
+		}
+	}
+	closeIt("rows", rows)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query games with unprocessed plus-minus: %w", err)
+	}
+
+	for _, g := range games {
+		if err := retryTransaction(ctx, fmt.Sprintf("plus-minus of game %s", g), func() error {
+			return updateGamePlusMinus(ctx, db, stmts, g)
+		}); err != nil {
+			return err
+		}
+		log.Println(fmt.Sprintf("Plus-minus of game %s against %q updated successfully", g, g.opponent))
+	}
+
+	return nil
+}
+
+// updateGamePlusMinus recalculates the plus-minus of the players of both teams of the game in a transaction.
+// Lineups, on/off statistics and tempo of both teams depend on the same intervals and shots, so they are recalculated in the same transaction,
+// and their season caches are marked unprocessed to be copied to Redis after it's committed, see updateSeasonCaches.
+// The aggregates of both teams are locked, so events of the game wait for the recalculation and the changes aren't counted by them.
+func updateGamePlusMinus(ctx context.Context, db *sql.DB, stmts preparedStatements, game pairedGame) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	if err = lockKeys(ctx, tx, stmts, aggregateKey(game.league, subjectTeam, game.team), aggregateKey(game.league, subjectTeam, game.opponent)); err != nil {
+		return err
+	}
+
+	if err = updatePlayersPlusMinus(ctx, tx, stmts, game); err != nil {
+		return err
+	}
+
+	// a game without a known season isn't counted in lineups, on/off statistics and tempo of seasons
+	for _, g := range []pairedGame{game, game.against()} {
+		if g.season == "" {
+			continue
+		}
+		events, err := selectCourtEvents(ctx, tx, g)
+		if err != nil {
+			return err
+		}
+		if err = updateLineups(ctx, tx, g, events); err != nil {
+			return err
+		}
+		if err = updateOnOff(ctx, tx, g, events); err != nil {
+			return err
+		}
+		if err = updateTempo(ctx, tx, stmts, g, events); err != nil {
+			return err
+		}
+		if err = markSeasonCacheUnprocessed(ctx, tx, seasonCache{g.league, subjectTeam, g.team, g.season, g.seasonType}); err != nil {
+			return err
+		}
+	}
+
+	if err = txExec(ctx, tx, stmts.updatePlusMinusProcessed, game.league, game.team, game.gameDate, game.opponent); err != nil {
+		return fmt.Errorf("failed to mark plus-minus of game %s processed: %w", game, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// updatePlayersPlusMinus recalculates the plus-minus of the players of both teams of the game, and adds the changes to their statistics.
+// The aggregates of the players changed are locked together with both teams before, as events of the players lock them.
+func updatePlayersPlusMinus(ctx context.Context, tx *sql.Tx, stmts preparedStatements, game pairedGame) error {
+	var changes []plusMinusChange
+	for _, g := range []pairedGame{game, game.against()} {
+		rows, err := tx.StmtContext(ctx, stmts.updatePlayersPlusMinus).QueryContext(ctx, g.league, g.team, g.gameDate, g.opponent)
+		if err != nil {
+			return fmt.Errorf("failed to update plus-minus of game %s: %w", g, err)
+		}
+		for rows.Next() {
+			c := plusMinusChange{team: g.team}
+			if err := rows.Scan(&c.player, &c.season, &c.seasonType, &c.change); err != nil {
+				closeIt("rows", rows)
+				return fmt.Errorf("failed to scan plus-minus of game %s: %w", g, err)
+			}
+			changes = append(changes, c)
+		}
+		closeIt("rows", rows)
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to update plus-minus of game %s: %w", g, err)
+		}
+	}
+
+	keys := []string{aggregateKey(game.league, subjectTeam, game.team), aggregateKey(game.league, subjectTeam, game.opponent)}
+	for _, c := range changes {
+		keys = append(keys, aggregateKey(game.league, subjectPlayer, c.player))
+	}
+	if err := lockKeys(ctx, tx, stmts, keys...); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		e := event{League: game.league, Player: c.player, Team: c.team}
+		if err := incrementStatistics(ctx, tx, stmts, e, c.season, c.seasonType, false, Statistics{PlusMinus: float64(c.change)}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
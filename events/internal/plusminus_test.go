@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"testing"
+)
+
+const bostonCelticsID = "boston-celtics"
+
+func TestUpdatePlayersPlusMinus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	updateExpectedPrepare, updateStmt := prepareMockStmt(t, db, mock, updatePlayersPlusMinusSQL)
+	incrementStatisticsExpectedPrepares, incrementStatisticsStmts := prepareStatisticsMockStmts(t, db, mock, operationIncrementStatistics)
+	lockKeyExpectedPrepare, lockKeyStmt := prepareMockStmt(t, db, mock, lockKeySQL)
+	stmts := preparedStatements{
+		lockKey:                  lockKeyStmt,
+		updatePlayersPlusMinus:   updateStmt,
+		forStatisticsByOperation: map[operation]map[table]*sql.Stmt{operationIncrementStatistics: incrementStatisticsStmts},
+	}
+
+	season, seasonType := "2024-25", seasonTypeRegular
+	game := pairedGame{teamGame{defaultLeague, bostonCelticsID, "2025-03-15"}, losAngelesLakersID, season, seasonType}
+
+	mock.ExpectBegin()
+	// the plus-minus of both teams is recalculated, and only changed rows are returned
+	updateExpectedPrepare.ExpectQuery().WithArgs(defaultLeague, bostonCelticsID, "2025-03-15", losAngelesLakersID).
+		WillReturnRows(sqlmock.NewRows([]string{"player", "season", "season_type", "change"}))
+	updateExpectedPrepare.ExpectQuery().WithArgs(defaultLeague, losAngelesLakersID, "2025-03-15", bostonCelticsID).
+		WillReturnRows(sqlmock.NewRows([]string{"player", "season", "season_type", "change"}).AddRow(leBronJamesID, season, seasonType, -5))
+
+	// the players changed are locked together with the teams, in the order of their keys
+	lockKeyExpectedPrepare.ExpectExec().WithArgs(defaultLeague + ":player:" + leBronJamesID).WillReturnResult(driver.RowsAffected(1))
+	lockKeyExpectedPrepare.ExpectExec().WithArgs(defaultLeague + ":team:" + bostonCelticsID).WillReturnResult(driver.RowsAffected(1))
+	lockKeyExpectedPrepare.ExpectExec().WithArgs(defaultLeague + ":team:" + losAngelesLakersID).WillReturnResult(driver.RowsAffected(1))
+
+	// only the change of the plus-minus is added to the statistics, without adding a game
+	change := []driver.Value{0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, -5.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0}
+	incrementStatisticsExpectedPrepares[tablePlayersStatistics].ExpectExec().WithArgs(append([]driver.Value{leBronJamesID, season, seasonType, defaultLeague}, change...)...).WillReturnResult(driver.RowsAffected(1))
+	incrementStatisticsExpectedPrepares[tableTeamsStatistics].ExpectExec().WithArgs(append([]driver.Value{losAngelesLakersID, season, seasonType, defaultLeague}, change...)...).WillReturnResult(driver.RowsAffected(1))
+	incrementStatisticsExpectedPrepares[tablePlayersTeamsStatistics].ExpectExec().WithArgs(append([]driver.Value{leBronJamesID, losAngelesLakersID, season, seasonType, defaultLeague}, change...)...).WillReturnResult(driver.RowsAffected(1))
+
+	tx, err := db.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := updatePlayersPlusMinus(t.Context(), tx, stmts, game); err != nil {
+		t.Fatalf("failed to update plus-minus: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
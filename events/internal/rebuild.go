@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"log"
+	"time"
+)
+
+// SQL statements to rebuild per-game rows and statistics of players from scratch.
+// Per-game rows of games with purged raw events are kept as they can't be rebuilt.
+// Parameter placeholder $1 is intended for an array of players.
+const (
+	deletePlayersByGamesSQL = `DELETE FROM "players_by_games" p WHERE p."player" = ANY($1) AND NOT EXISTS (
+	SELECT 1 FROM "team_games" g 
+	WHERE g."league" = p."league" AND g."team" = p."team" AND g."game_date" = p."game_date" AND g."purged_at" IS NOT NULL
+)`
+	deletePlayersStatisticsSQL      = `DELETE FROM "players_statistics" WHERE "player" = ANY($1)`
+	deletePlayersTeamsStatisticsSQL = `DELETE FROM "players_teams_statistics" WHERE "player" = ANY($1)`
+	// attempts and offensive rebounds are recalculated by the statement of 'miss' events, so it's run for games with shots or rebounds as well
+	selectPlayersGamesEventsSQL = `SELECT DISTINCT "league", "player", "team", "game_date", "event" FROM "events" WHERE "player" = ANY($1)
+UNION
+SELECT DISTINCT "league", "player", "team", "game_date", 'miss' FROM "events" WHERE "player" = ANY($1) AND "event" IN ('shot', 'rebound')`
+	selectPlayersSeasonsSQL = `SELECT DISTINCT "league", "player", "team", "season", "season_type" FROM "players_by_games_all" WHERE "player" = ANY($1)`
+)
+
+// gameEvents identifies all the events of the same type of a player in a game
+type gameEvents struct {
+	league       string
+	player, team string
+	gameDate     time.Time
+	event        eventType
+}
+
+// rebuildPlayers recalculates per-game rows and statistics of the players from their raw events, e.g. after corrections.
+// Statistics of their teams are recalculated as well. The caches are to be updated after the transaction is committed.
+func rebuildPlayers(ctx context.Context, tx *sql.Tx, cfg config, stmts preparedStatements, players []string) error {
+	if len(players) == 0 {
+		return nil
+	}
+
+	for _, deleteSQL := range []string{deletePlayersByGamesSQL, deletePlayersStatisticsSQL, deletePlayersTeamsStatisticsSQL} {
+		if _, err := tx.ExecContext(ctx, deleteSQL, pq.Array(players)); err != nil {
+			return fmt.Errorf("failed to delete rows of players: %w", err)
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, selectPlayersGamesEventsSQL, pq.Array(players))
+	if err != nil {
+		return fmt.Errorf("failed to select games events of players: %w", err)
+	}
+
+	// all the rows are read before further statements are executed in the same transaction
+	var games []gameEvents
+	for rows.Next() {
+		var g gameEvents
+		if err := rows.Scan(&g.league, &g.player, &g.team, &g.gameDate, &g.event); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan games events of players: %w", err)
+		}
+		games = append(games, g)
+	}
+	closeIt("rows", rows)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to select games events of players: %w", err)
+	}
+
+	for _, g := range games {
+		league, ok := cfg.leagues[g.league]
+		if !ok {
+			return fmt.Errorf("unknown league %q of %q on %s", g.league, g.player, g.gameDate.Format(time.DateOnly))
+		}
+
+		gameDate, season := g.gameDate.Format(time.DateOnly), league.season(g.gameDate)
+		seasonType := league.Calendar.seasonType(season, gameDate)
+
+		if err := txExec(ctx, tx, stmts.forUpdatesByEventType[g.event], g.player, g.team, gameDate, season, seasonType, g.league); err != nil {
+			return fmt.Errorf("failed to update %q table for %q events of %q on %s: %w", tablePlayersByGames, g.event, g.player, gameDate, err)
+		}
+	}
+
+	// statistics are recalculated from all the per-game rows, including the ones of games with purged raw events
+	rows, err = tx.QueryContext(ctx, selectPlayersSeasonsSQL, pq.Array(players))
+	if err != nil {
+		return fmt.Errorf("failed to select seasons of players: %w", err)
+	}
+
+	type statisticsKey struct {
+		stint      event // the player, the team and the league
+		season     string
+		seasonType seasonType
+	}
+	var keys []statisticsKey
+	for rows.Next() {
+		var key statisticsKey
+		if err := rows.Scan(&key.stint.League, &key.stint.Player, &key.stint.Team, &key.season, &key.seasonType); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan seasons of players: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	closeIt("rows", rows)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to select seasons of players: %w", err)
+	}
+
+	for _, key := range keys {
+		if err := recalculateStatistics(ctx, tx, stmts, key.stint, key.season, key.seasonType); err != nil {
+			return err
+		}
+	}
+	log.Println(fmt.Sprintf("Rebuilt %d games events and %d statistics of %d players", len(games), len(keys), len(players)))
+
+	return nil
+}
@@ -0,0 +1,293 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+type subject string
+
+// subjects of statistics, also registered with stable identifiers
+const (
+	subjectPlayer subject = "player"
+	subjectTeam   subject = "team"
+)
+
+var subjects = []subject{subjectPlayer, subjectTeam}
+
+// Registry tables keep stable identifiers and display names of players and teams,
+// and aliases resolving normalized names to identifiers.
+const (
+	tablePlayers        table = "players"
+	tableTeams          table = "teams"
+	tablePlayersAliases table = "players_aliases"
+	tableTeamsAliases   table = "teams_aliases"
+)
+
+const (
+	operationSelectID      operation = "select_id"
+	operationSelectAlias   operation = "select_alias"
+	operationInsertID      operation = "insert_id"
+	operationDeleteID      operation = "delete_id"
+	operationUpsertName    operation = "upsert_name"
+	operationInsertAlias   operation = "insert_alias"
+	operationUnprocessAll  operation = "unprocess_all"
+	operationSelectAliases operation = "select_aliases"
+	operationUpdateAlias   operation = "update_alias"
+)
+
+// registrySQLs returns SQL statements to maintain the registry of the subject
+func registrySQLs(subject subject) map[operation]string {
+	entities, aliases := fmt.Sprintf("%ss", subject), fmt.Sprintf("%ss_aliases", subject)
+	groups := `'', ''` // conference and division of teams only
+	if subject == subjectTeam {
+		groups = `COALESCE(e."conference", ''), COALESCE(e."division", '')`
+	}
+	return map[operation]string{
+		operationSelectID:    fmt.Sprintf(`SELECT "id" FROM "%s" WHERE "id" = $1`, entities),
+		operationSelectAlias: fmt.Sprintf(`SELECT "%s" FROM "%s" WHERE "alias" = $1`, subject, aliases),
+		operationInsertID:    fmt.Sprintf(`INSERT INTO "%s" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO NOTHING`, entities),
+		operationDeleteID:    fmt.Sprintf(`DELETE FROM "%s" WHERE "id" = $1`, entities),
+		operationUpsertName:  fmt.Sprintf(`INSERT INTO "%s" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`, entities),
+		operationInsertAlias: fmt.Sprintf(`INSERT INTO "%s" ("alias", "%s") VALUES ($1, $2) ON CONFLICT ("alias") DO NOTHING`, aliases, subject),
+		// name changes are propagated to Redis through all the aliases of the subject
+		operationUnprocessAll:  fmt.Sprintf(`UPDATE "%s" SET "processed" = false WHERE "%s" = $1`, aliases, subject),
+		operationSelectAliases: fmt.Sprintf(`SELECT a."alias", a."%s", e."name", %s FROM "%s" a JOIN "%s" e ON e."id" = a."%s" WHERE a."processed" = false`, subject, groups, aliases, entities, subject),
+		operationUpdateAlias:   fmt.Sprintf(`UPDATE "%s" SET "processed" = true WHERE "alias" = $1`, aliases),
+	}
+}
+
+// Redis hashes mirroring the registry for the statistics service
+func registryAliasesKey(subject subject) string { return fmt.Sprintf("registry:%s:aliases", subject) }
+func registryNamesKey(subject subject) string   { return fmt.Sprintf("registry:%s:names", subject) }
+func registryConferencesKey(subject subject) string {
+	return fmt.Sprintf("registry:%s:conferences", subject)
+}
+func registryDivisionsKey(subject subject) string {
+	return fmt.Sprintf("registry:%s:divisions", subject)
+}
+
+var errAliasConflict = errors.New("alias belongs to another identifier")
+
+var idPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// normalizeName returns the alias of the name: lower-cased with whitespaces collapsed
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// slugify returns an identifier for the name consisting of lower-cased letters and digits separated by dashes
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// resolve returns the identifier of the subject given by its identifier or by any of its aliases.
+// Unknown names are registered with a new identifier derived from the name.
+func resolve(ctx context.Context, tx *sql.Tx, stmts preparedStatements, subject subject, nameOrID string) (string, error) {
+	registry := stmts.forRegistryBySubject[subject]
+
+	var id string
+	err := tx.StmtContext(ctx, registry[operationSelectID]).QueryRowContext(ctx, nameOrID).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to select %s identifier %q: %w", subject, nameOrID, err)
+	}
+
+	alias := normalizeName(nameOrID)
+	if id, err = selectAlias(ctx, tx, registry, subject, alias); err != nil || id != "" {
+		return id, err
+	}
+
+	slug := slugify(nameOrID)
+	if slug == "" {
+		return "", fmt.Errorf("failed to derive %s identifier from %q", subject, nameOrID)
+	}
+
+	// the first free of "slug", "slug-2", "slug-3", etc.
+	// Concurrent transactions may register the same name, or take the identifier for another name, meanwhile:
+	// the insertion waits for them and skips a taken identifier, so the name is resolved to the identifier registered first.
+	for i := 1; ; i++ {
+		id = slug
+		if i > 1 {
+			id = fmt.Sprintf("%s-%d", slug, i)
+		}
+
+		var existing string
+		err := tx.StmtContext(ctx, registry[operationSelectID]).QueryRowContext(ctx, id).Scan(&existing)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("failed to select %s identifier %q: %w", subject, id, err)
+		}
+
+		result, err := tx.StmtContext(ctx, registry[operationInsertID]).ExecContext(ctx, id, strings.TrimSpace(nameOrID))
+		if err != nil {
+			return "", fmt.Errorf("failed to register %s %q as %q: %w", subject, nameOrID, id, err)
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return "", fmt.Errorf("failed to register %s %q as %q: %w", subject, nameOrID, id, err)
+		}
+		if inserted > 0 {
+			break
+		}
+
+		if owner, err := selectAlias(ctx, tx, registry, subject, alias); err != nil || owner != "" {
+			return owner, err
+		}
+	}
+
+	if err := txExec(ctx, tx, registry[operationInsertAlias], alias, id); err != nil {
+		return "", fmt.Errorf("failed to register %s alias %q of %q: %w", subject, alias, id, err)
+	}
+
+	// the name registered concurrently with another identifier keeps it, and the new identifier is dropped
+	owner, err := selectAlias(ctx, tx, registry, subject, alias)
+	if err != nil {
+		return "", err
+	}
+	if owner != id {
+		if err := txExec(ctx, tx, registry[operationDeleteID], id); err != nil {
+			return "", fmt.Errorf("failed to delete %s %q: %w", subject, id, err)
+		}
+		return owner, nil
+	}
+
+	log.Println(fmt.Sprintf("Registered %s %q as %q", subject, nameOrID, id))
+
+	return id, nil
+}
+
+// selectAlias returns the identifier the alias of the subject belongs to, empty for an unknown alias
+func selectAlias(ctx context.Context, tx *sql.Tx, registry map[operation]*sql.Stmt, subject subject, alias string) (string, error) {
+	var id string
+	err := tx.StmtContext(ctx, registry[operationSelectAlias]).QueryRowContext(ctx, alias).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to select %s alias %q: %w", subject, alias, err)
+	}
+
+	return id, nil
+}
+
+// register sets the display name of the subject with the given identifier, and adds the aliases, including the name itself.
+// Non-empty timezone of the venue, conference and division are set for a team.
+func register(ctx context.Context, db *sql.DB, stmts preparedStatements, subject subject, id string, registration registration) (err error) {
+	registry := stmts.forRegistryBySubject[subject]
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	if err = txExec(ctx, tx, registry[operationUpsertName], id, registration.Name); err != nil {
+		return fmt.Errorf("failed to upsert %s %q: %w", subject, id, err)
+	}
+
+	if registration.Timezone != "" {
+		if err = txExec(ctx, tx, stmts.updateTeamTimezone, id, registration.Timezone); err != nil {
+			return fmt.Errorf("failed to update timezone of %s %q: %w", subject, id, err)
+		}
+	}
+
+	if registration.Conference != "" || registration.Division != "" {
+		if err = txExec(ctx, tx, stmts.updateTeamGroups, id, registration.Conference, registration.Division); err != nil {
+			return fmt.Errorf("failed to update groups of %s %q: %w", subject, id, err)
+		}
+	}
+
+	if err = txExec(ctx, tx, registry[operationUnprocessAll], id); err != nil {
+		return fmt.Errorf("failed to mark aliases of %s %q unprocessed: %w", subject, id, err)
+	}
+
+	for _, alias := range append([]string{registration.Name}, registration.Aliases...) {
+		alias = normalizeName(alias)
+		if err = txExec(ctx, tx, registry[operationInsertAlias], alias, id); err != nil {
+			return fmt.Errorf("failed to insert %s alias %q: %w", subject, alias, err)
+		}
+
+		var owner string
+		if err = tx.StmtContext(ctx, registry[operationSelectAlias]).QueryRowContext(ctx, alias).Scan(&owner); err != nil {
+			return fmt.Errorf("failed to select %s alias %q: %w", subject, alias, err)
+		}
+		if owner != id {
+			return fmt.Errorf("%s alias %q of %q: %w %q", subject, alias, id, errAliasConflict, owner)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// updateRegistryCache copies unprocessed aliases and display names of the subject to Redis and marks them processed
+func updateRegistryCache(ctx context.Context, subject subject, stmts preparedStatements, rdb *redis.Client) error {
+	registry := stmts.forRegistryBySubject[subject]
+
+	rows, err := registry[operationSelectAliases].QueryContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to query unprocessed %s aliases: %w", subject, err)
+	}
+	defer closeIt("rows", rows)
+
+	for rows.Next() {
+		var alias, id, name, conference, division string
+		if err := rows.Scan(&alias, &id, &name, &conference, &division); err != nil {
+			return fmt.Errorf("failed to scan %s alias: %w", subject, err)
+		}
+
+		if _, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, registryAliasesKey(subject), alias, id)
+			pipe.HSet(ctx, registryNamesKey(subject), id, name)
+			if conference != "" {
+				pipe.HSet(ctx, registryConferencesKey(subject), id, conference)
+			}
+			if division != "" {
+				pipe.HSet(ctx, registryDivisionsKey(subject), id, division)
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to set to Redis %s alias %q of %q: %w", subject, alias, id, err)
+		}
+
+		if _, err := registry[operationUpdateAlias].ExecContext(ctx, alias); err != nil {
+			return fmt.Errorf("failed to update processed %s alias %q: %w", subject, alias, err)
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryHandler_AdminOnly(t *testing.T) {
+	handler := registryHandler(t.Context(), config{adminToken: "secret"}, nil, preparedStatements{}, nil, subjectPlayer)
+	for _, token := range []string{"", "wrong"} {
+		r := httptest.NewRequest(http.MethodPut, "/api/v1/players/lebron-james", strings.NewReader(`{"name":"LeBron James"}`))
+		r.SetPathValue("id", "lebron-james")
+		r.Header.Set(adminTokenHeader, token)
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("token %q: expected status code %d, got %d", token, http.StatusForbidden, w.Code)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	for name, expected := range map[string]string{
+		leBronJames:         "lebron james",
+		"  Lebron   James ": "lebron james",
+		"LEBRON\tJAMES":     "lebron james",
+	} {
+		if actual := normalizeName(name); actual != expected {
+			t.Errorf("normalizeName(%q): expected %q, got %q", name, expected, actual)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	for name, expected := range map[string]string{
+		leBronJames:          leBronJamesID,
+		losAngelesLakers:     losAngelesLakersID,
+		"Shaquille O'Neal":   "shaquille-o-neal",
+		"  P.J. Tucker  ":    "p-j-tucker",
+		"Team: 76ers":        "team-76ers",
+		"Nikola Jokić":       "nikola-joki",
+		"---":                "",
+		"Karl-Anthony Towns": "karl-anthony-towns",
+	} {
+		if actual := slugify(name); actual != expected {
+			t.Errorf("slugify(%q): expected %q, got %q", name, expected, actual)
+		}
+		if actual := slugify(name); actual != "" && !idPattern.MatchString(actual) {
+			t.Errorf("slugify(%q): %q doesn't match identifier pattern", name, actual)
+		}
+	}
+}
+
+func TestResolve_RegistersUnknownName(t *testing.T) {
+	ctx := t.Context()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	expectedPrepares, registryStmts := prepareRegistryMockStmts(t, db, mock)
+	teams := expectedPrepares[subjectTeam]
+
+	mock.ExpectBegin()
+	teams[operationSelectID].ExpectQuery().WithArgs(losAngelesLakers).WillReturnError(sql.ErrNoRows)
+	teams[operationSelectAlias].ExpectQuery().WithArgs("los angeles lakers").WillReturnError(sql.ErrNoRows)
+	// the identifier derived from the name is taken by another team
+	teams[operationSelectID].ExpectQuery().WithArgs(losAngelesLakersID).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(losAngelesLakersID))
+	teams[operationSelectID].ExpectQuery().WithArgs(losAngelesLakersID + "-2").WillReturnError(sql.ErrNoRows)
+	teams[operationInsertID].ExpectExec().WithArgs(losAngelesLakersID+"-2", losAngelesLakers).WillReturnResult(driver.RowsAffected(1))
+	teams[operationInsertAlias].ExpectExec().WithArgs("los angeles lakers", losAngelesLakersID+"-2").WillReturnResult(driver.RowsAffected(1))
+	teams[operationSelectAlias].ExpectQuery().WithArgs("los angeles lakers").WillReturnRows(sqlmock.NewRows([]string{"team"}).AddRow(losAngelesLakersID + "-2"))
+	mock.ExpectRollback()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	id, err := resolve(ctx, tx, preparedStatements{forRegistryBySubject: registryStmts}, subjectTeam, losAngelesLakers)
+	if err != nil {
+		t.Fatalf("failed to resolve: %v", err)
+	}
+
+	if id != losAngelesLakersID+"-2" {
+		t.Errorf("expected %q, got %q", losAngelesLakersID+"-2", id)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("failed to rollback transaction: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestResolve_NameRegisteredConcurrently(t *testing.T) {
+	ctx := t.Context()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	expectedPrepares, registryStmts := prepareRegistryMockStmts(t, db, mock)
+	teams := expectedPrepares[subjectTeam]
+
+	mock.ExpectBegin()
+	teams[operationSelectID].ExpectQuery().WithArgs(losAngelesLakers).WillReturnError(sql.ErrNoRows)
+	teams[operationSelectAlias].ExpectQuery().WithArgs("los angeles lakers").WillReturnError(sql.ErrNoRows)
+	teams[operationSelectID].ExpectQuery().WithArgs(losAngelesLakersID).WillReturnError(sql.ErrNoRows)
+	// another transaction registers the same name meanwhile, so the insertion is skipped
+	teams[operationInsertID].ExpectExec().WithArgs(losAngelesLakersID, losAngelesLakers).WillReturnResult(driver.RowsAffected(0))
+	teams[operationSelectAlias].ExpectQuery().WithArgs("los angeles lakers").WillReturnRows(sqlmock.NewRows([]string{"team"}).AddRow(losAngelesLakersID))
+	mock.ExpectRollback()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	id, err := resolve(ctx, tx, preparedStatements{forRegistryBySubject: registryStmts}, subjectTeam, losAngelesLakers)
+	if err != nil {
+		t.Fatalf("failed to resolve: %v", err)
+	}
+
+	if id != losAngelesLakersID {
+		t.Errorf("expected %q, got %q", losAngelesLakersID, id)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("failed to rollback transaction: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,312 @@
+package internal
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	errGameNotFound = errors.New("game not found")
+	errGameNotFinal = errors.New("game is not finalized")
+	errGamePurged   = errors.New("raw events of the game are purged")
+)
+
+const tableTeamGames table = "team_games"
+
+// finalizeTeamGameSQL is an SQL statement to finalize a game the team has events of, ending at its latest event.
+// Finalizing the game again keeps the original time and end. The game is cached again, as its result counts towards the standings.
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+const finalizeTeamGameSQL = `INSERT INTO "team_games" ("league", "team", "game_date", "finalized_at", "latest_event_at", "watermark", "ends_at")
+(
+	SELECT "league", "team", "game_date", NOW() AT TIME ZONE 'UTC', MAX("timestamp"), MAX("timestamp"), MAX("timestamp")
+	FROM "events"
+	WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+	GROUP BY "league", "team", "game_date"
+)
+ON CONFLICT ("league", "team", "game_date") DO UPDATE SET
+	"finalized_at" = COALESCE("team_games"."finalized_at", EXCLUDED."finalized_at"),
+	"ends_at" = COALESCE("team_games"."ends_at", EXCLUDED."ends_at"),
+	"processed" = false`
+
+// selectTeamGamePurgedSQL is an SQL statement to check whether raw events of the game are purged, with the same parameters
+const selectTeamGamePurgedSQL = `SELECT EXISTS (
+	SELECT 1 FROM "team_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3 AND "purged_at" IS NOT NULL
+)`
+
+// SQL statements to purge raw events of a game with the same parameters
+const (
+	selectTeamGameForPurgeSQL = `SELECT "finalized_at" IS NOT NULL, "purged_at" IS NOT NULL FROM "team_games"
+WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3 FOR UPDATE`
+	selectTeamGameEventsSQL = `SELECT "player", "team", "timestamp", "event", "value", COALESCE("home_team", ''), "league", "offensive" FROM "events"
+WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3 ORDER BY "timestamp", "player"`
+	deleteTeamGameEventsSQL = `DELETE FROM "events" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3`
+	// $4: path of the archive file
+	// $5: number of archived events
+	updateTeamGamePurgedSQL = `UPDATE "team_games" SET "purged_at" = NOW() AT TIME ZONE 'UTC', "archive" = $4, "archived_events" = $5
+WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3`
+)
+
+// selectTeamGamesToPurgeSQL is an SQL statement to select finalized games not purged yet, played before the date given by $1
+const selectTeamGamesToPurgeSQL = `SELECT "league", "team", "game_date" FROM "team_games"
+WHERE "finalized_at" IS NOT NULL AND "purged_at" IS NULL AND "game_date" < $1
+ORDER BY "game_date", "league", "team"`
+
+// teamGame identifies the game by the team playing it
+type teamGame struct {
+	league, team string
+	gameDate     string // in format "2006-01-02"
+}
+
+func (g teamGame) String() string {
+	return fmt.Sprintf("%s %s %s", g.league, g.team, g.gameDate)
+}
+
+// validateNotPurged rejects events of games with purged raw events, as the statistics can't be recalculated for them anymore
+func validateNotPurged(ctx context.Context, tx *sql.Tx, stmts preparedStatements, game teamGame) error {
+	var purged bool
+	if err := tx.StmtContext(ctx, stmts.selectTeamGamePurged).QueryRowContext(ctx, game.league, game.team, game.gameDate).Scan(&purged); err != nil {
+		return fmt.Errorf("failed to select game %s: %w", game, err)
+	}
+
+	if purged {
+		return fmt.Errorf("%w: %s", errGamePurged, game)
+	}
+
+	return nil
+}
+
+// finalizeHandler marks the game of the team on the date final, so its raw events can be purged according to the retention policy
+func finalizeHandler(ctx context.Context, cfg config, db *sql.DB, stmts preparedStatements) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(cfg, w, r) {
+			return
+		}
+
+		league := r.URL.Query().Get("league")
+		if league == "" {
+			league = defaultLeague
+		}
+		if _, ok := cfg.leagues[league]; !ok {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("unknown 'league': %q", league))
+			return
+		}
+
+		date := r.PathValue("date")
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid game date: %w", err))
+			return
+		}
+
+		if err := finalizeGame(ctx, db, stmts, league, r.PathValue("team"), date); err != nil {
+			statusCode := http.StatusInternalServerError
+			if errors.Is(err, errGameNotFound) {
+				statusCode = http.StatusNotFound
+			}
+			respondError(w, statusCode, fmt.Errorf("failed to finalize game of team %q on %s: %w", r.PathValue("team"), date, err))
+			return
+		}
+		log.Println(fmt.Sprintf("Game of team %q in %s on %s finalized", r.PathValue("team"), league, date))
+	}
+}
+
+// finalizeGame marks the game of the team, given either by identifier or name, final
+func finalizeGame(ctx context.Context, db *sql.DB, stmts preparedStatements, league, team, gameDate string) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	if team, err = resolve(ctx, tx, stmts, subjectTeam, team); err != nil {
+		return fmt.Errorf("failed to resolve team: %w", err)
+	}
+
+	result, err := tx.StmtContext(ctx, stmts.finalizeTeamGame).ExecContext(ctx, league, team, gameDate)
+	if err != nil {
+		return fmt.Errorf("failed to finalize game: %w", err)
+	}
+
+	// no events of the team on the date
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return errors.Join(errGameNotFound, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// purgeEvents archives and deletes raw events of the given games, or of all the finalized games older than the retention period
+func purgeEvents(ctx context.Context, cfg config, db *sql.DB, games []teamGame) error {
+	if len(games) == 0 {
+		cutoff := time.Now().UTC().AddDate(0, 0, -cfg.retentionDays).Format(time.DateOnly)
+
+		rows, err := db.QueryContext(ctx, selectTeamGamesToPurgeSQL, cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to select games to purge: %w", err)
+		}
+
+		for rows.Next() {
+			var g teamGame
+			var gameDate time.Time
+			if err := rows.Scan(&g.league, &g.team, &gameDate); err != nil {
+				closeIt("rows", rows)
+				return fmt.Errorf("failed to scan game to purge: %w", err)
+			}
+			g.gameDate = gameDate.Format(time.DateOnly)
+			games = append(games, g)
+		}
+		closeIt("rows", rows)
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to select games to purge: %w", err)
+		}
+		log.Println(fmt.Sprintf("%d finalized games before %s are to be purged", len(games), cutoff))
+	}
+
+	for _, g := range games {
+		if err := purgeGame(ctx, cfg, db, g); err != nil {
+			return fmt.Errorf("failed to purge game %s: %w", g, err)
+		}
+	}
+
+	return nil
+}
+
+// purgeGame archives raw events of the finalized game to a file, and deletes them in the same transaction marking the game purged
+func purgeGame(ctx context.Context, cfg config, db *sql.DB, game teamGame) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	var finalized, purged bool
+	err = tx.QueryRowContext(ctx, selectTeamGameForPurgeSQL, game.league, game.team, game.gameDate).Scan(&finalized, &purged)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errGameNotFinal
+	}
+	if err != nil {
+		return fmt.Errorf("failed to select game: %w", err)
+	}
+	if !finalized {
+		return errGameNotFinal
+	}
+	if purged {
+		log.Println(fmt.Sprintf("Game %s is already purged", game))
+		return tx.Rollback()
+	}
+
+	rows, err := tx.QueryContext(ctx, selectTeamGameEventsSQL, game.league, game.team, game.gameDate)
+	if err != nil {
+		return fmt.Errorf("failed to select events: %w", err)
+	}
+
+	var events []event
+	for rows.Next() {
+		var e event
+		var value int
+		if err = rows.Scan(&e.Player, &e.Team, &e.Timestamp, &e.Event, &value, &e.HomeTeam, &e.League, &e.Offensive); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan event: %w", err)
+		}
+		if e.Event == eventShot || e.Event == eventMiss {
+			e.Points = value
+		}
+		events = append(events, e)
+	}
+	closeIt("rows", rows)
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to select events: %w", err)
+	}
+
+	path, err := writeArchive(cfg.archiveDir, game, events)
+	if err != nil {
+		return fmt.Errorf("failed to archive events: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, deleteTeamGameEventsSQL, game.league, game.team, game.gameDate); err != nil {
+		return fmt.Errorf("failed to delete events: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, updateTeamGamePurgedSQL, game.league, game.team, game.gameDate, path, len(events)); err != nil {
+		return fmt.Errorf("failed to mark game purged: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Println(fmt.Sprintf("%d events of game %s are archived to %q and purged", len(events), game, path))
+
+	return nil
+}
+
+// writeArchive writes the events as gzip-compressed NDJSON, one event per line in the format of the ingestion API,
+// to the file "{league}/{game date}/{team}.ndjson.gz" in the directory. The file is replaced atomically.
+func writeArchive(dir string, game teamGame, events []event) (path string, err error) {
+	path = filepath.Join(dir, game.league, game.gameDate, game.team+".ndjson.gz")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), game.team+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			closeIt("archive file", file)
+			if removeErr := os.Remove(file.Name()); removeErr != nil {
+				err = errors.Join(err, removeErr)
+			}
+		}
+	}()
+
+	gz := gzip.NewWriter(file)
+	encoder := json.NewEncoder(gz)
+	for _, e := range events {
+		if err = encoder.Encode(e); err != nil {
+			return "", fmt.Errorf("failed to write event %q: %w", e, err)
+		}
+	}
+
+	if err = gz.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress archive: %w", err)
+	}
+	if err = file.Sync(); err != nil {
+		return "", fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return "", fmt.Errorf("failed to close archive file: %w", err)
+	}
+	if err = os.Rename(file.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to rename archive file: %w", err)
+	}
+
+	return path, nil
+}
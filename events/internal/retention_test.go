@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteArchive(t *testing.T) {
+	game := teamGame{league: defaultLeague, team: losAngelesLakersID, gameDate: "2025-03-15"}
+	events := []event{
+		{Player: leBronJamesID, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.March, 15, 18, 45, 0, 0, time.UTC), Event: eventEnter, League: defaultLeague},
+		{Player: leBronJamesID, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.March, 15, 18, 46, 0, 0, time.UTC), Event: eventShot, Points: 3, League: defaultLeague},
+	}
+
+	path, err := writeArchive(t.TempDir(), game, events)
+	if err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	if filepath.Base(path) != losAngelesLakersID+".ndjson.gz" {
+		t.Errorf("unexpected archive file %q", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer closeIt("archive file", file)
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("failed to decompress archive: %v", err)
+	}
+
+	var archived []event
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var e event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("failed to unmarshal line %q: %v", scanner.Text(), err)
+		}
+		archived = append(archived, e)
+	}
+
+	if len(archived) != len(events) {
+		t.Fatalf("expected %d archived events, got %d", len(events), len(archived))
+	}
+	for i := range events {
+		if archived[i].String() != events[i].String() {
+			t.Errorf("expected event %q, got %q", events[i], archived[i])
+		}
+	}
+}
+
+func TestFinalizeHandler_AdminOnly(t *testing.T) {
+	handler := finalizeHandler(t.Context(), config{adminToken: "secret"}, nil, preparedStatements{})
+	for _, token := range []string{"", "wrong"} {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/teams/lakers/games/2025-03-15/final", nil)
+		r.SetPathValue("team", "lakers")
+		r.SetPathValue("date", "2025-03-15")
+		r.Header.Set(adminTokenHeader, token)
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("token %q: expected status code %d, got %d", token, http.StatusForbidden, w.Code)
+		}
+	}
+}
+
+func TestPurgeGame_NotFinal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	game := teamGame{league: defaultLeague, team: losAngelesLakersID, gameDate: "2025-03-15"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "finalized_at" IS NOT NULL`).WithArgs(game.league, game.team, game.gameDate).
+		WillReturnRows(sqlmock.NewRows([]string{"finalized", "purged"}).AddRow(false, false))
+	mock.ExpectRollback()
+
+	dir := t.TempDir()
+	if err := purgeGame(t.Context(), config{archiveDir: dir}, db, game); !errors.Is(err, errGameNotFinal) {
+		t.Errorf("expected %v, got %v", errGameNotFinal, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("expected no archives, got %v (%v)", entries, err)
+	}
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"net/http"
+	"time"
+)
+
+// rosterValidation defines how events of players not on the roster of the team are treated
+type rosterValidation string
+
+const (
+	rosterValidationStrict rosterValidation = "strict" // events are rejected
+	rosterValidationWarn   rosterValidation = "warn"   // events are accepted, and a warning is logged
+)
+
+var errNotOnRoster = errors.New("player is not on the roster of the team")
+
+const tableRosters table = "rosters"
+
+// upsertRosterMembershipSQL is an SQL statement to upsert a membership
+// Parameter placeholders are intended for:
+// $1: team
+// $2: player
+// $3: valid from date in format "2006-01-02"
+// $4: valid to date in format "2006-01-02", or NULL
+const upsertRosterMembershipSQL = `INSERT INTO "rosters" ("team", "player", "valid_from", "valid_to") VALUES ($1, $2, $3, $4)
+ON CONFLICT ("team", "player", "valid_from") DO UPDATE SET "valid_to" = EXCLUDED."valid_to"`
+
+// selectRosterMembershipSQL is an SQL statement to check the membership on the date
+// Parameter placeholders are intended for:
+// $1: team
+// $2: player
+// $3: date in format "2006-01-02"
+const selectRosterMembershipSQL = `SELECT EXISTS (
+	SELECT 1 FROM "rosters" 
+	WHERE "team" = $1 AND "player" = $2 AND "valid_from" <= $3 AND ("valid_to" IS NULL OR "valid_to" >= $3)
+)`
+
+// membership is a request to add a player to the roster of a team
+type membership struct {
+	Player string `json:"player"`
+	From   string `json:"from"`
+	To     string `json:"to"` // optional, the membership is indefinite if not specified
+}
+
+func (m membership) validate() error {
+	if m.Player == "" {
+		return errors.New("'player' is not specified")
+	}
+
+	from, err := time.Parse(time.DateOnly, m.From)
+	if err != nil {
+		return fmt.Errorf("invalid 'from' date %q: %w", m.From, err)
+	}
+
+	if m.To != "" {
+		to, err := time.Parse(time.DateOnly, m.To)
+		if err != nil {
+			return fmt.Errorf("invalid 'to' date %q: %w", m.To, err)
+		}
+		if to.Before(from) {
+			return fmt.Errorf("'to' date %s is before 'from' date %s", m.To, m.From)
+		}
+	}
+
+	return nil
+}
+
+// validateRoster checks that the player is on the roster of the team on the game date.
+// The violation is returned as errNotOnRoster in strict mode, and only logged otherwise.
+func validateRoster(ctx context.Context, tx *sql.Tx, stmts preparedStatements, cfg config, team, player, gameDate string) error {
+	var onRoster bool
+	if err := tx.StmtContext(ctx, stmts.selectRosterMembership).QueryRowContext(ctx, team, player, gameDate).Scan(&onRoster); err != nil {
+		return fmt.Errorf("failed to select roster membership: %w", err)
+	}
+
+	if onRoster {
+		return nil
+	}
+
+	if cfg.rosterValidation == rosterValidationStrict {
+		return fmt.Errorf("%w: player %q, team %q, date %s", errNotOnRoster, player, team, gameDate)
+	}
+
+	log.Println(fmt.Sprintf("WARNING: player %q is not on the roster of team %q on %s", player, team, gameDate))
+	return nil
+}
+
+func rosterHandler(ctx context.Context, cfg config, db *sql.DB, stmts preparedStatements, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(cfg, w, r) {
+			return
+		}
+
+		var membership membership
+		if err := json.NewDecoder(r.Body).Decode(&membership); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to decode JSON: %w", err))
+			return
+		}
+
+		if err := membership.validate(); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to validate membership: %w", err))
+			return
+		}
+
+		if err := addToRoster(ctx, db, stmts, r.PathValue("team"), membership); err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to add player %q to the roster of team %q: %w", membership.Player, r.PathValue("team"), err))
+			return
+		}
+		log.Println(fmt.Sprintf("Player %q added to the roster of team %q from %s to %q", membership.Player, r.PathValue("team"), membership.From, membership.To))
+
+		// the player or the team may be registered on the way
+		for _, subject := range subjects {
+			if err := updateRegistryCache(ctx, subject, stmts, rdb); err != nil {
+				respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to update %s registry cache: %w", subject, err))
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// addToRoster upserts the membership of the player in the team, both given either by identifiers or names
+func addToRoster(ctx context.Context, db *sql.DB, stmts preparedStatements, team string, membership membership) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	teamID, err := resolve(ctx, tx, stmts, subjectTeam, team)
+	if err != nil {
+		return fmt.Errorf("failed to resolve team: %w", err)
+	}
+
+	playerID, err := resolve(ctx, tx, stmts, subjectPlayer, membership.Player)
+	if err != nil {
+		return fmt.Errorf("failed to resolve player: %w", err)
+	}
+
+	var to any // NULL for an indefinite membership
+	if membership.To != "" {
+		to = membership.To
+	}
+
+	if err = txExec(ctx, tx, stmts.upsertRosterMembership, teamID, playerID, membership.From, to); err != nil {
+		return fmt.Errorf("failed to upsert roster membership: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
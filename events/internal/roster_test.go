@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testNotOnRoster(t *testing.T, cfg config) error {
+	ctx := t.Context()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	rosterExpectedPrepare, rosterStmt := prepareMockStmt(t, db, mock, selectRosterMembershipSQL)
+
+	e := event{Player: leBronJamesID, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.UTC), Event: eventAssist}
+
+	mock.ExpectBegin()
+	rosterExpectedPrepare.ExpectQuery().WithArgs(losAngelesLakersID, leBronJamesID, e.gameDate(time.UTC)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectRollback()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	validationErr := validateRoster(ctx, tx, preparedStatements{selectRosterMembership: rosterStmt}, cfg, e.Team, e.Player, e.gameDate(time.UTC))
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("failed to rollback transaction: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+
+	return validationErr
+}
+
+func TestRosterHandler_AdminOnly(t *testing.T) {
+	handler := rosterHandler(t.Context(), config{adminToken: "secret"}, nil, preparedStatements{}, nil)
+	for _, token := range []string{"", "wrong"} {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/teams/lakers/roster", strings.NewReader(`{"player":"LeBron James","from":"2024-10-01"}`))
+		r.SetPathValue("team", "lakers")
+		r.Header.Set(adminTokenHeader, token)
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("token %q: expected status code %d, got %d", token, http.StatusForbidden, w.Code)
+		}
+	}
+}
+
+func TestValidateRoster_Strict(t *testing.T) {
+	if err := testNotOnRoster(t, config{rosterValidation: rosterValidationStrict}); !errors.Is(err, errNotOnRoster) {
+		t.Errorf("expected %v, got %v", errNotOnRoster, err)
+	}
+}
+
+func TestValidateRoster_Warn(t *testing.T) {
+	if err := testNotOnRoster(t, config{rosterValidation: rosterValidationWarn}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMembership_Validate(t *testing.T) {
+	for _, m := range []membership{
+		{From: "2024-10-01"},
+		{Player: leBronJames},
+		{Player: leBronJames, From: "2024-10-32"},
+		{Player: leBronJames, From: "2024-10-01", To: "2024-09-30"},
+	} {
+		if err := m.validate(); err == nil {
+			t.Errorf("expected error for %+v", m)
+		}
+	}
+
+	if err := (membership{Player: leBronJames, From: "2024-10-01", To: "2025-02-06"}).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
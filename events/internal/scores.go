@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// upsertGameOpponentsSQL is an SQL statement to pair the games of the visiting team and the home team as opponents in the season,
+// marking them unprocessed only when the pairing changes, so it's cached again.
+// Parameter placeholders are intended for:
+// $1: league
+// $2: home team
+// $3: game date in format "2006-01-02"
+// $4: visiting team
+// $5: season
+// $6: season type
+const upsertGameOpponentsSQL = `INSERT INTO "team_games" AS g ("league", "team", "game_date", "home_team", "opponent", "season", "season_type")
+VALUES ($1, $2, $3, $2, $4, $5, $6), ($1, $4, $3, $2, $2, $5, $6)
+ON CONFLICT ("league", "team", "game_date") DO UPDATE SET
+	"home_team" = EXCLUDED."home_team", "opponent" = EXCLUDED."opponent", "season" = EXCLUDED."season", "season_type" = EXCLUDED."season_type",
+	"processed" = false
+WHERE g."home_team" IS DISTINCT FROM EXCLUDED."home_team" OR g."opponent" IS DISTINCT FROM EXCLUDED."opponent"
+	OR g."season" IS DISTINCT FROM EXCLUDED."season" OR g."season_type" IS DISTINCT FROM EXCLUDED."season_type"`
+
+// SQL statements to record periods of the game on lifecycle events
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+// $4: period
+// $5: timestamp of the event
+const (
+	insertGamePeriodSQL = `INSERT INTO "game_periods" ("league", "team", "game_date", "period", "started_at") VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT ("league", "team", "game_date", "period") DO UPDATE SET "started_at" = EXCLUDED."started_at", "ended_at" = NULL`
+	updateGamePeriodEndedSQL = `UPDATE "game_periods" SET "ended_at" = $5 WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3 AND "period" = $4`
+)
+
+// SQL statements to recalculate the score of the game of the team by periods from its shots.
+// A shot belongs to the latest period started before it, and shots before the first period to period 0.
+// The game is marked unprocessed, so the score is cached again.
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+const (
+	deleteTeamScoresSQL = `WITH "game" AS (
+	UPDATE "team_games" SET "processed" = false WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+)
+DELETE FROM "team_scores" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3`
+	insertTeamScoresSQL = `INSERT INTO "team_scores" ("league", "team", "game_date", "period", "points")
+SELECT $1, $2, $3, COALESCE(p."period", 0), SUM(e."value")
+FROM "events" e
+LEFT JOIN LATERAL (
+	SELECT "period" FROM "game_periods"
+	WHERE "league" = e."league" AND "team" = e."team" AND "game_date" = e."game_date" AND "started_at" <= e."timestamp"
+	ORDER BY "period" DESC LIMIT 1
+) p ON true
+WHERE e."league" = $1 AND e."team" = $2 AND e."game_date" = $3 AND e."event" = 'shot'
+	AND e."timestamp" >= $3::date - 1 AND e."timestamp" < $3::date + 2
+GROUP BY COALESCE(p."period", 0)`
+)
+
+// selectTeamScoresSQL is an SQL statement to select the score of the game of the team by periods, with the same parameters
+const selectTeamScoresSQL = `SELECT "period", "points" FROM "team_scores" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3 ORDER BY "period"`
+
+// periodScore is the number of points the team scored in the period, the period 0 holding points scored before the first period started
+type periodScore struct {
+	Period int `json:"period"`
+	Points int `json:"points"`
+}
+
+// score is the score of the game of a team by periods
+type score struct {
+	Periods []periodScore `json:"periods"`
+	Total   int           `json:"total"`
+}
+
+// scoreboardKey returns the Redis key of the set of home teams playing games in the league on the date in format "2006-01-02"
+func scoreboardKey(league, gameDate string) string {
+	return fmt.Sprintf("%s:scoreboard:%s", league, gameDate)
+}
+
+// pairGame records the home team of the event as the opponent of its team in the season, and vice versa. Events of home teams don't identify their opponents,
+// so a game is paired by the first event of its visiting team specifying the home team.
+func pairGame(ctx context.Context, tx *sql.Tx, stmts preparedStatements, e event, gameDate, season string, seasonType seasonType) error {
+	if e.HomeTeam == "" || e.HomeTeam == e.Team {
+		return nil
+	}
+
+	if err := txExec(ctx, tx, stmts.upsertGameOpponents, e.League, e.HomeTeam, gameDate, e.Team, season, seasonType); err != nil {
+		return fmt.Errorf("failed to pair game of %q with %q on %s: %w", e.Team, e.HomeTeam, gameDate, err)
+	}
+
+	return nil
+}
+
+// updateScore recalculates the score of the game by periods, after a shot or a change of periods
+func updateScore(ctx context.Context, tx *sql.Tx, stmts preparedStatements, game teamGame) error {
+	if err := txExec(ctx, tx, stmts.deleteTeamScores, game.league, game.team, game.gameDate); err != nil {
+		return fmt.Errorf("failed to delete score of game %s: %w", game, err)
+	}
+	if err := txExec(ctx, tx, stmts.insertTeamScores, game.league, game.team, game.gameDate); err != nil {
+		return fmt.Errorf("failed to insert score of game %s: %w", game, err)
+	}
+
+	return nil
+}
+
+// recordPeriod starts or ends the period of the game on the lifecycle event.
+// The score is recalculated on the start of a period, as shots after it may have arrived before the event.
+func recordPeriod(ctx context.Context, tx *sql.Tx, stmts preparedStatements, game teamGame, e event, period int) error {
+	started := e.Event == eventGameStart || e.Event == eventPeriodStart
+	query := updateGamePeriodEndedSQL
+	if started {
+		query = insertGamePeriodSQL
+	}
+	if _, err := tx.ExecContext(ctx, query, game.league, game.team, game.gameDate, period, e.Timestamp); err != nil {
+		return fmt.Errorf("failed to record period %d of game %s: %w", period, game, err)
+	}
+
+	if !started {
+		return nil
+	}
+	return updateScore(ctx, tx, stmts, game)
+}
+
+// selectScore selects the score of the game by periods in the transaction
+func selectScore(ctx context.Context, tx *sql.Tx, stmts preparedStatements, game teamGame) (score, error) {
+	s := score{Periods: []periodScore{}}
+	rows, err := tx.StmtContext(ctx, stmts.selectTeamScores).QueryContext(ctx, game.league, game.team, game.gameDate)
+	if err != nil {
+		return score{}, fmt.Errorf("failed to select score of game %s: %w", game, err)
+	}
+	defer closeIt("rows", rows)
+
+	for rows.Next() {
+		var p periodScore
+		if err := rows.Scan(&p.Period, &p.Points); err != nil {
+			return score{}, fmt.Errorf("failed to scan score of game %s: %w", game, err)
+		}
+		s.Periods = append(s.Periods, p)
+		s.Total += p.Points
+	}
+	if err := rows.Err(); err != nil {
+		return score{}, fmt.Errorf("failed to select score of game %s: %w", game, err)
+	}
+
+	return s, nil
+}
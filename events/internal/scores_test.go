@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"testing"
+)
+
+func TestSelectScore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	scoresExpectedPrepare, scoresStmt := prepareMockStmt(t, db, mock, selectTeamScoresSQL)
+	stmts := preparedStatements{selectTeamScores: scoresStmt}
+
+	game := teamGame{defaultLeague, losAngelesLakersID, "2025-03-15"}
+	mock.ExpectBegin()
+	scoresExpectedPrepare.ExpectQuery().WithArgs(game.league, game.team, game.gameDate).
+		WillReturnRows(sqlmock.NewRows([]string{"period", "points"}).AddRow(0, 2).AddRow(1, 28).AddRow(2, 25))
+
+	tx, err := db.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := selectScore(t.Context(), tx, stmts, game)
+	if err != nil {
+		t.Fatalf("failed to select score: %v", err)
+	}
+	if s.Total != 55 || len(s.Periods) != 3 || s.Periods[1] != (periodScore{1, 28}) {
+		t.Errorf("unexpected score %+v", s)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPairGame_HomeTeam(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	mock.ExpectBegin()
+	tx, err := db.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// events of the home team don't identify the opponent, so nothing is paired
+	for _, e := range []event{
+		{Team: losAngelesLakersID, League: defaultLeague},
+		{Team: losAngelesLakersID, HomeTeam: losAngelesLakersID, League: defaultLeague},
+	} {
+		if err := pairGame(t.Context(), tx, preparedStatements{}, e, "2025-03-15", "2024-25", seasonTypeRegular); err != nil {
+			t.Errorf("failed to pair game: %v", err)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+var errSeasonClosed = errors.New("season is closed")
+
+// Tables of closed seasons. Statistics of a closed season are frozen as immutable rows of history tables,
+// and its per-game rows are moved to the history of per-game rows.
+const (
+	tableClosedSeasons                 table = "closed_seasons"
+	tablePlayersByGamesHistory         table = "players_by_games_history"
+	tablePlayersStatisticsHistory      table = "players_statistics_history"
+	tableTeamsStatisticsHistory        table = "teams_statistics_history"
+	tablePlayersTeamsStatisticsHistory table = "players_teams_statistics_history"
+	// tablePlayersByGamesAll is a view of all the per-game rows, the ones of closed seasons included.
+	// A live per-game row takes precedence over the history one of the same game, e.g. after an admin override.
+	tablePlayersByGamesAll table = "players_by_games_all"
+)
+
+// historyTables are history tables of tables by their names
+var historyTables = map[table]table{
+	tablePlayersByGames:         tablePlayersByGamesHistory,
+	tablePlayersStatistics:      tablePlayersStatisticsHistory,
+	tableTeamsStatistics:        tableTeamsStatisticsHistory,
+	tablePlayersTeamsStatistics: tablePlayersTeamsStatisticsHistory,
+}
+
+// selectSeasonClosedSQL is an SQL statement to check whether the season of the league is closed
+// Parameter placeholders are intended for:
+// $1: league
+// $2: season
+const selectSeasonClosedSQL = `SELECT EXISTS (SELECT 1 FROM "closed_seasons" WHERE "league" = $1 AND "season" = $2)`
+
+// reopenPlayerGameSQL is an SQL statement to copy the per-game row of a closed season back, so a late event updates it rather than a blank row.
+// Parameter placeholders are intended for:
+// $1: league
+// $2: player
+// $3: game date in format "2006-01-02"
+const reopenPlayerGameSQL = `INSERT INTO "players_by_games"
+SELECT * FROM "players_by_games_history" WHERE "league" = $1 AND "player" = $2 AND "game_date" = $3
+ON CONFLICT DO NOTHING`
+
+// SQL statements to close a season with the same parameters as selectSeasonClosedSQL
+const (
+	insertClosedSeasonSQL           = `INSERT INTO "closed_seasons" ("league", "season") VALUES ($1, $2)`
+	deletePlayersByGamesOfSeasonSQL = `DELETE FROM "players_by_games" WHERE "league" = $1 AND "season" = $2`
+)
+
+// copyToHistorySQL returns an SQL statement to copy rows of the season of the league to the history table
+func copyToHistorySQL(source table) string {
+	return fmt.Sprintf(`INSERT INTO "%s" SELECT * FROM "%s" WHERE "league" = $1 AND "season" = $2`, historyTables[source], source)
+}
+
+// validateSeasonOpen rejects events of closed seasons unless the admin overrides it.
+// On the override, the per-game row of the player is reopened.
+func validateSeasonOpen(ctx context.Context, tx *sql.Tx, stmts preparedStatements, league, season, player, gameDate string, override bool) error {
+	var closed bool
+	if err := tx.StmtContext(ctx, stmts.selectSeasonClosed).QueryRowContext(ctx, league, season).Scan(&closed); err != nil {
+		return fmt.Errorf("failed to select closed season %s of %s: %w", season, league, err)
+	}
+
+	if !closed {
+		return nil
+	}
+
+	if !override {
+		return fmt.Errorf("%w: %s of %s", errSeasonClosed, season, league)
+	}
+
+	if err := txExec(ctx, tx, stmts.reopenPlayerGame, league, player, gameDate); err != nil {
+		return fmt.Errorf("failed to reopen game of %q on %s: %w", player, gameDate, err)
+	}
+	log.Println(fmt.Sprintf("WARNING: event of %q on %s of closed season %s of %s is accepted on admin override", player, gameDate, season, league))
+
+	return nil
+}
+
+// closeSeason freezes statistics of the season of the league, moves its per-game rows to the history, and marks the season closed
+func closeSeason(ctx context.Context, cfg config, db *sql.DB, league, season string) (err error) {
+	if _, ok := cfg.leagues[league]; !ok {
+		return fmt.Errorf("unknown league %q", league)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	var closed bool
+	if err = tx.QueryRowContext(ctx, selectSeasonClosedSQL, league, season).Scan(&closed); err != nil {
+		return fmt.Errorf("failed to select closed season: %w", err)
+	}
+	if closed {
+		return fmt.Errorf("%w: %s of %s", errSeasonClosed, season, league)
+	}
+
+	if _, err = tx.ExecContext(ctx, insertClosedSeasonSQL, league, season); err != nil {
+		return fmt.Errorf("failed to insert closed season: %w", err)
+	}
+
+	for _, source := range append([]table{tablePlayersByGames}, statisticsTables...) {
+		result, err := tx.ExecContext(ctx, copyToHistorySQL(source), league, season)
+		if err != nil {
+			return fmt.Errorf("failed to copy %q rows to history: %w", source, err)
+		}
+		copied, _ := result.RowsAffected()
+		log.Println(fmt.Sprintf("%d rows of %q are copied to %q", copied, source, historyTables[source]))
+	}
+
+	if _, err = tx.ExecContext(ctx, deletePlayersByGamesOfSeasonSQL, league, season); err != nil {
+		return fmt.Errorf("failed to delete %q rows: %w", tablePlayersByGames, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
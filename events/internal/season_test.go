@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"net/http/httptest"
+	"testing"
+)
+
+func testClosedSeason(t *testing.T, override bool) error {
+	ctx := t.Context()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	closedExpectedPrepare, closedStmt := prepareMockStmt(t, db, mock, selectSeasonClosedSQL)
+	reopenExpectedPrepare, reopenStmt := prepareMockStmt(t, db, mock, reopenPlayerGameSQL)
+
+	mock.ExpectBegin()
+	closedExpectedPrepare.ExpectQuery().WithArgs(defaultLeague, "2023-24").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	if override {
+		reopenExpectedPrepare.ExpectExec().WithArgs(defaultLeague, leBronJamesID, "2024-04-14").WillReturnResult(driver.RowsAffected(1))
+	}
+	mock.ExpectRollback()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	stmts := preparedStatements{selectSeasonClosed: closedStmt, reopenPlayerGame: reopenStmt}
+	validationErr := validateSeasonOpen(ctx, tx, stmts, defaultLeague, "2023-24", leBronJamesID, "2024-04-14", override)
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("failed to rollback transaction: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+
+	return validationErr
+}
+
+func TestValidateSeasonOpen_Closed(t *testing.T) {
+	if err := testClosedSeason(t, false); !errors.Is(err, errSeasonClosed) {
+		t.Errorf("expected %v, got %v", errSeasonClosed, err)
+	}
+}
+
+func TestValidateSeasonOpen_Override(t *testing.T) {
+	if err := testClosedSeason(t, true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	for _, tc := range []struct {
+		configured, given string
+		admin, invalid    bool
+	}{
+		{"", "", false, false},
+		{"secret", "", false, false},
+		{"secret", "secret", true, false},
+		{"secret", "guess", false, true},
+		{"", "guess", false, true},
+	} {
+		r := httptest.NewRequest("POST", "/api/v1/event", nil)
+		if tc.given != "" {
+			r.Header.Set(adminTokenHeader, tc.given)
+		}
+
+		admin, err := isAdmin(config{adminToken: tc.configured}, r)
+		if admin != tc.admin || errors.Is(err, errInvalidAdminToken) != tc.invalid {
+			t.Errorf("isAdmin with %q configured and %q given: expected %v (invalid %v), got %v (%v)", tc.configured, tc.given, tc.admin, tc.invalid, admin, err)
+		}
+	}
+}
@@ -9,16 +9,53 @@ import (
 	"github.com/redis/go-redis/v9"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type preparedStatements struct {
-	upsertEvent              *sql.Stmt
-	forUpdatesByEventType    map[eventType]*sql.Stmt
-	forStatisticsByOperation map[operation]map[table]*sql.Stmt
+	upsertEvent                     *sql.Stmt
+	forUpdatesByEventType           map[eventType]*sql.Stmt
+	forIncrementsByEventType        map[eventType]*sql.Stmt // of counter events only
+	forStatisticsByOperation        map[operation]map[table]*sql.Stmt
+	forRegistryBySubject            map[subject]map[operation]*sql.Stmt
+	selectRosterMembership          *sql.Stmt
+	upsertRosterMembership          *sql.Stmt
+	selectTeamTimezone              *sql.Stmt
+	updateTeamTimezone              *sql.Stmt
+	updateTeamGroups                *sql.Stmt
+	selectPlayerGame                *sql.Stmt
+	deletePlayerGame                *sql.Stmt
+	finalizeTeamGame                *sql.Stmt
+	selectTeamGamePurged            *sql.Stmt
+	selectSeasonClosed              *sql.Stmt
+	reopenPlayerGame                *sql.Stmt
+	lockKey                         *sql.Stmt
+	upsertTeamGameWatermark         *sql.Stmt
+	selectUnprocessedTeamGames      *sql.Stmt
+	updateUnprocessedTeamGame       *sql.Stmt
+	upsertGameOpponents             *sql.Stmt
+	deleteTeamScores                *sql.Stmt
+	insertTeamScores                *sql.Stmt
+	selectTeamScores                *sql.Stmt
+	markPlusMinusUnprocessed        *sql.Stmt
+	selectUnprocessedPlusMinusGames *sql.Stmt
+	updatePlayersPlusMinus          *sql.Stmt
+	updatePlusMinusProcessed        *sql.Stmt
 }
 
-func startServer(ctx context.Context, db *sql.DB, stmts preparedStatements, rdb *redis.Client) error {
-	http.HandleFunc("/api/v1/event", eventHandler(ctx, db, stmts, rdb))
+func startServer(ctx context.Context, cfg config, db *sql.DB, stmts preparedStatements, rdb *redis.Client) error {
+	http.HandleFunc("/api/v1/event", eventHandler(ctx, cfg, db, stmts, rdb))
+	http.HandleFunc("PUT /api/v1/players/{id}", registryHandler(ctx, cfg, db, stmts, rdb, subjectPlayer))
+	http.HandleFunc("PUT /api/v1/teams/{id}", registryHandler(ctx, cfg, db, stmts, rdb, subjectTeam))
+	http.HandleFunc("POST /api/v1/teams/{team}/roster", rosterHandler(ctx, cfg, db, stmts, rdb))
+	http.HandleFunc("POST /api/v1/teams/{team}/games/{date}/final", finalizeHandler(ctx, cfg, db, stmts))
+	http.HandleFunc("GET /api/v1/event/{id}/status", ingestionStatusHandler(ctx, db))
+	http.HandleFunc("GET /api/v1/reports/late-events", lateEventsHandler(ctx, cfg, db))
+	http.HandleFunc("GET /api/v1/admin/dead-letters", deadLettersHandler(ctx, cfg, db))
+	http.HandleFunc("GET /api/v1/admin/dead-letters/{id}", deadLetterHandler(ctx, cfg, db))
+	http.HandleFunc("PUT /api/v1/admin/dead-letters/{id}", editDeadLetterHandler(ctx, cfg, db))
+	http.HandleFunc("POST /api/v1/admin/dead-letters/{id}/replay", replayDeadLetterHandler(ctx, cfg, db, stmts, rdb))
 
 	log.Println("NBA Player events consumer is running")
 	if err := http.ListenAndServe(":8080", nil); err != nil {
@@ -28,7 +65,7 @@ func startServer(ctx context.Context, db *sql.DB, stmts preparedStatements, rdb
 	return nil
 }
 
-func eventHandler(ctx context.Context, db *sql.DB, stmts preparedStatements, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+func eventHandler(ctx context.Context, cfg config, db *sql.DB, stmts preparedStatements, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
@@ -41,34 +78,181 @@ func eventHandler(ctx context.Context, db *sql.DB, stmts preparedStatements, rdb
 			return
 		}
 
-		if err := event.validate(); err != nil {
+		if event.League == "" {
+			event.League = defaultLeague
+		}
+
+		if err := event.validate(cfg.leagues); err != nil {
 			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to validate event: %w", err))
 			return
 		}
 
-		if err := processEvent(ctx, event, db, stmts); err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to process event %q: %w", event, err))
+		// events of closed seasons are accepted on admin override only
+		override, err := isAdmin(cfg, r)
+		if err != nil {
+			respondError(w, http.StatusForbidden, err)
+			return
+		}
+
+		if cfg.ingestionMode == ingestionModeAsync {
+			id, err := enqueueEvent(ctx, db, stmts, event, override)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to queue event %q: %w", event, err))
+				return
+			}
+			log.Println(fmt.Sprintf("Event %q queued as %d", event, id))
+			respondAccepted(w, id)
+			return
+		}
+
+		if err := retryTransaction(ctx, fmt.Sprintf("event %q", event), func() error {
+			return processEvent(ctx, cfg, event, override, db, stmts)
+		}); err != nil {
+			if rejected(err) {
+				respondError(w, processingStatusCode(err), fmt.Errorf("failed to process event %q: %w", event, err))
+				return
+			}
+
+			// the valid event is kept to be inspected and replayed by an admin
+			deadLetterID, deadLetterErr := insertDeadLetter(ctx, db, event, override, err)
+			if deadLetterErr != nil {
+				respondError(w, http.StatusInternalServerError, errors.Join(fmt.Errorf("failed to process event %q: %w", event, err), deadLetterErr))
+				return
+			}
+			respondError(w, processingStatusCode(err), fmt.Errorf("failed to process event %q, saved as dead letter %d: %w", event, deadLetterID, err))
 			return
 		}
 		log.Println(fmt.Sprintf("Event %q processed successfully", event))
 
-		for _, table := range statisticsTables {
-			if err := updateCache(ctx, table, stmts, rdb); err != nil {
-				respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to update %q cache: %w", table, err))
+		if err := updateCaches(ctx, db, stmts, rdb); err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+}
+
+// updateCaches copies unprocessed registry entries, states of games and statistics to Redis after events are processed
+func updateCaches(ctx context.Context, db *sql.DB, stmts preparedStatements, rdb *redis.Client) error {
+	for _, subject := range subjects {
+		if err := updateRegistryCache(ctx, subject, stmts, rdb); err != nil {
+			return fmt.Errorf("failed to update %s registry cache: %w", subject, err)
+		}
+	}
+
+	// plus-minus changes statistics, so it's recalculated before they are cached
+	if err := updatePlusMinus(ctx, db, stmts); err != nil {
+		return fmt.Errorf("failed to update plus-minus: %w", err)
+	}
+
+	if err := updateSeasonCaches(ctx, db, rdb); err != nil {
+		return fmt.Errorf("failed to update season caches: %w", err)
+	}
+
+	if err := updateGamesCache(ctx, db, stmts, rdb); err != nil {
+		return fmt.Errorf("failed to update games cache: %w", err)
+	}
+
+	for _, table := range statisticsTables {
+		if err := updateCache(ctx, table, db, stmts, rdb); err != nil {
+			return fmt.Errorf("failed to update %q cache: %w", table, err)
+		}
+		log.Println(fmt.Sprintf("Cache table %q updated successfully", table))
+	}
+
+	return nil
+}
+
+type registration struct {
+	Name       string   `json:"name"`
+	Aliases    []string `json:"aliases"`
+	Timezone   string   `json:"timezone"`   // optional IANA timezone of the venue of a team
+	Conference string   `json:"conference"` // optional conference of a team, grouping standings
+	Division   string   `json:"division"`   // optional division of a team within its conference
+}
+
+func registryHandler(ctx context.Context, cfg config, db *sql.DB, stmts preparedStatements, rdb *redis.Client, subject subject) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(cfg, w, r) {
+			return
+		}
+
+		id := r.PathValue("id")
+		if !idPattern.MatchString(id) {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid %s identifier %q: lower-cased letters and digits separated by dashes expected", subject, id))
+			return
+		}
+
+		var registration registration
+		if err := json.NewDecoder(r.Body).Decode(&registration); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to decode JSON: %w", err))
+			return
+		}
+
+		if strings.TrimSpace(registration.Name) == "" {
+			respondError(w, http.StatusBadRequest, errors.New("'name' is not specified"))
+			return
+		}
+
+		if subject != subjectTeam {
+			for attribute, value := range map[string]string{"timezone": registration.Timezone, "conference": registration.Conference, "division": registration.Division} {
+				if value != "" {
+					respondError(w, http.StatusBadRequest, fmt.Errorf("'%s' is not applicable to %s", attribute, subject))
+					return
+				}
+			}
+		}
+
+		if registration.Timezone != "" {
+			if _, err := time.LoadLocation(registration.Timezone); err != nil {
+				respondError(w, http.StatusBadRequest, fmt.Errorf("invalid 'timezone': %w", err))
 				return
 			}
-			log.Println(fmt.Sprintf("Cache table %q updated successfully after event %q", table, event))
 		}
+
+		registration.Name = strings.TrimSpace(registration.Name)
+		registration.Conference, registration.Division = strings.TrimSpace(registration.Conference), strings.TrimSpace(registration.Division)
+		if err := register(ctx, db, stmts, subject, id, registration); err != nil {
+			statusCode := http.StatusInternalServerError
+			if errors.Is(err, errAliasConflict) {
+				statusCode = http.StatusConflict
+			}
+			respondError(w, statusCode, fmt.Errorf("failed to register %s %q: %w", subject, id, err))
+			return
+		}
+		log.Println(fmt.Sprintf("Registered %s %q as %q with aliases %q", subject, registration.Name, id, registration.Aliases))
+
+		if err := updateRegistryCache(ctx, subject, stmts, rdb); err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to update %s registry cache: %w", subject, err))
+			return
+		}
+	}
+}
+
+// processingStatusCode returns the status code of the response to the event failed to be processed
+func processingStatusCode(err error) int {
+	switch {
+	case errors.Is(err, errNotOnRoster), errors.Is(err, errGameLimitExceeded):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, errGamePurged), errors.Is(err, errSeasonClosed), errors.Is(err, errInvalidGameTransition):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
 	}
 }
 
+// rejected reports whether the event is rejected deliberately as an invalid one, e.g. of a closed season,
+// rather than failed to be processed, so it isn't kept as a dead letter to be replayed
+func rejected(err error) bool {
+	return errors.Is(err, errNotOnRoster) || errors.Is(err, errSeasonClosed) || errors.Is(err, errGamePurged) || errors.Is(err, errInvalidGameTransition)
+}
+
 // respondError logs the error and write it to http.ResponseWriter with the given statusCode
 func respondError(w http.ResponseWriter, statusCode int, err error) {
 	log.Println(err)
 	http.Error(w, fmt.Sprintf("ERROR: %s", err.Error()), statusCode)
 }
 
-func processEvent(ctx context.Context, event event, db *sql.DB, preparedStatements preparedStatements) error {
+func processEvent(ctx context.Context, cfg config, event event, override bool, db *sql.DB, preparedStatements preparedStatements) error {
 	tx, err := db.BeginTx(ctx, nil) // nil *TxOptions means default
 	if err != nil {
 		return fmt.Errorf("failed to open transaction: %w", err)
@@ -91,26 +275,149 @@ func processEvent(ctx context.Context, event event, db *sql.DB, preparedStatemen
 		}
 	}()
 
-	season, gameDate := event.season(), event.gameDate()
+	// game lifecycle events drive the state of the game rather than statistics of a player
+	if gameEventTypes[event.Event] {
+		err = applyGameEvent(ctx, tx, cfg, event, override, preparedStatements)
+	} else {
+		err = applyEvent(ctx, tx, cfg, event, override, preparedStatements)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// applyEvent stores the event of a player and updates the aggregates in the transaction
+func applyEvent(ctx context.Context, tx *sql.Tx, cfg config, event event, override bool, preparedStatements preparedStatements) error {
+	var err error
+
+	// timestamps are stored in UTC
+	event.Timestamp = event.Timestamp.UTC()
 
-	if err = txExec(ctx, tx, preparedStatements.upsertEvent, event.Player, event.Team, event.Timestamp, event.Event, gameDate, event.value()); err != nil {
+	// players and teams are stored by their stable identifiers
+	if event.Player, err = resolve(ctx, tx, preparedStatements, subjectPlayer, event.Player); err != nil {
+		return fmt.Errorf("failed to resolve player: %w", err)
+	}
+	if event.Team, err = resolve(ctx, tx, preparedStatements, subjectTeam, event.Team); err != nil {
+		return fmt.Errorf("failed to resolve team: %w", err)
+	}
+
+	// concurrent events of the same player or team are serialized, so aggregates are updated from the events of each other
+	if err = lockAggregates(ctx, tx, preparedStatements, event.League, event.Player, event.Team); err != nil {
+		return fmt.Errorf("failed to lock aggregates: %w", err)
+	}
+
+	var homeTeam any // NULL unless specified
+	if event.HomeTeam != "" {
+		if event.HomeTeam, err = resolve(ctx, tx, preparedStatements, subjectTeam, event.HomeTeam); err != nil {
+			return fmt.Errorf("failed to resolve home team: %w", err)
+		}
+		homeTeam = event.HomeTeam
+	}
+
+	// the game date is local to the venue, so a late game is not split across two dates
+	venue, err := venueLocation(ctx, tx, preparedStatements, event.venueTeam())
+	if err != nil {
+		return fmt.Errorf("failed to get venue location: %w", err)
+	}
+
+	league := cfg.leagues[event.League]
+	season, gameDate := event.season(league, venue), event.gameDate(venue)
+	seasonType := league.Calendar.seasonType(season, gameDate)
+
+	if err = validateNotPurged(ctx, tx, preparedStatements, teamGame{event.League, event.Team, gameDate}); err != nil {
+		return fmt.Errorf("failed to validate game: %w", err)
+	}
+
+	if err = validateSeasonOpen(ctx, tx, preparedStatements, event.League, season, event.Player, gameDate, override); err != nil {
+		return fmt.Errorf("failed to validate season: %w", err)
+	}
+
+	if err = validateRoster(ctx, tx, preparedStatements, cfg, event.Team, event.Player, gameDate); err != nil {
+		return fmt.Errorf("failed to validate roster: %w", err)
+	}
+
+	// a correction of an existing event is applied by recalculation, while a new event is added incrementally
+	var corrected bool
+	var previous teamGame // of the corrected event
+	if err = tx.StmtContext(ctx, preparedStatements.upsertEvent).QueryRowContext(ctx, event.Player, event.Team, event.Timestamp, event.Event, gameDate, event.value(), homeTeam, event.League, event.synthetic, event.Offensive).Scan(&corrected, &previous.team, &previous.gameDate); err != nil {
 		return fmt.Errorf("failed to upsert event %q: %w", event, err)
 	}
 
-	if err = txExec(ctx, tx, preparedStatements.forUpdatesByEventType[event.Event], event.Player, event.Team, gameDate, season); err != nil {
-		return fmt.Errorf("failed to update %q table after %q event: %w", tablePlayersByGames, event, err)
+	state, err := updateWatermark(ctx, tx, preparedStatements, cfg, event, gameDate)
+	if err != nil {
+		return err
+	}
+	if err = pairGame(ctx, tx, preparedStatements, event, gameDate, season, seasonType); err != nil {
+		return err
+	}
+
+	before, existed, err := selectPlayerGame(ctx, tx, preparedStatements, event.League, event.Player, gameDate, season)
+	if err != nil {
+		return err
 	}
 
-	if err = txExec(ctx, tx, preparedStatements.forStatisticsByOperation[operationUpdateStatistics][tablePlayersStatistics], event.Player, season); err != nil {
-		return fmt.Errorf("failed to update %q table: %w", tablePlayersStatistics, err)
+	if corrected {
+		err = recalculatePlayerGame(ctx, tx, preparedStatements, event, gameDate, season, seasonType)
+	} else {
+		err = updatePlayerGame(ctx, tx, preparedStatements, event, gameDate, season, seasonType)
+	}
+	if err != nil {
+		return err
 	}
 
-	if err = txExec(ctx, tx, preparedStatements.forStatisticsByOperation[operationUpdateStatistics][tableTeamsStatistics], event.Team, season); err != nil {
-		return fmt.Errorf("failed to update %q table: %w", tableTeamsStatistics, err)
+	after, exists, err := selectPlayerGame(ctx, tx, preparedStatements, event.League, event.Player, gameDate, season)
+	if err != nil {
+		return err
+	}
+	if err = league.validateGame(int(after.Fouls), after.MinutesPlayed); err != nil {
+		return fmt.Errorf("failed to validate %q game of %q on %s: %w", event.League, event.Player, gameDate, err)
 	}
 
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	switch {
+	case corrected:
+		err = recalculateStatistics(ctx, tx, preparedStatements, event, season, seasonType)
+	case exists: // e.g. a single 'enter' event doesn't make a game yet
+		err = incrementStatistics(ctx, tx, preparedStatements, event, season, seasonType, !existed, after.sub(before))
+	}
+	if err != nil {
+		return err
+	}
+
+	// a correction of the team or the game date moves the event from the game it was counted for
+	previous.league = event.League
+	if corrected && (previous.team != event.Team || previous.gameDate != gameDate) {
+		if err = recalculatePreviousGame(ctx, tx, preparedStatements, league, event, previous, gameDate); err != nil {
+			return err
+		}
+	}
+
+	// a corrected event may have been a shot
+	if corrected || event.Event == eventShot {
+		if err = updateScore(ctx, tx, preparedStatements, teamGame{event.League, event.Team, gameDate}); err != nil {
+			return err
+		}
+	}
+
+	// plus-minus depends on on-court intervals and shots, possessions on attempts, rebounds and turnovers too,
+	// and a corrected event may have been one of them
+	if corrected || event.Event == eventShot || event.Event == eventEnter || event.Event == eventExit ||
+		event.Event == eventMiss || event.Event == eventRebound || event.Event == eventTurnover {
+		if err = markPlusMinusUnprocessed(ctx, tx, preparedStatements, teamGame{event.League, event.Team, gameDate}); err != nil {
+			return err
+		}
+	}
+
+	// a per-game row added or recalculated after the end of the game is final too
+	if state == gameStateFinal && exists {
+		if _, err = tx.ExecContext(ctx, updatePlayerGameFinalSQL, event.League, event.Player, gameDate, season); err != nil {
+			return fmt.Errorf("failed to mark game of %q on %s final: %w", event.Player, gameDate, err)
+		}
 	}
 
 	return nil
@@ -125,9 +432,34 @@ func txExec(ctx context.Context, tx *sql.Tx, stmt *sql.Stmt, args ...any) error
 	return nil
 }
 
-var subjectsByTables = map[table]string{
-	tablePlayersStatistics: "player",
-	tableTeamsStatistics:   "team",
+var subjectsByTables = map[table]subject{
+	tablePlayersStatistics:      subjectPlayer,
+	tableTeamsStatistics:        subjectTeam,
+	tablePlayersTeamsStatistics: subjectPlayer,
+}
+
+// statisticsArgsByTables returns arguments of the statement updating the statistics table after the event
+var statisticsArgsByTables = map[table]func(e event, season string, st seasonType) []any{
+	tablePlayersStatistics: func(e event, season string, st seasonType) []any { return []any{e.Player, season, st, e.League} },
+	tableTeamsStatistics:   func(e event, season string, st seasonType) []any { return []any{e.Team, season, st, e.League} },
+	tablePlayersTeamsStatistics: func(e event, season string, st seasonType) []any {
+		return []any{e.Player, e.Team, season, st, e.League}
+	},
+}
+
+// statisticsKey returns the Redis key of the statistics of the player or the team in the league for the season of the given type
+func statisticsKey(league string, subject subject, id, season string, st seasonType) string {
+	return fmt.Sprintf("%s:%s:%s:%s:%s", league, subject, id, season, st)
+}
+
+// stintKey returns the Redis key of the statistics of the player in the league for the season of the given type with the team
+func stintKey(league, player, team, season string, st seasonType) string {
+	return fmt.Sprintf("%s:team:%s", statisticsKey(league, subjectPlayer, player, season, st), team)
+}
+
+// stintsKey returns the Redis key of the set of teams the player played for in the league during the season of the given type
+func stintsKey(league, player, season string, st seasonType) string {
+	return fmt.Sprintf("%s:teams", statisticsKey(league, subjectPlayer, player, season, st))
 }
 
 type Statistics struct {
@@ -139,42 +471,120 @@ type Statistics struct {
 	Fouls         float64 `json:"fouls"`
 	Turnovers     float64 `json:"turnovers"`
 	MinutesPlayed float64 `json:"minutesPlayed"`
+	PlusMinus     float64 `json:"plusMinus"`
+
+	FieldGoalsMade      float64 `json:"fieldGoalsMade"`
+	FieldGoalsAttempted float64 `json:"fieldGoalsAttempted"`
+	ThreePointersMade   float64 `json:"threePointersMade"`
+	FreeThrowsMade      float64 `json:"freeThrowsMade"`
+	FreeThrowsAttempted float64 `json:"freeThrowsAttempted"`
+	OffensiveRebounds   float64 `json:"offensiveRebounds"`
+	Possessions         float64 `json:"possessions"` // the share of the possessions of the team by minutes played
+}
+
+// cachedStatistics are Statistics stored in Redis, flagged provisional until all of their games are settled
+type cachedStatistics struct {
+	Statistics
+	PlusMinusTotal float64 `json:"plusMinusTotal"` // the sum of plus-minus over the games of the season
+	Provisional    bool    `json:"provisional,omitempty"`
 }
 
-// updateCache copies unprocessed rows to Redis and marks them processed
-func updateCache(ctx context.Context, table table, stmts preparedStatements, rdb *redis.Client) error {
+// updateCache copies unprocessed rows to Redis and marks them processed.
+// Rows are claimed by the transaction, so concurrent updates skip the rows claimed by each other,
+// and an event updating a claimed row waits for the transaction to mark it unprocessed again.
+func updateCache(ctx context.Context, table table, db *sql.DB, stmts preparedStatements, rdb *redis.Client) (err error) {
 	subject := subjectsByTables[table]
 
-	rows, err := stmts.forStatisticsByOperation[operationSelectUnprocessed][table].QueryContext(ctx)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	rows, err := tx.StmtContext(ctx, stmts.forStatisticsByOperation[operationSelectUnprocessed][table]).QueryContext(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to query rows from %q: %w", table, err)
 	}
-	defer closeIt("rows", rows)
 
+	// statistics of stints are keyed by the player and the team
+	stint := table == tablePlayersTeamsStatistics
+
+	type unprocessed struct {
+		league     string
+		key        string // identifier of the player or the team
+		team       string
+		season     string
+		seasonType seasonType
+		statistics cachedStatistics
+	}
+
+	// all the claimed rows are read before further statements are executed in the same transaction
+	var claimed []unprocessed
 	for rows.Next() {
-		var key string
-		var season string
-		var s Statistics
-		if err := rows.Scan(&key, &season, &s.Points, &s.Rebounds, &s.Assists, &s.Steals, &s.Blocks, &s.Fouls, &s.Turnovers, &s.MinutesPlayed); err != nil {
+		var u unprocessed
+		s := &u.statistics.Statistics
+		keyDest := []any{&u.league, &u.key, &u.season, &u.seasonType}
+		if stint {
+			keyDest = []any{&u.league, &u.key, &u.team, &u.season, &u.seasonType}
+		}
+		if err = rows.Scan(append(keyDest, &s.Points, &s.Rebounds, &s.Assists, &s.Steals, &s.Blocks, &s.Fouls, &s.Turnovers, &s.MinutesPlayed, &s.PlusMinus, &u.statistics.PlusMinusTotal,
+			&s.FieldGoalsMade, &s.FieldGoalsAttempted, &s.ThreePointersMade, &s.FreeThrowsMade, &s.FreeThrowsAttempted, &s.OffensiveRebounds, &s.Possessions, &u.statistics.Provisional)...); err != nil {
+			closeIt("rows", rows)
 			return fmt.Errorf("failed to scan row from %q: %w", table, err)
 		}
+		claimed = append(claimed, u)
+	}
+	closeIt("rows", rows)
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to query rows from %q: %w", table, err)
+	}
+
+	for _, u := range claimed {
+		league, key, team, season, seasonType := u.league, u.key, u.team, u.season, u.seasonType
 
-		valueJSON, err := json.Marshal(s)
+		valueJSON, err := json.Marshal(u.statistics)
 		if err != nil {
 			return fmt.Errorf("failed to marshal statistics from %q: %w", table, err)
 		}
 
-		statsKey := fmt.Sprintf("%s:%s:%s", subject, key, season)
+		statsKey := statisticsKey(league, subject, key, season, seasonType)
+		if stint {
+			statsKey = stintKey(league, key, team, season, seasonType)
+		}
 		log.Println(fmt.Sprintf("Going to set the %q key to the value %q in Redis. ", statsKey, valueJSON))
 
-		if err := rdb.Set(ctx, statsKey, valueJSON, 0).Err(); err != nil {
-			return fmt.Errorf("failed to set to Redis statistics of %s %q for season %s: %w", subject, key, season, err)
+		// the statistics and the indexes used for listing and searching are updated atomically
+		if _, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, statsKey, valueJSON, 0)
+			pipe.ZAdd(ctx, indexKey(league, indexesBySubjects[subject]), redis.Z{Member: key})
+			pipe.ZAdd(ctx, indexKey(league, indexSeasons), redis.Z{Member: season})
+			pipe.ZAdd(ctx, indexLeagues, redis.Z{Member: league})
+			if stint {
+				pipe.SAdd(ctx, stintsKey(league, key, season, seasonType), team)
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to set to Redis statistics of %s %q in %s for %s season %s: %w", subject, key, league, seasonType, season, err)
 		}
 
-		if _, err := stmts.forStatisticsByOperation[operationUpdateUnprocessed][table].ExecContext(ctx, key, season); err != nil {
-			return fmt.Errorf("failed to update processed row of %s where %s is %q for season %s: %w", table, subject, key, season, err)
+		keyArgs := []any{league, key, season, seasonType}
+		if stint {
+			keyArgs = []any{league, key, team, season, seasonType}
+		}
+		if err := txExec(ctx, tx, stmts.forStatisticsByOperation[operationUpdateUnprocessed][table], keyArgs...); err != nil {
+			return fmt.Errorf("failed to update processed row of %s where %s is %q in %s for %s season %s: %w", table, subject, key, league, seasonType, season, err)
 		}
 	}
 
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
@@ -4,18 +4,22 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"github.com/DATA-DOG/go-sqlmock"
+	"maps"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 )
 
 const (
-	leBronJames      = "LeBron James"
-	losAngelesLakers = "Los Angeles Lakers"
+	leBronJames        = "LeBron James"
+	leBronJamesID      = "lebron-james"
+	losAngelesLakers   = "Los Angeles Lakers"
+	losAngelesLakersID = "los-angeles-lakers"
 )
 
 func esc(s string) string {
-	for _, c := range []string{"(", ")", "$", ".", "+"} {
+	for _, c := range []string{"(", ")", "$", ".", "+", "*"} {
 		s = strings.ReplaceAll(s, c, "\\"+c)
 	}
 	return s
@@ -30,18 +34,66 @@ func prepareMockStmt(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, sql string)
 	return expectedPrepare, stmt
 }
 
-func prepareUpdateStatisticsMockStmts(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock) (map[table]*sqlmock.ExpectedPrepare, map[table]*sql.Stmt) {
+func prepareStatisticsMockStmts(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock, operation operation) (map[table]*sqlmock.ExpectedPrepare, map[table]*sql.Stmt) {
 	expectedPrepares := map[table]*sqlmock.ExpectedPrepare{}
 	stmts := map[table]*sql.Stmt{}
 	for _, table := range statisticsTables {
-		expectedPrepare, stmt := prepareMockStmt(t, db, mock, statisticsTableOperationsSQLs[operationUpdateStatistics][table])
+		expectedPrepare, stmt := prepareMockStmt(t, db, mock, statisticsTableOperationsSQLs[operation][table])
 		expectedPrepares[table] = expectedPrepare
 		stmts[table] = stmt
 	}
 	return expectedPrepares, stmts
 }
 
-func testEventHandler(t *testing.T, e event, eventSQL string) {
+func prepareRecalculationMockStmts(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock) (map[eventType]*sqlmock.ExpectedPrepare, map[eventType]*sql.Stmt) {
+	expectedPrepares := map[eventType]*sqlmock.ExpectedPrepare{}
+	stmts := map[eventType]*sql.Stmt{}
+	for eventType := range eventTypes {
+		updateSQL := updateGameOnTimeEventSQL
+		if column, ok := countersByEventTypes[eventType]; ok {
+			updateSQL = updateGameOnCounterEventSQL(eventType, column)
+		} else if eventType == eventMiss {
+			updateSQL = updateGameOnAttemptEventSQL
+		}
+		expectedPrepare, stmt := prepareMockStmt(t, db, mock, updateSQL)
+		expectedPrepares[eventType] = expectedPrepare
+		stmts[eventType] = stmt
+	}
+	return expectedPrepares, stmts
+}
+
+func prepareRegistryMockStmts(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock) (map[subject]map[operation]*sqlmock.ExpectedPrepare, map[subject]map[operation]*sql.Stmt) {
+	expectedPrepares := map[subject]map[operation]*sqlmock.ExpectedPrepare{}
+	stmts := map[subject]map[operation]*sql.Stmt{}
+	for _, subject := range subjects {
+		expectedPrepares[subject] = map[operation]*sqlmock.ExpectedPrepare{}
+		stmts[subject] = map[operation]*sql.Stmt{}
+		for _, operation := range []operation{operationSelectID, operationSelectAlias, operationInsertID, operationInsertAlias} {
+			expectedPrepare, stmt := prepareMockStmt(t, db, mock, registrySQLs(subject)[operation])
+			expectedPrepares[subject][operation] = expectedPrepare
+			stmts[subject][operation] = stmt
+		}
+	}
+	return expectedPrepares, stmts
+}
+
+var countersByEventTypes = map[eventType]column{
+	eventShot:     columnPoints,
+	eventRebound:  columnRebounds,
+	eventAssist:   columnAssists,
+	eventSteal:    columnSteals,
+	eventBlock:    columnBlocks,
+	eventFoul:     columnFouls,
+	eventTurnover: columnTurnovers,
+}
+
+// testEventHandler processes the event, which is new unless corrected, i.e. it replaces an existing event at the same timestamp
+func testEventHandler(t *testing.T, e event, corrected bool) {
+	testEventHandlerFrom(t, e, corrected, "")
+}
+
+// testEventHandlerFrom processes the event as testEventHandler does, correcting an event of the previous game date of the team unless it's empty
+func testEventHandlerFrom(t *testing.T, e event, corrected bool, previousGameDate string) {
 	ctx := t.Context()
 
 	db, mock, err := sqlmock.New()
@@ -51,25 +103,158 @@ func testEventHandler(t *testing.T, e event, eventSQL string) {
 	defer closeIt("DB", db)
 
 	upsertEventExpectedPrepare, upsertEventStmt := prepareMockStmt(t, db, mock, upsertEventSQL)
+	eventSQL := updateGameOnTimeEventSQL
+	if column, ok := countersByEventTypes[e.Event]; ok {
+		eventSQL = incrementGameOnCounterEventSQL(column)
+	} else if e.Event == eventMiss {
+		eventSQL = updateGameOnAttemptEventSQL
+	}
 	eventExpectedPrepare, eventStmt := prepareMockStmt(t, db, mock, eventSQL)
-	updateStatisticsExpectedPrepares, updateStatisticsStmts := prepareUpdateStatisticsMockStmts(t, db, mock)
+	recalculationExpectedPrepares, recalculationStmts := prepareRecalculationMockStmts(t, db, mock)
+	updateStatisticsExpectedPrepares, updateStatisticsStmts := prepareStatisticsMockStmts(t, db, mock, operationUpdateStatistics)
+	incrementStatisticsExpectedPrepares, incrementStatisticsStmts := prepareStatisticsMockStmts(t, db, mock, operationIncrementStatistics)
+	registryExpectedPrepares, registryStmts := prepareRegistryMockStmts(t, db, mock)
+	rosterExpectedPrepare, rosterStmt := prepareMockStmt(t, db, mock, selectRosterMembershipSQL)
+	timezoneExpectedPrepare, timezoneStmt := prepareMockStmt(t, db, mock, selectTeamTimezoneSQL)
+	playerGameExpectedPrepare, playerGameStmt := prepareMockStmt(t, db, mock, selectPlayerGameSQL)
+	deletePlayerGameExpectedPrepare, deletePlayerGameStmt := prepareMockStmt(t, db, mock, deletePlayerGameSQL)
+	purgedExpectedPrepare, purgedStmt := prepareMockStmt(t, db, mock, selectTeamGamePurgedSQL)
+	closedExpectedPrepare, closedStmt := prepareMockStmt(t, db, mock, selectSeasonClosedSQL)
+	lockKeyExpectedPrepare, lockKeyStmt := prepareMockStmt(t, db, mock, lockKeySQL)
+	watermarkExpectedPrepare, watermarkStmt := prepareMockStmt(t, db, mock, upsertTeamGameWatermarkSQL)
+	deleteScoresExpectedPrepare, deleteScoresStmt := prepareMockStmt(t, db, mock, deleteTeamScoresSQL)
+	insertScoresExpectedPrepare, insertScoresStmt := prepareMockStmt(t, db, mock, insertTeamScoresSQL)
+	plusMinusExpectedPrepare, plusMinusStmt := prepareMockStmt(t, db, mock, markPlusMinusUnprocessedSQL)
 
 	stmts := preparedStatements{
 		upsertEvent:              upsertEventStmt,
-		forUpdatesByEventType:    map[eventType]*sql.Stmt{e.Event: eventStmt},
-		forStatisticsByOperation: map[operation]map[table]*sql.Stmt{operationUpdateStatistics: updateStatisticsStmts},
+		forUpdatesByEventType:    recalculationStmts,
+		forIncrementsByEventType: map[eventType]*sql.Stmt{},
+		forStatisticsByOperation: map[operation]map[table]*sql.Stmt{
+			operationUpdateStatistics:    updateStatisticsStmts,
+			operationIncrementStatistics: incrementStatisticsStmts,
+		},
+		forRegistryBySubject:     registryStmts,
+		selectRosterMembership:   rosterStmt,
+		selectTeamTimezone:       timezoneStmt,
+		selectPlayerGame:         playerGameStmt,
+		deletePlayerGame:         deletePlayerGameStmt,
+		selectTeamGamePurged:     purgedStmt,
+		selectSeasonClosed:       closedStmt,
+		lockKey:                  lockKeyStmt,
+		upsertTeamGameWatermark:  watermarkStmt,
+		deleteTeamScores:         deleteScoresStmt,
+		insertTeamScores:         insertScoresStmt,
+		markPlusMinusUnprocessed: plusMinusStmt,
+	}
+	eventArgs := []driver.Value{leBronJamesID, losAngelesLakersID}
+	if _, ok := countersByEventTypes[e.Event]; ok {
+		stmts.forIncrementsByEventType[e.Event] = eventStmt
+	} else {
+		stmts.forUpdatesByEventType[e.Event] = eventStmt
 	}
 
-	season, gameDate := e.season(), e.gameDate()
+	if e.League == "" {
+		e.League = defaultLeague
+	}
+	league := defaultLeagues[e.League]
+	season, gameDate := e.season(league, time.UTC), e.gameDate(time.UTC)
+	seasonType := league.Calendar.seasonType(season, gameDate)
+	eventArgs = append(eventArgs, gameDate, season, seasonType, e.League)
+
+	playerGameColumns := []string{"points", "rebounds", "assists", "steals", "blocks", "fouls", "turnovers", "minutes_played", "plus_minus",
+		"field_goals_made", "field_goals_attempted", "three_pointers_made", "free_throws_made", "free_throws_attempted", "offensive_rebounds", "possessions"}
 
 	mock.ExpectBegin()
-	upsertEventExpectedPrepare.ExpectExec().WithArgs(e.Player, e.Team, e.Timestamp, e.Event, gameDate, e.value()).WillReturnResult(driver.RowsAffected(1))
-	eventExpectedPrepare.ExpectExec().WithArgs(e.Player, e.Team, gameDate, season).WillReturnResult(driver.RowsAffected(0))
-	updateStatisticsExpectedPrepares[tablePlayersStatistics].ExpectExec().WithArgs(e.Player, season).WillReturnResult(driver.RowsAffected(1))
-	updateStatisticsExpectedPrepares[tableTeamsStatistics].ExpectExec().WithArgs(e.Team, season).WillReturnResult(driver.RowsAffected(1))
+	// the player is resolved by alias, the team is given by identifier
+	registryExpectedPrepares[subjectPlayer][operationSelectID].ExpectQuery().WithArgs(e.Player).WillReturnError(sql.ErrNoRows)
+	registryExpectedPrepares[subjectPlayer][operationSelectAlias].ExpectQuery().WithArgs(normalizeName(e.Player)).WillReturnRows(sqlmock.NewRows([]string{"player"}).AddRow(leBronJamesID))
+	registryExpectedPrepares[subjectTeam][operationSelectID].ExpectQuery().WithArgs(losAngelesLakersID).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(losAngelesLakersID))
+	// aggregates are locked in the order of their keys
+	lockKeyExpectedPrepare.ExpectExec().WithArgs(e.League + ":player:" + leBronJamesID).WillReturnResult(driver.RowsAffected(1))
+	lockKeyExpectedPrepare.ExpectExec().WithArgs(e.League + ":team:" + losAngelesLakersID).WillReturnResult(driver.RowsAffected(1))
+	timezoneExpectedPrepare.ExpectQuery().WithArgs(losAngelesLakersID).WillReturnRows(sqlmock.NewRows([]string{"timezone"}).AddRow(""))
+	purgedExpectedPrepare.ExpectQuery().WithArgs(e.League, losAngelesLakersID, gameDate).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	closedExpectedPrepare.ExpectQuery().WithArgs(e.League, season).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	rosterExpectedPrepare.ExpectQuery().WithArgs(losAngelesLakersID, leBronJamesID, gameDate).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	previousTeam := ""
+	if corrected {
+		previousTeam = losAngelesLakersID
+		if previousGameDate == "" {
+			previousGameDate = gameDate
+		}
+	}
+	upsertEventExpectedPrepare.ExpectQuery().WithArgs(leBronJamesID, losAngelesLakersID, e.Timestamp.UTC(), e.Event, gameDate, e.value(), nil, e.League, false, e.Offensive).
+		WillReturnRows(sqlmock.NewRows([]string{"exists", "team", "game_date"}).AddRow(corrected, previousTeam, previousGameDate))
+	watermarkExpectedPrepare.ExpectQuery().WithArgs(e.League, losAngelesLakersID, gameDate, e.Timestamp.UTC(), 0.0).WillReturnRows(sqlmock.NewRows([]string{"late", "finalized_at", "state"}).AddRow(false, nil, gameStateLive))
+
+	if corrected {
+		playerGameExpectedPrepare.ExpectQuery().WithArgs(e.League, leBronJamesID, gameDate, season).WillReturnRows(sqlmock.NewRows(playerGameColumns).AddRow(2, 0, 0, 0, 0, 0, 0, 0.0, 0, 0, 0, 0, 0, 0, 0, 0.0))
+		deletePlayerGameExpectedPrepare.ExpectExec().WithArgs(e.League, leBronJamesID, gameDate, season).WillReturnResult(driver.RowsAffected(1))
+		for _, eventType := range slices.Sorted(maps.Keys(eventTypes)) {
+			expectedPrepare := recalculationExpectedPrepares[eventType]
+			if stmts.forUpdatesByEventType[eventType] == eventStmt {
+				expectedPrepare = eventExpectedPrepare
+			}
+			expectedPrepare.ExpectExec().WithArgs(eventArgs...).WillReturnResult(driver.RowsAffected(0))
+		}
+	} else {
+		playerGameExpectedPrepare.ExpectQuery().WithArgs(e.League, leBronJamesID, gameDate, season).WillReturnRows(sqlmock.NewRows(playerGameColumns))
+		if _, ok := countersByEventTypes[e.Event]; ok {
+			eventExpectedPrepare.ExpectExec().WithArgs(append(eventArgs, e.value())...).WillReturnResult(driver.RowsAffected(1))
+		} else {
+			eventExpectedPrepare.ExpectExec().WithArgs(eventArgs...).WillReturnResult(driver.RowsAffected(0))
+		}
+		// made shots and rebounds recalculate attempts and offensive rebounds too
+		if e.Event == eventShot || e.Event == eventRebound {
+			recalculationExpectedPrepares[eventMiss].ExpectExec().WithArgs(eventArgs...).WillReturnResult(driver.RowsAffected(1))
+		}
+	}
+	playerGameExpectedPrepare.ExpectQuery().WithArgs(e.League, leBronJamesID, gameDate, season).WillReturnRows(sqlmock.NewRows(playerGameColumns).AddRow(3, 0, 0, 0, 0, 0, 0, 0.0, 0, 0, 0, 0, 0, 0, 0, 0.0))
+
+	if corrected {
+		updateStatisticsExpectedPrepares[tablePlayersStatistics].ExpectExec().WithArgs(leBronJamesID, season, seasonType, e.League).WillReturnResult(driver.RowsAffected(1))
+		updateStatisticsExpectedPrepares[tableTeamsStatistics].ExpectExec().WithArgs(losAngelesLakersID, season, seasonType, e.League).WillReturnResult(driver.RowsAffected(1))
+		updateStatisticsExpectedPrepares[tablePlayersTeamsStatistics].ExpectExec().WithArgs(leBronJamesID, losAngelesLakersID, season, seasonType, e.League).WillReturnResult(driver.RowsAffected(1))
+	} else {
+		// the first event of the game adds the game with its per-game values
+		change := []driver.Value{1, 3.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0}
+		incrementStatisticsExpectedPrepares[tablePlayersStatistics].ExpectExec().WithArgs(append([]driver.Value{leBronJamesID, season, seasonType, e.League}, change...)...).WillReturnResult(driver.RowsAffected(1))
+		incrementStatisticsExpectedPrepares[tableTeamsStatistics].ExpectExec().WithArgs(append([]driver.Value{losAngelesLakersID, season, seasonType, e.League}, change...)...).WillReturnResult(driver.RowsAffected(1))
+		incrementStatisticsExpectedPrepares[tablePlayersTeamsStatistics].ExpectExec().WithArgs(append([]driver.Value{leBronJamesID, losAngelesLakersID, season, seasonType, e.League}, change...)...).WillReturnResult(driver.RowsAffected(1))
+	}
+	// the game the corrected event is moved from is recalculated without it
+	if corrected && previousGameDate != gameDate {
+		lockKeyExpectedPrepare.ExpectExec().WithArgs(e.League + ":team:" + losAngelesLakersID).WillReturnResult(driver.RowsAffected(1))
+		deletePlayerGameExpectedPrepare.ExpectExec().WithArgs(e.League, leBronJamesID, previousGameDate, season).WillReturnResult(driver.RowsAffected(1))
+		previousEventArgs := []driver.Value{leBronJamesID, losAngelesLakersID, previousGameDate, season, seasonType, e.League}
+		for _, eventType := range slices.Sorted(maps.Keys(eventTypes)) {
+			expectedPrepare := recalculationExpectedPrepares[eventType]
+			if stmts.forUpdatesByEventType[eventType] == eventStmt {
+				expectedPrepare = eventExpectedPrepare
+			}
+			expectedPrepare.ExpectExec().WithArgs(previousEventArgs...).WillReturnResult(driver.RowsAffected(0))
+		}
+		updateStatisticsExpectedPrepares[tablePlayersStatistics].ExpectExec().WithArgs(leBronJamesID, season, seasonType, e.League).WillReturnResult(driver.RowsAffected(1))
+		updateStatisticsExpectedPrepares[tableTeamsStatistics].ExpectExec().WithArgs(losAngelesLakersID, season, seasonType, e.League).WillReturnResult(driver.RowsAffected(1))
+		updateStatisticsExpectedPrepares[tablePlayersTeamsStatistics].ExpectExec().WithArgs(leBronJamesID, losAngelesLakersID, season, seasonType, e.League).WillReturnResult(driver.RowsAffected(1))
+		deleteScoresExpectedPrepare.ExpectExec().WithArgs(e.League, losAngelesLakersID, previousGameDate).WillReturnResult(driver.RowsAffected(1))
+		insertScoresExpectedPrepare.ExpectExec().WithArgs(e.League, losAngelesLakersID, previousGameDate).WillReturnResult(driver.RowsAffected(1))
+		plusMinusExpectedPrepare.ExpectExec().WithArgs(e.League, losAngelesLakersID, previousGameDate).WillReturnResult(driver.RowsAffected(1))
+	}
+	// the score of the game is recalculated after a shot, or a correction of an event which may have been a shot
+	if corrected || e.Event == eventShot {
+		deleteScoresExpectedPrepare.ExpectExec().WithArgs(e.League, losAngelesLakersID, gameDate).WillReturnResult(driver.RowsAffected(1))
+		insertScoresExpectedPrepare.ExpectExec().WithArgs(e.League, losAngelesLakersID, gameDate).WillReturnResult(driver.RowsAffected(1))
+	}
+	// plus-minus and tempo are recalculated after a change of on-court intervals, attempts, rebounds or turnovers
+	if corrected || e.Event == eventShot || e.Event == eventEnter || e.Event == eventExit ||
+		e.Event == eventMiss || e.Event == eventRebound || e.Event == eventTurnover {
+		plusMinusExpectedPrepare.ExpectExec().WithArgs(e.League, losAngelesLakersID, gameDate).WillReturnResult(driver.RowsAffected(1))
+	}
 	mock.ExpectCommit()
 
-	if err := processEvent(ctx, e, db, stmts); err != nil {
+	if err := processEvent(ctx, config{rosterValidation: rosterValidationStrict, leagues: defaultLeagues}, e, false, db, stmts); err != nil {
 		t.Fatalf("failed to process event: %v", err)
 	}
 
@@ -78,79 +263,116 @@ func testEventHandler(t *testing.T, e event, eventSQL string) {
 	}
 }
 
+func TestEventHandler_Correction(t *testing.T) {
+	testEventHandler(t,
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventShot, Points: 3},
+		true,
+	)
+}
+
+func TestEventHandler_CorrectionOfGameDate(t *testing.T) {
+	// the shot was counted for the game of the previous date, e.g. before the timezone of the venue was known
+	testEventHandlerFrom(t,
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.UTC), Event: eventShot, Points: 3},
+		true, "2025-05-22",
+	)
+}
+
+func TestEventHandler_CorrectionOfShot(t *testing.T) {
+	// the shot at the timestamp is corrected to a rebound, so the score is recalculated without its points
+	testEventHandler(t,
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventRebound},
+		true,
+	)
+}
+
 func TestEventHandler_EventEnter(t *testing.T) {
 	testEventHandler(t,
-		event{Player: leBronJames, Team: losAngelesLakers, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventEnter},
-		updateGameOnTimeEventSQL,
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventEnter},
+		false,
 	)
 }
 
 func TestEventHandler_EventExit(t *testing.T) {
 	testEventHandler(t,
-		event{Player: leBronJames, Team: losAngelesLakers, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventExit},
-		updateGameOnTimeEventSQL,
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventExit},
+		false,
 	)
 }
 
 func TestEventHandler_EventShot1Point(t *testing.T) {
 	testEventHandler(t,
-		event{Player: leBronJames, Team: losAngelesLakers, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventShot, Points: 1},
-		updateGameOnCounterEventSQL(eventShot, columnPoints),
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventShot, Points: 1},
+		false,
 	)
 }
 
 func TestEventHandler_EventShot2Points(t *testing.T) {
 	testEventHandler(t,
-		event{Player: leBronJames, Team: losAngelesLakers, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventShot, Points: 2},
-		updateGameOnCounterEventSQL(eventShot, columnPoints),
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventShot, Points: 2},
+		false,
 	)
 }
 
 func TestEventHandler_EventShot3Points(t *testing.T) {
 	testEventHandler(t,
-		event{Player: leBronJames, Team: losAngelesLakers, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventShot, Points: 3},
-		updateGameOnCounterEventSQL(eventShot, columnPoints),
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventShot, Points: 3},
+		false,
+	)
+}
+
+func TestEventHandler_EventMiss(t *testing.T) {
+	testEventHandler(t,
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventMiss, Points: 3},
+		false,
+	)
+}
+
+func TestEventHandler_EventOffensiveRebound(t *testing.T) {
+	testEventHandler(t,
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventRebound, Offensive: true},
+		false,
 	)
 }
 
 func TestEventHandler_EventRebound(t *testing.T) {
 	testEventHandler(t,
-		event{Player: leBronJames, Team: losAngelesLakers, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventRebound},
-		updateGameOnCounterEventSQL(eventRebound, columnRebounds),
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventRebound},
+		false,
 	)
 }
 
 func TestEventHandler_EventAssist(t *testing.T) {
 	testEventHandler(t,
-		event{Player: leBronJames, Team: losAngelesLakers, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventAssist},
-		updateGameOnCounterEventSQL(eventAssist, columnAssists),
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventAssist},
+		false,
 	)
 }
 
 func TestEventHandler_EventSteal(t *testing.T) {
 	testEventHandler(t,
-		event{Player: leBronJames, Team: losAngelesLakers, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventSteal},
-		updateGameOnCounterEventSQL(eventSteal, columnSteals),
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventSteal},
+		false,
 	)
 }
 
 func TestEventHandler_EventBlock(t *testing.T) {
 	testEventHandler(t,
-		event{Player: leBronJames, Team: losAngelesLakers, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventBlock},
-		updateGameOnCounterEventSQL(eventBlock, columnBlocks),
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventBlock},
+		false,
 	)
 }
 
 func TestEventHandler_EventFoul(t *testing.T) {
 	testEventHandler(t,
-		event{Player: leBronJames, Team: losAngelesLakers, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventFoul},
-		updateGameOnCounterEventSQL(eventFoul, columnFouls),
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventFoul},
+		false,
 	)
 }
 
 func TestEventHandler_EventTurnover(t *testing.T) {
 	testEventHandler(t,
-		event{Player: leBronJames, Team: losAngelesLakers, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventTurnover},
-		updateGameOnCounterEventSQL(eventTurnover, columnTurnovers),
+		event{Player: leBronJames, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.May, 23, 15, 0, 0, 0, time.Local), Event: eventTurnover},
+		false,
 	)
 }
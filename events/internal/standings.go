@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"slices"
+)
+
+// lastGames is the number of the latest games of the record of a team over them
+const lastGames = 10
+
+// updateTeamGroupsSQL is an SQL statement to set the conference and the division of the team, keeping the ones not specified
+// Parameter placeholders are intended for:
+// $1: team
+// $2: conference, or empty
+// $3: division, or empty
+const updateTeamGroupsSQL = `UPDATE "teams" SET "conference" = COALESCE(NULLIF($2, ''), "conference"), "division" = COALESCE(NULLIF($3, ''), "division")
+WHERE "id" = $1`
+
+// selectSeasonResultsSQL is an SQL statement to select the results of final regular season games of the season in the order of their dates.
+// A game is final once either team ended or finalized it, and the results are the totals of the scores of the teams.
+// Parameter placeholders are intended for:
+// $1: league
+// $2: season
+const selectSeasonResultsSQL = `SELECT h."team", h."opponent",
+	COALESCE((SELECT SUM("points") FROM "team_scores" WHERE "league" = h."league" AND "team" = h."team" AND "game_date" = h."game_date"), 0),
+	COALESCE((SELECT SUM("points") FROM "team_scores" WHERE "league" = h."league" AND "team" = h."opponent" AND "game_date" = h."game_date"), 0)
+FROM "team_games" h
+LEFT JOIN "team_games" a ON a."league" = h."league" AND a."team" = h."opponent" AND a."game_date" = h."game_date"
+WHERE h."league" = $1 AND h."season" = $2 AND h."season_type" = 'regular' AND h."home_team" = h."team" AND h."opponent" IS NOT NULL
+	AND (h."state" = 'final' OR h."finalized_at" IS NOT NULL OR a."state" = 'final' OR a."finalized_at" IS NOT NULL)
+ORDER BY h."game_date", h."team"`
+
+// leagueSeason identifies the standings of the league in the season
+type leagueSeason struct {
+	league, season string
+}
+
+// gameResult is the result of a final game
+type gameResult struct {
+	home, away             string
+	homePoints, awayPoints int
+}
+
+// record is a number of wins and losses
+type record struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+}
+
+func (r *record) add(won bool) {
+	if won {
+		r.Wins++
+	} else {
+		r.Losses++
+	}
+}
+
+// standing is the record of a team in the regular season
+type standing struct {
+	Team string `json:"team"`
+	record
+	Home    record `json:"home"`
+	Away    record `json:"away"`
+	Streak  string `json:"streak"` // e.g. "W3" or "L1"
+	LastTen record `json:"lastTen"`
+}
+
+func (s standing) winPercentage() float64 {
+	return float64(s.Wins) / float64(s.Wins+s.Losses)
+}
+
+// standingsKey returns the Redis key of the standings of the league in the season
+func standingsKey(league, season string) string {
+	return fmt.Sprintf("%s:standings:%s", league, season)
+}
+
+// newStandings returns the records of the teams from the results of their games in the order of dates, ordered by win percentage.
+// Tied games aren't counted, as they are expected to be played to a decision.
+func newStandings(results []gameResult) []standing {
+	outcomes := map[string][]bool{} // whether each game was won by the team
+	standings := map[string]*standing{}
+	add := func(team string, won, home bool) {
+		s, ok := standings[team]
+		if !ok {
+			s = &standing{Team: team}
+			standings[team] = s
+		}
+		s.add(won)
+		if home {
+			s.Home.add(won)
+		} else {
+			s.Away.add(won)
+		}
+		outcomes[team] = append(outcomes[team], won)
+	}
+
+	for _, r := range results {
+		if r.homePoints == r.awayPoints {
+			continue
+		}
+		add(r.home, r.homePoints > r.awayPoints, true)
+		add(r.away, r.awayPoints > r.homePoints, false)
+	}
+
+	list := make([]standing, 0, len(standings))
+	for team, s := range standings {
+		games := outcomes[team]
+		for _, won := range games[max(len(games)-lastGames, 0):] {
+			s.LastTen.add(won)
+		}
+
+		last, streak := games[len(games)-1], 0
+		for i := len(games) - 1; i >= 0 && games[i] == last; i-- {
+			streak++
+		}
+		s.Streak = fmt.Sprintf("L%d", streak)
+		if last {
+			s.Streak = fmt.Sprintf("W%d", streak)
+		}
+
+		list = append(list, *s)
+	}
+
+	slices.SortFunc(list, func(a, b standing) int {
+		return cmp.Or(cmp.Compare(b.winPercentage(), a.winPercentage()), cmp.Compare(b.Wins, a.Wins), cmp.Compare(a.Team, b.Team))
+	})
+
+	return list
+}
+
+// updateStandingsCache recalculates the standings of the league in the season from the results of its games, and copies them to Redis
+func updateStandingsCache(ctx context.Context, tx *sql.Tx, rdb *redis.Client, league, season string) error {
+	rows, err := tx.QueryContext(ctx, selectSeasonResultsSQL, league, season)
+	if err != nil {
+		return fmt.Errorf("failed to select results of %s season %s: %w", league, season, err)
+	}
+
+	var results []gameResult
+	for rows.Next() {
+		var r gameResult
+		if err := rows.Scan(&r.home, &r.away, &r.homePoints, &r.awayPoints); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan result of %s season %s: %w", league, season, err)
+		}
+		results = append(results, r)
+	}
+	closeIt("rows", rows)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to select results of %s season %s: %w", league, season, err)
+	}
+
+	valueJSON, err := json.Marshal(newStandings(results))
+	if err != nil {
+		return fmt.Errorf("failed to marshal standings: %w", err)
+	}
+
+	if err := rdb.Set(ctx, standingsKey(league, season), valueJSON, 0).Err(); err != nil {
+		return fmt.Errorf("failed to set to Redis standings of %s season %s: %w", league, season, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestNewStandings(t *testing.T) {
+	const celtics, knicks = "boston-celtics", "new-york-knicks"
+	results := []gameResult{
+		{losAngelesLakersID, celtics, 110, 104},
+		{celtics, knicks, 99, 101},
+		{knicks, losAngelesLakersID, 95, 102},
+		{losAngelesLakersID, knicks, 100, 100}, // not counted
+		{celtics, losAngelesLakersID, 120, 111},
+	}
+
+	standings := newStandings(results)
+	if len(standings) != 3 {
+		t.Fatalf("expected standings of 3 teams, got %d", len(standings))
+	}
+
+	for i, expected := range []standing{
+		{Team: losAngelesLakersID, record: record{2, 1}, Home: record{1, 0}, Away: record{1, 1}, Streak: "L1", LastTen: record{2, 1}},
+		{Team: knicks, record: record{1, 1}, Home: record{0, 1}, Away: record{1, 0}, Streak: "L1", LastTen: record{1, 1}},
+		{Team: celtics, record: record{1, 2}, Home: record{1, 1}, Away: record{0, 1}, Streak: "W1", LastTen: record{1, 2}},
+	} {
+		if standings[i] != expected {
+			t.Errorf("expected standing %d %+v, got %+v", i, expected, standings[i])
+		}
+	}
+}
+
+func TestNewStandings_LastTen(t *testing.T) {
+	const celtics = "boston-celtics"
+	var results []gameResult
+	for i := range 12 {
+		// the Lakers lose the first 4 games, and win the last 8
+		points := 110
+		if i < 4 {
+			points = 90
+		}
+		results = append(results, gameResult{losAngelesLakersID, celtics, points, 100})
+	}
+
+	lakers := newStandings(results)[0]
+	if lakers.Team != losAngelesLakersID || lakers.record != (record{8, 4}) || lakers.Streak != "W8" || lakers.LastTen != (record{8, 2}) {
+		t.Errorf("unexpected standing %+v", lakers)
+	}
+}
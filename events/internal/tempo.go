@@ -0,0 +1,226 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// selectGameTotalsSQL is an SQL statement to select the totals of the players of both teams in the game the possessions are estimated from
+// Parameter placeholders are intended for:
+// $1: league
+// $2: game date in format "2006-01-02"
+// $3: team
+// $4: opponent
+const selectGameTotalsSQL = `SELECT "team", SUM("field_goals_attempted"), SUM("free_throws_attempted"), SUM("offensive_rebounds"), SUM("turnovers")
+FROM "players_by_games_all"
+WHERE "league" = $1 AND "game_date" = $2 AND "team" IN ($3, $4)
+GROUP BY "team"`
+
+// upsertGameTempoSQL is an SQL statement to replace the tempo of the team in the game
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+// $4: season
+// $5: season type
+// $6: opponent
+// $7: possessions
+// $8, $9, $10: minutes, points for and points against of the game
+const upsertGameTempoSQL = `INSERT INTO "tempo_by_games" ("league", "team", "game_date", "season", "season_type", "opponent", "possessions", "minutes", "points_for", "points_against")
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT ("league", "team", "game_date") DO UPDATE SET
+	"season" = EXCLUDED."season",
+	"season_type" = EXCLUDED."season_type",
+	"opponent" = EXCLUDED."opponent",
+	"possessions" = EXCLUDED."possessions",
+	"minutes" = EXCLUDED."minutes",
+	"points_for" = EXCLUDED."points_for",
+	"points_against" = EXCLUDED."points_against"`
+
+// selectSeasonTempoSQL is an SQL statement to select the tempo of the team in the games of the season of the type
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: season
+// $4: season type
+const selectSeasonTempoSQL = `SELECT "game_date", "opponent", "possessions", "minutes", "points_for", "points_against"
+FROM "tempo_by_games"
+WHERE "league" = $1 AND "team" = $2 AND "season" = $3 AND "season_type" = $4
+ORDER BY "game_date"`
+
+// updatePlayersPossessionsSQL is an SQL statement to recalculate the possessions of the players of the team in the game,
+// i.e. the share of the possessions of the team by the minutes each player was on the court.
+// It returns the changes of the possessions of the per-game rows changed.
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+// $4: possessions of the team
+// $5: minutes of the game
+const updatePlayersPossessionsSQL = `WITH "previous" AS (
+	SELECT "player", "season", "possessions",
+		CASE WHEN $5::float8 > 0 THEN $4::float8 * "minutes_played" / $5::float8 ELSE 0 END AS "share"
+	FROM "players_by_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+)
+UPDATE "players_by_games" g SET "possessions" = p."share"
+FROM "previous" p
+WHERE g."league" = $1 AND g."player" = p."player" AND g."game_date" = $3 AND g."season" = p."season" AND g."possessions" <> p."share"
+RETURNING g."player", g."season", g."season_type", g."possessions" - p."possessions"`
+
+// freeThrowPossessions is the share of free throw attempts ending a possession, as and-ones and technical fouls don't
+const freeThrowPossessions = 0.44
+
+// gameTotals are the totals of the players of a team in a game the possessions are estimated from
+type gameTotals struct {
+	fieldGoalsAttempted, freeThrowsAttempted, offensiveRebounds, turnovers float64
+}
+
+// possessions returns the possessions of the team: the attempts and the turnovers ending them, except the attempts followed by offensive rebounds
+func (t gameTotals) possessions() float64 {
+	return t.fieldGoalsAttempted + freeThrowPossessions*t.freeThrowsAttempted - t.offensiveRebounds + t.turnovers
+}
+
+// gamePossessions returns the possessions of each team in the game, estimated as the average of the possessions of both teams,
+// as they alternate
+func gamePossessions(team, opponent gameTotals) float64 {
+	return max(0, (team.possessions()+opponent.possessions())/2)
+}
+
+// gameTempo is the possessions and the minutes of a game of a team, and the points of the team and its opponent
+type gameTempo struct {
+	GameDate      string  `json:"gameDate"`
+	Opponent      string  `json:"opponent"`
+	Possessions   float64 `json:"possessions"`
+	Minutes       float64 `json:"minutes"`
+	PointsFor     int     `json:"pointsFor"`
+	PointsAgainst int     `json:"pointsAgainst"`
+}
+
+// tempoKey returns the Redis key of the tempo of the team in the league for the season of the given type
+func tempoKey(league, team, season string, st seasonType) string {
+	return fmt.Sprintf("%s:tempo", statisticsKey(league, subjectTeam, team, season, st))
+}
+
+// updateTempo recalculates the possessions of the team in the game, with the minutes and the points of the game from its events,
+// and shares them among the players by their minutes adding the changes to their statistics. The tempo of the team in the season is cached by cacheTempo.
+func updateTempo(ctx context.Context, tx *sql.Tx, stmts preparedStatements, game pairedGame, events []courtEvent) error {
+	rows, err := tx.QueryContext(ctx, selectGameTotalsSQL, game.league, game.gameDate, game.team, game.opponent)
+	if err != nil {
+		return fmt.Errorf("failed to select totals of game %s: %w", game, err)
+	}
+	var team, opponent gameTotals
+	for rows.Next() {
+		var id string
+		var t gameTotals
+		if err := rows.Scan(&id, &t.fieldGoalsAttempted, &t.freeThrowsAttempted, &t.offensiveRebounds, &t.turnovers); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan totals of game %s: %w", game, err)
+		}
+		if id == game.team {
+			team = t
+		} else {
+			opponent = t
+		}
+	}
+	closeIt("rows", rows)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to select totals of game %s: %w", game, err)
+	}
+
+	_, total := newGameOnOff(game.team, events)
+	possessions := gamePossessions(team, opponent)
+	if _, err := tx.ExecContext(ctx, upsertGameTempoSQL, game.league, game.team, game.gameDate, game.season, game.seasonType, game.opponent,
+		possessions, total.Minutes, total.PointsFor, total.PointsAgainst,
+	); err != nil {
+		return fmt.Errorf("failed to upsert tempo of game %s: %w", game, err)
+	}
+
+	return updatePlayersPossessions(ctx, tx, stmts, game, possessions, total.Minutes)
+}
+
+// cacheTempo copies the tempo of the team in the games of the season to Redis
+func cacheTempo(ctx context.Context, tx *sql.Tx, rdb *redis.Client, c seasonCache) error {
+	rows, err := tx.QueryContext(ctx, selectSeasonTempoSQL, c.league, c.id, c.season, c.seasonType)
+	if err != nil {
+		return fmt.Errorf("failed to select tempo of %q in %s season %s: %w", c.id, c.league, c.season, err)
+	}
+	games := []gameTempo{}
+	for rows.Next() {
+		var g gameTempo
+		var gameDate time.Time
+		if err := rows.Scan(&gameDate, &g.Opponent, &g.Possessions, &g.Minutes, &g.PointsFor, &g.PointsAgainst); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan tempo of %q in %s season %s: %w", c.id, c.league, c.season, err)
+		}
+		g.GameDate = gameDate.Format(time.DateOnly)
+		games = append(games, g)
+	}
+	closeIt("rows", rows)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to select tempo of %q in %s season %s: %w", c.id, c.league, c.season, err)
+	}
+
+	valueJSON, err := json.Marshal(struct {
+		Games []gameTempo `json:"games"`
+	}{games})
+	if err != nil {
+		return fmt.Errorf("failed to marshal tempo: %w", err)
+	}
+	key := tempoKey(c.league, c.id, c.season, c.seasonType)
+	if err := rdb.Set(ctx, key, valueJSON, 0).Err(); err != nil {
+		return fmt.Errorf("failed to SET %q key to Redis: %w", key, err)
+	}
+
+	return nil
+}
+
+// possessionsChange is a change of the possessions of the per-game row of a player
+type possessionsChange struct {
+	player     string
+	season     string
+	seasonType seasonType
+	change     float64
+}
+
+// updatePlayersPossessions shares the possessions of the team in the game among its players by their minutes, and adds the changes to their statistics.
+// The aggregates of the players changed are locked together with the team before, as plus-minus changes are.
+func updatePlayersPossessions(ctx context.Context, tx *sql.Tx, stmts preparedStatements, game pairedGame, possessions, minutes float64) error {
+	rows, err := tx.QueryContext(ctx, updatePlayersPossessionsSQL, game.league, game.team, game.gameDate, possessions, minutes)
+	if err != nil {
+		return fmt.Errorf("failed to update possessions of game %s: %w", game, err)
+	}
+	var changes []possessionsChange
+	for rows.Next() {
+		var c possessionsChange
+		if err := rows.Scan(&c.player, &c.season, &c.seasonType, &c.change); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan possessions of game %s: %w", game, err)
+		}
+		changes = append(changes, c)
+	}
+	closeIt("rows", rows)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to update possessions of game %s: %w", game, err)
+	}
+
+	keys := []string{aggregateKey(game.league, subjectTeam, game.team)}
+	for _, c := range changes {
+		keys = append(keys, aggregateKey(game.league, subjectPlayer, c.player))
+	}
+	if err := lockKeys(ctx, tx, stmts, keys...); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		e := event{League: game.league, Player: c.player, Team: game.team}
+		if err := incrementStatistics(ctx, tx, stmts, e, c.season, c.seasonType, false, Statistics{Possessions: c.change}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
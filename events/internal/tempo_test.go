@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"math"
+	"testing"
+)
+
+func TestGamePossessions(t *testing.T) {
+	team := gameTotals{fieldGoalsAttempted: 88, freeThrowsAttempted: 25, offensiveRebounds: 10, turnovers: 14}
+	opponent := gameTotals{fieldGoalsAttempted: 85, freeThrowsAttempted: 20, offensiveRebounds: 8, turnovers: 12}
+
+	// (88 + 11 - 10 + 14 + 85 + 8.8 - 8 + 12) / 2
+	if actual := gamePossessions(team, opponent); math.Abs(actual-100.4) > 1e-9 {
+		t.Errorf("expected 100.4 possessions, got %v", actual)
+	}
+
+	// offensive rebounds of stored games without misses don't make possessions negative
+	if actual := gamePossessions(gameTotals{offensiveRebounds: 3}, gameTotals{}); actual != 0 {
+		t.Errorf("expected 0 possessions, got %v", actual)
+	}
+}
+
+func TestUpdatePlayersPossessions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	incrementStatisticsExpectedPrepares, incrementStatisticsStmts := prepareStatisticsMockStmts(t, db, mock, operationIncrementStatistics)
+	lockKeyExpectedPrepare, lockKeyStmt := prepareMockStmt(t, db, mock, lockKeySQL)
+	stmts := preparedStatements{
+		lockKey:                  lockKeyStmt,
+		forStatisticsByOperation: map[operation]map[table]*sql.Stmt{operationIncrementStatistics: incrementStatisticsStmts},
+	}
+
+	season, seasonType := "2024-25", seasonTypeRegular
+	game := pairedGame{teamGame{defaultLeague, losAngelesLakersID, "2025-03-15"}, bostonCelticsID, season, seasonType}
+
+	mock.ExpectBegin()
+	// only changed rows are returned
+	mock.ExpectQuery(esc(updatePlayersPossessionsSQL)).WithArgs(defaultLeague, losAngelesLakersID, "2025-03-15", 100.0, 48.0).
+		WillReturnRows(sqlmock.NewRows([]string{"player", "season", "season_type", "change"}).AddRow(leBronJamesID, season, seasonType, 73.5))
+
+	// the players changed are locked together with the team, in the order of their keys
+	lockKeyExpectedPrepare.ExpectExec().WithArgs(defaultLeague + ":player:" + leBronJamesID).WillReturnResult(driver.RowsAffected(1))
+	lockKeyExpectedPrepare.ExpectExec().WithArgs(defaultLeague + ":team:" + losAngelesLakersID).WillReturnResult(driver.RowsAffected(1))
+
+	// only the change of the possessions is added to the statistics, without adding a game
+	change := []driver.Value{0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 73.5}
+	incrementStatisticsExpectedPrepares[tablePlayersStatistics].ExpectExec().WithArgs(append([]driver.Value{leBronJamesID, season, seasonType, defaultLeague}, change...)...).WillReturnResult(driver.RowsAffected(1))
+	incrementStatisticsExpectedPrepares[tableTeamsStatistics].ExpectExec().WithArgs(append([]driver.Value{losAngelesLakersID, season, seasonType, defaultLeague}, change...)...).WillReturnResult(driver.RowsAffected(1))
+	incrementStatisticsExpectedPrepares[tablePlayersTeamsStatistics].ExpectExec().WithArgs(append([]driver.Value{leBronJamesID, losAngelesLakersID, season, seasonType, defaultLeague}, change...)...).WillReturnResult(driver.RowsAffected(1))
+
+	tx, err := db.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := updatePlayersPossessions(t.Context(), tx, stmts, game, 100, 48); err != nil {
+		t.Fatalf("failed to update possessions: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,304 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"time"
+	_ "time/tzdata" // timezones are available regardless of the container image
+)
+
+// selectTeamTimezoneSQL is an SQL statement to select IANA timezone of the venue of the team, e.g. "America/Los_Angeles"
+const selectTeamTimezoneSQL = `SELECT COALESCE("timezone", '') FROM "teams" WHERE "id" = $1`
+
+// updateTeamTimezoneSQL is an SQL statement to set IANA timezone of the venue of the team
+const updateTeamTimezoneSQL = `UPDATE "teams" SET "timezone" = $2 WHERE "id" = $1`
+
+// fixEventsGameDatesSQL is an SQL statement to recalculate game dates of all the events in the timezones of their venues.
+// Timestamps are stored in UTC. It returns the players whose events are moved to other dates, with their games moved from and to.
+const fixEventsGameDatesSQL = `UPDATE "events" e 
+SET "game_date" = f."fixed_date"
+FROM (
+	SELECT e."player", e."timestamp", e."game_date", (e."timestamp" AT TIME ZONE 'UTC' AT TIME ZONE COALESCE(t."timezone", 'UTC'))::date AS "fixed_date"
+	FROM "events" e JOIN "teams" t ON t."id" = COALESCE(e."home_team", e."team")
+) f
+WHERE e."player" = f."player" AND e."timestamp" = f."timestamp" AND e."game_date" = f."game_date" AND f."game_date" <> f."fixed_date"
+RETURNING e."league", e."player", e."team", to_char(f."game_date", 'YYYY-MM-DD'), to_char(e."game_date", 'YYYY-MM-DD')`
+
+// moveTeamGameSQL is an SQL statement to move the game of the team to the fixed date, merging it into a game already there,
+// e.g. the parts of a game split at midnight UTC. The merged game keeps the latest watermarks and the state of the later part,
+// and its score and plus-minus are recalculated.
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date moved from in format "2006-01-02"
+// $4: game date moved to in format "2006-01-02"
+// $5: season of the game date moved to
+// $6: season type of the game date moved to
+const moveTeamGameSQL = `INSERT INTO "team_games" AS g ("league", "team", "game_date", "finalized_at", "purged_at", "archive", "archived_events",
+	"latest_event_at", "watermark", "last_received_at", "ends_at", "settled_at", "late_events", "state", "period", "started_at",
+	"home_team", "opponent", "season", "season_type")
+SELECT "league", "team", $4, "finalized_at", "purged_at", "archive", "archived_events",
+	"latest_event_at", "watermark", "last_received_at", "ends_at", "settled_at", "late_events", "state", "period", "started_at",
+	"home_team", "opponent", $5, $6
+FROM "team_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+ON CONFLICT ("league", "team", "game_date") DO UPDATE SET
+	"finalized_at" = GREATEST(g."finalized_at", EXCLUDED."finalized_at"),
+	"purged_at" = COALESCE(g."purged_at", EXCLUDED."purged_at"),
+	"archive" = COALESCE(g."archive", EXCLUDED."archive"),
+	"archived_events" = COALESCE(g."archived_events", EXCLUDED."archived_events"),
+	"latest_event_at" = GREATEST(g."latest_event_at", EXCLUDED."latest_event_at"),
+	"watermark" = GREATEST(g."watermark", EXCLUDED."watermark"),
+	"last_received_at" = GREATEST(g."last_received_at", EXCLUDED."last_received_at"),
+	"ends_at" = GREATEST(g."ends_at", EXCLUDED."ends_at"),
+	"settled_at" = GREATEST(g."settled_at", EXCLUDED."settled_at"),
+	"late_events" = g."late_events" + EXCLUDED."late_events",
+	"state" = CASE WHEN EXCLUDED."latest_event_at" > g."latest_event_at" THEN EXCLUDED."state" ELSE g."state" END,
+	"period" = GREATEST(g."period", EXCLUDED."period"),
+	"started_at" = LEAST(g."started_at", EXCLUDED."started_at"),
+	"home_team" = COALESCE(g."home_team", EXCLUDED."home_team"),
+	"opponent" = COALESCE(g."opponent", EXCLUDED."opponent"),
+	"season" = EXCLUDED."season", "season_type" = EXCLUDED."season_type",
+	"processed" = false, "plus_minus_processed" = false`
+
+// SQL statements to move the periods and the late events of the game of the team to the fixed date, with the same parameters.
+// Late events follow their events, and all of them the game left without events.
+const (
+	moveGamePeriodsSQL = `INSERT INTO "game_periods" ("league", "team", "game_date", "period", "started_at", "ended_at")
+SELECT "league", "team", $4, "period", "started_at", "ended_at" FROM "game_periods" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+ON CONFLICT ("league", "team", "game_date", "period") DO NOTHING`
+	moveLateEventsSQL = `UPDATE "late_events" l SET "game_date" = $4
+WHERE l."league" = $1 AND l."team" = $2 AND l."game_date" = $3 AND (
+	NOT EXISTS (SELECT 1 FROM "events" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3)
+	OR EXISTS (SELECT 1 FROM "events" e WHERE e."player" = l."player" AND e."timestamp" = l."timestamp" AND e."game_date" = $4)
+)`
+)
+
+// selectGameHasEventsSQL is an SQL statement to check whether the game of the team has events left after the game dates are fixed
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+const selectGameHasEventsSQL = `SELECT EXISTS (SELECT 1 FROM "events" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3)`
+
+// deleteVacatedGameSQL is an SQL statement to delete the game of the team left without events along with its rows keyed by the date,
+// with the same parameters. The on/off statistics of its players are recalculated with the game they moved to,
+// so it returns the players of the deleted on/off rows to cache them again.
+const deleteVacatedGameSQL = `WITH "periods" AS (
+	DELETE FROM "game_periods" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+), "scores" AS (
+	DELETE FROM "team_scores" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+), "tempo" AS (
+	DELETE FROM "tempo_by_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+), "lineups" AS (
+	DELETE FROM "lineups_by_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+), "game" AS (
+	DELETE FROM "team_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+)
+DELETE FROM "on_off_by_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3 RETURNING "player"`
+
+// gameMove is a game of the team whose events are moved to another date by fixing game dates
+type gameMove struct {
+	from teamGame
+	to   string
+}
+
+// backward reports whether the game is moved to an earlier date
+func (m gameMove) backward() bool {
+	return m.to < m.from.gameDate
+}
+
+// venueLocation returns the location of the venue of the team, or UTC if the timezone of the team is unknown
+func venueLocation(ctx context.Context, tx *sql.Tx, stmts preparedStatements, team string) (*time.Location, error) {
+	var timezone string
+	if err := tx.StmtContext(ctx, stmts.selectTeamTimezone).QueryRowContext(ctx, team).Scan(&timezone); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to select timezone of team %q: %w", team, err)
+	}
+
+	if timezone == "" {
+		return time.UTC, nil
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone %q of team %q: %w", timezone, team, err)
+	}
+
+	return location, nil
+}
+
+// fixGameDates recalculates game dates of historical events in the timezones of their venues,
+// moves the games keyed by the dates along with them, and rebuilds the per-game rows and the statistics of the affected players
+func fixGameDates(ctx context.Context, cfg config, db *sql.DB, stmts preparedStatements) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, fixEventsGameDatesSQL)
+	if err != nil {
+		return fmt.Errorf("failed to fix game dates of events: %w", err)
+	}
+
+	var moved int
+	affected := map[string]bool{}
+	movedGames := map[gameMove]bool{}
+	for rows.Next() {
+		var player string
+		var m gameMove
+		if err = rows.Scan(&m.from.league, &player, &m.from.team, &m.from.gameDate, &m.to); err != nil {
+			closeIt("rows", rows)
+			return fmt.Errorf("failed to scan player: %w", err)
+		}
+		affected[player] = true
+		movedGames[m] = true
+		moved++
+	}
+	closeIt("rows", rows)
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to fix game dates of events: %w", err)
+	}
+	log.Println(fmt.Sprintf("Game dates of %d events of %d players in %d games are fixed", moved, len(affected), len(movedGames)))
+
+	if err = moveGames(ctx, tx, cfg, stmts, slices.Collect(maps.Keys(movedGames))); err != nil {
+		return err
+	}
+
+	players := make([]string, 0, len(affected))
+	for player := range affected {
+		players = append(players, player)
+	}
+
+	if err = rebuildPlayers(ctx, tx, cfg, stmts, players); err != nil {
+		return fmt.Errorf("failed to rebuild players: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// moveGames moves the games of the teams to the fixed dates of their events. Games moved to earlier dates are moved from the earliest,
+// and the ones moved to later dates from the latest, so a game is vacated before the next one of the team moves to its date.
+// Scores of the games are recalculated from the moved events, and their plus-minus, lineups, on/off statistics and tempo
+// are recalculated as unprocessed, while the ones of the vacated games are deleted.
+func moveGames(ctx context.Context, tx *sql.Tx, cfg config, stmts preparedStatements, moves []gameMove) error {
+	slices.SortFunc(moves, func(a, b gameMove) int {
+		switch {
+		case a.backward() != b.backward():
+			if a.backward() {
+				return -1
+			}
+			return 1
+		case a.backward():
+			return cmp.Or(cmp.Compare(a.from.gameDate, b.from.gameDate), cmp.Compare(a.from.league, b.from.league), cmp.Compare(a.from.team, b.from.team))
+		default:
+			return cmp.Or(cmp.Compare(b.from.gameDate, a.from.gameDate), cmp.Compare(a.from.league, b.from.league), cmp.Compare(a.from.team, b.from.team))
+		}
+	})
+
+	for _, m := range moves {
+		league, ok := cfg.leagues[m.from.league]
+		if !ok {
+			return fmt.Errorf("unknown league %q of game %s", m.from.league, m.from)
+		}
+		from, err := time.Parse(time.DateOnly, m.from.gameDate)
+		if err != nil {
+			return fmt.Errorf("failed to parse game date %q: %w", m.from.gameDate, err)
+		}
+		to, err := time.Parse(time.DateOnly, m.to)
+		if err != nil {
+			return fmt.Errorf("failed to parse game date %q: %w", m.to, err)
+		}
+		season := league.season(to)
+		seasonType := league.Calendar.seasonType(season, m.to)
+
+		args := []any{m.from.league, m.from.team, m.from.gameDate, m.to}
+		if _, err := tx.ExecContext(ctx, moveTeamGameSQL, append(args, season, seasonType)...); err != nil {
+			return fmt.Errorf("failed to move game %s to %s: %w", m.from, m.to, err)
+		}
+		if _, err := tx.ExecContext(ctx, moveGamePeriodsSQL, args...); err != nil {
+			return fmt.Errorf("failed to move periods of game %s to %s: %w", m.from, m.to, err)
+		}
+		if _, err := tx.ExecContext(ctx, moveLateEventsSQL, args...); err != nil {
+			return fmt.Errorf("failed to move late events of game %s to %s: %w", m.from, m.to, err)
+		}
+
+		game := teamGame{m.from.league, m.from.team, m.to}
+		if err := updateScore(ctx, tx, stmts, game); err != nil {
+			return err
+		}
+
+		var hasEvents bool
+		if err := tx.QueryRowContext(ctx, selectGameHasEventsSQL, m.from.league, m.from.team, m.from.gameDate).Scan(&hasEvents); err != nil {
+			return fmt.Errorf("failed to select events of game %s: %w", m.from, err)
+		}
+		if hasEvents {
+			// the game keeps the events of its other date, e.g. a game of the next day, so it's recalculated without the moved ones
+			if err := updateScore(ctx, tx, stmts, m.from); err != nil {
+				return err
+			}
+			if err := markPlusMinusUnprocessed(ctx, tx, stmts, m.from); err != nil {
+				return err
+			}
+			continue
+		}
+
+		players, err := deleteVacatedGame(ctx, tx, m.from)
+		if err != nil {
+			return err
+		}
+		// the season caches of the vacated game are cached again without it, as the game moved to may be in another season
+		previousSeason := league.season(from)
+		previousSeasonType := league.Calendar.seasonType(previousSeason, m.from.gameDate)
+		caches := []seasonCache{{m.from.league, subjectTeam, m.from.team, previousSeason, previousSeasonType}}
+		for _, player := range players {
+			caches = append(caches, seasonCache{m.from.league, subjectPlayer, player, previousSeason, previousSeasonType})
+		}
+		for _, c := range caches {
+			if err := markSeasonCacheUnprocessed(ctx, tx, c); err != nil {
+				return err
+			}
+		}
+	}
+	log.Println(fmt.Sprintf("Moved %d games to the fixed dates of their events", len(moves)))
+
+	return nil
+}
+
+// deleteVacatedGame deletes the game left without events by fixing game dates, returning the players of its on/off statistics
+func deleteVacatedGame(ctx context.Context, tx *sql.Tx, game teamGame) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, deleteVacatedGameSQL, game.league, game.team, game.gameDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete vacated game %s: %w", game, err)
+	}
+	defer closeIt("rows", rows)
+
+	var players []string
+	for rows.Next() {
+		var player string
+		if err := rows.Scan(&player); err != nil {
+			return nil, fmt.Errorf("failed to scan on/off statistics of game %s: %w", game, err)
+		}
+		players = append(players, player)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to delete vacated game %s: %w", game, err)
+	}
+
+	return players, nil
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMoveGames(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	deleteScoresExpectedPrepare, deleteScoresStmt := prepareMockStmt(t, db, mock, deleteTeamScoresSQL)
+	insertScoresExpectedPrepare, insertScoresStmt := prepareMockStmt(t, db, mock, insertTeamScoresSQL)
+	plusMinusExpectedPrepare, plusMinusStmt := prepareMockStmt(t, db, mock, markPlusMinusUnprocessedSQL)
+	stmts := preparedStatements{
+		deleteTeamScores:         deleteScoresStmt,
+		insertTeamScores:         insertScoresStmt,
+		markPlusMinusUnprocessed: plusMinusStmt,
+	}
+
+	season, seasonType := "2024-25", seasonTypeRegular
+	// the tail of the game of March 15th stored in UTC moves back to it, and so does the one of the back-to-back game of March 16th
+	moves := []gameMove{
+		{teamGame{defaultLeague, losAngelesLakersID, "2025-03-17"}, "2025-03-16"},
+		{teamGame{defaultLeague, losAngelesLakersID, "2025-03-16"}, "2025-03-15"},
+	}
+
+	mock.ExpectBegin()
+	// the game of March 16th is vacated before the next one moves to its date
+	args := []driver.Value{defaultLeague, losAngelesLakersID, "2025-03-16", "2025-03-15"}
+	mock.ExpectExec(esc(moveTeamGameSQL)).WithArgs(append(args, season, seasonType)...).WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectExec(esc(moveGamePeriodsSQL)).WithArgs(args...).WillReturnResult(driver.RowsAffected(0))
+	mock.ExpectExec(esc(moveLateEventsSQL)).WithArgs(args...).WillReturnResult(driver.RowsAffected(0))
+	deleteScoresExpectedPrepare.ExpectExec().WithArgs(defaultLeague, losAngelesLakersID, "2025-03-15").WillReturnResult(driver.RowsAffected(1))
+	insertScoresExpectedPrepare.ExpectExec().WithArgs(defaultLeague, losAngelesLakersID, "2025-03-15").WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectQuery(esc(selectGameHasEventsSQL)).WithArgs(defaultLeague, losAngelesLakersID, "2025-03-16").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(esc(deleteVacatedGameSQL)).WithArgs(defaultLeague, losAngelesLakersID, "2025-03-16").
+		WillReturnRows(sqlmock.NewRows([]string{"player"}).AddRow(leBronJamesID))
+	mock.ExpectExec(esc(markSeasonCacheUnprocessedSQL)).WithArgs(defaultLeague, subjectTeam, losAngelesLakersID, season, seasonType).WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectExec(esc(markSeasonCacheUnprocessedSQL)).WithArgs(defaultLeague, subjectPlayer, leBronJamesID, season, seasonType).WillReturnResult(driver.RowsAffected(1))
+
+	// the game of March 17th keeps its other events, so it's recalculated without the moved ones
+	args = []driver.Value{defaultLeague, losAngelesLakersID, "2025-03-17", "2025-03-16"}
+	mock.ExpectExec(esc(moveTeamGameSQL)).WithArgs(append(args, season, seasonType)...).WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectExec(esc(moveGamePeriodsSQL)).WithArgs(args...).WillReturnResult(driver.RowsAffected(0))
+	mock.ExpectExec(esc(moveLateEventsSQL)).WithArgs(args...).WillReturnResult(driver.RowsAffected(0))
+	deleteScoresExpectedPrepare.ExpectExec().WithArgs(defaultLeague, losAngelesLakersID, "2025-03-16").WillReturnResult(driver.RowsAffected(1))
+	insertScoresExpectedPrepare.ExpectExec().WithArgs(defaultLeague, losAngelesLakersID, "2025-03-16").WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectQuery(esc(selectGameHasEventsSQL)).WithArgs(defaultLeague, losAngelesLakersID, "2025-03-17").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	deleteScoresExpectedPrepare.ExpectExec().WithArgs(defaultLeague, losAngelesLakersID, "2025-03-17").WillReturnResult(driver.RowsAffected(1))
+	insertScoresExpectedPrepare.ExpectExec().WithArgs(defaultLeague, losAngelesLakersID, "2025-03-17").WillReturnResult(driver.RowsAffected(1))
+	plusMinusExpectedPrepare.ExpectExec().WithArgs(defaultLeague, losAngelesLakersID, "2025-03-17").WillReturnResult(driver.RowsAffected(1))
+
+	tx, err := db.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := moveGames(t.Context(), tx, config{leagues: defaultLeagues}, stmts, moves); err != nil {
+		t.Fatalf("failed to move games: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
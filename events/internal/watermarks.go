@@ -0,0 +1,273 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"net/http"
+	"time"
+)
+
+// defaultLatenessTolerance is how far events of a game may arrive out of order, unless LATENESS_TOLERANCE environment variable is specified
+const defaultLatenessTolerance = 5 * time.Minute
+
+// settleInterval is the interval of settling games, i.e. of checking whether their watermarks passed their ends
+const settleInterval = time.Minute
+
+const tableLateEvents table = "late_events"
+
+// upsertTeamGameWatermarkSQL is an SQL statement to advance the watermark of the game after its event,
+// and to select whether the event is late, i.e. behind the previous watermark, when the game was finalized, if it was, and the state of the game.
+// The watermark trails the latest event time seen by the lateness tolerance, so events within the tolerance are in order.
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+// $4: timestamp of the event
+// $5: lateness tolerance in seconds
+const upsertTeamGameWatermarkSQL = `WITH "previous" AS (
+	SELECT "watermark", "finalized_at", "state" FROM "team_games" WHERE "league" = $1 AND "team" = $2 AND "game_date" = $3
+), "upserted" AS (
+	INSERT INTO "team_games" AS g ("league", "team", "game_date", "latest_event_at", "watermark", "last_received_at")
+	VALUES ($1, $2, $3, CAST($4 AS timestamp), CAST($4 AS timestamp) - CAST($5 AS float8) * INTERVAL '1 second', NOW() AT TIME ZONE 'UTC')
+	ON CONFLICT ("league", "team", "game_date") DO UPDATE SET
+		"latest_event_at" = GREATEST(g."latest_event_at", EXCLUDED."latest_event_at"),
+		"watermark" = GREATEST(g."watermark", EXCLUDED."watermark"),
+		"last_received_at" = EXCLUDED."last_received_at",
+		"late_events" = g."late_events" + CASE WHEN EXCLUDED."latest_event_at" < g."watermark" THEN 1 ELSE 0 END,
+		"settled_at" = NULL
+)
+SELECT COALESCE(CAST($4 AS timestamp) < (SELECT "watermark" FROM "previous"), false), (SELECT "finalized_at" FROM "previous"),
+	COALESCE((SELECT "state" FROM "previous"), 'scheduled')`
+
+// insertLateEventSQL is an SQL statement to record the event arrived after finalization of its game
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+// $4: player
+// $5: timestamp of the event
+// $6: event type
+// $7: time the game was finalized
+const insertLateEventSQL = `INSERT INTO "late_events" ("league", "team", "game_date", "player", "timestamp", "event", "finalized_at") VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+// settleTeamGamesSQL is an SQL statement to settle the ended games the watermarks passed the ends of, and to select them.
+// A game without events for the lateness tolerance given by $1 in seconds is idle, so its watermark advances to the latest event time.
+const settleTeamGamesSQL = `UPDATE "team_games" SET
+	"watermark" = GREATEST("watermark", "latest_event_at"),
+	"settled_at" = NOW() AT TIME ZONE 'UTC'
+WHERE "settled_at" IS NULL AND "ends_at" IS NOT NULL AND GREATEST("watermark", CASE
+	WHEN "last_received_at" IS NULL OR "last_received_at" <= NOW() AT TIME ZONE 'UTC' - CAST($1 AS float8) * INTERVAL '1 second' THEN "latest_event_at"
+END) >= "ends_at"
+RETURNING "league", "team", "game_date"`
+
+// markGameStatisticsUnprocessedSQLs are SQL statements to mark statistics the game contributes to unprocessed, so they are cached again.
+// Parameter placeholders are intended for:
+// $1: league
+// $2: team
+// $3: game date in format "2006-01-02"
+var markGameStatisticsUnprocessedSQLs = map[table]string{
+	tablePlayersStatistics: `UPDATE "players_statistics" s SET "processed" = false FROM "players_by_games" p
+WHERE p."league" = $1 AND p."team" = $2 AND p."game_date" = $3
+AND s."league" = p."league" AND s."player" = p."player" AND s."season" = p."season" AND s."season_type" = p."season_type"`,
+	tableTeamsStatistics: `UPDATE "teams_statistics" s SET "processed" = false FROM "players_by_games" p
+WHERE p."league" = $1 AND p."team" = $2 AND p."game_date" = $3
+AND s."league" = p."league" AND s."team" = p."team" AND s."season" = p."season" AND s."season_type" = p."season_type"`,
+	tablePlayersTeamsStatistics: `UPDATE "players_teams_statistics" s SET "processed" = false FROM "players_by_games" p
+WHERE p."league" = $1 AND p."team" = $2 AND p."game_date" = $3
+AND s."league" = p."league" AND s."player" = p."player" AND s."team" = p."team" AND s."season" = p."season" AND s."season_type" = p."season_type"`,
+}
+
+// provisionalSQL is the beginning of an SQL expression of whether statistics "s" are provisional, i.e. whether any of their games is not settled.
+// It's completed by the condition on the key of the player or the team, and the closing parenthesis.
+const provisionalSQL = `EXISTS (
+	SELECT 1 FROM "players_by_games" p
+	LEFT JOIN "team_games" g ON g."league" = p."league" AND g."team" = p."team" AND g."game_date" = p."game_date"
+	WHERE g."settled_at" IS NULL AND p."league" = s."league" AND p."season" = s."season" AND p."season_type" = s."season_type"`
+
+// selectLateEventsSQL is an SQL statement to list events arrived after finalization of their games in the order of arrival
+// Parameter placeholders are intended for:
+// $1: league
+// $2: the first game date in format "2006-01-02"
+// $3: the last game date in format "2006-01-02"
+const selectLateEventsSQL = `SELECT "team", "game_date", "player", "timestamp", "event", "finalized_at", "received_at" FROM "late_events"
+WHERE "league" = $1 AND "game_date" BETWEEN $2 AND $3
+ORDER BY "received_at", "id"`
+
+// lateEvent is an event arrived after finalization of its game
+type lateEvent struct {
+	Team        string    `json:"team"`
+	GameDate    string    `json:"gameDate"`
+	Player      string    `json:"player"`
+	Timestamp   time.Time `json:"timestamp"`
+	Event       eventType `json:"event"`
+	FinalizedAt time.Time `json:"finalizedAt"`
+	ReceivedAt  time.Time `json:"receivedAt"`
+}
+
+// updateWatermark advances the watermark of the game after its event, counts the event late if it's behind the watermark,
+// and records it if it arrived after the game was finalized. The game is unsettled again, so its statistics are provisional.
+// The state of the game is returned.
+func updateWatermark(ctx context.Context, tx *sql.Tx, stmts preparedStatements, cfg config, e event, gameDate string) (gameState, error) {
+	var late bool
+	var finalizedAt sql.NullTime
+	var state gameState
+	if err := tx.StmtContext(ctx, stmts.upsertTeamGameWatermark).QueryRowContext(ctx, e.League, e.Team, gameDate, e.Timestamp, cfg.latenessTolerance.Seconds()).
+		Scan(&late, &finalizedAt, &state); err != nil {
+		return "", fmt.Errorf("failed to update watermark of game %s: %w", teamGame{e.League, e.Team, gameDate}, err)
+	}
+
+	if late {
+		log.Println(fmt.Sprintf("Event %q is behind the watermark of game %s", e, teamGame{e.League, e.Team, gameDate}))
+	}
+
+	if finalizedAt.Valid {
+		if _, err := tx.ExecContext(ctx, insertLateEventSQL, e.League, e.Team, gameDate, e.Player, e.Timestamp, e.Event, finalizedAt.Time); err != nil {
+			return "", fmt.Errorf("failed to record late event %q: %w", e, err)
+		}
+		log.Println(fmt.Sprintf("Event %q arrived after game %s was finalized at %s", e, teamGame{e.League, e.Team, gameDate}, finalizedAt.Time.Format(time.RFC3339)))
+	}
+
+	return state, nil
+}
+
+// settleGamesPeriodically settles games the watermarks passed the ends of, and caches their statistics as complete, until the context is done
+func settleGamesPeriodically(ctx context.Context, cfg config, db *sql.DB, stmts preparedStatements, rdb *redis.Client) {
+	ticker := time.NewTicker(settleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			games, err := settleGames(ctx, cfg, db)
+			if err != nil {
+				log.Println(fmt.Errorf("failed to settle games: %w", err))
+				continue
+			}
+			if len(games) == 0 {
+				continue
+			}
+			log.Println(fmt.Sprintf("%d games settled: %s", len(games), games))
+
+			if err := updateCaches(ctx, db, stmts, rdb); err != nil {
+				log.Println(fmt.Errorf("failed to update caches of settled games: %w", err))
+			}
+		}
+	}
+}
+
+// settleGames settles games the watermarks passed the ends of, marks the statistics they contribute to unprocessed, and returns them
+func settleGames(ctx context.Context, cfg config, db *sql.DB) (games []teamGame, err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, settleTeamGamesSQL, cfg.latenessTolerance.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to settle games: %w", err)
+	}
+	for rows.Next() {
+		var g teamGame
+		var gameDate time.Time
+		if err = rows.Scan(&g.league, &g.team, &gameDate); err != nil {
+			closeIt("rows", rows)
+			return nil, fmt.Errorf("failed to scan settled game: %w", err)
+		}
+		g.gameDate = gameDate.Format(time.DateOnly)
+		games = append(games, g)
+	}
+	closeIt("rows", rows)
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to settle games: %w", err)
+	}
+
+	for _, g := range games {
+		for _, table := range statisticsTables {
+			if _, err = tx.ExecContext(ctx, markGameStatisticsUnprocessedSQLs[table], g.league, g.team, g.gameDate); err != nil {
+				return nil, fmt.Errorf("failed to mark %q of game %s unprocessed: %w", table, g, err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return games, nil
+}
+
+// lateEventsHandler responds events of the league arrived after finalization of their games, optionally of games between ?from= and ?to= dates
+func lateEventsHandler(ctx context.Context, cfg config, db *sql.DB) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		league := r.URL.Query().Get("league")
+		if league == "" {
+			league = defaultLeague
+		}
+		if _, ok := cfg.leagues[league]; !ok {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("unknown 'league': %q", league))
+			return
+		}
+
+		from, to := "0001-01-01", "9999-12-31"
+		for name, date := range map[string]*string{"from": &from, "to": &to} {
+			s := r.URL.Query().Get(name)
+			if s == "" {
+				continue
+			}
+			if _, err := time.Parse(time.DateOnly, s); err != nil {
+				respondError(w, http.StatusBadRequest, fmt.Errorf("invalid '%s' date: %w", name, err))
+				return
+			}
+			*date = s
+		}
+
+		lateEvents, err := selectLateEvents(ctx, db, league, from, to)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(lateEvents); err != nil {
+			log.Println(fmt.Errorf("failed to write late events: %w", err))
+		}
+	}
+}
+
+func selectLateEvents(ctx context.Context, db *sql.DB, league, from, to string) ([]lateEvent, error) {
+	rows, err := db.QueryContext(ctx, selectLateEventsSQL, league, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select late events: %w", err)
+	}
+	defer closeIt("rows", rows)
+
+	lateEvents := []lateEvent{}
+	for rows.Next() {
+		var e lateEvent
+		var gameDate time.Time
+		if err := rows.Scan(&e.Team, &gameDate, &e.Player, &e.Timestamp, &e.Event, &e.FinalizedAt, &e.ReceivedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan late event: %w", err)
+		}
+		e.GameDate = gameDate.Format(time.DateOnly)
+		lateEvents = append(lateEvents, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate late events: %w", err)
+	}
+
+	return lateEvents, nil
+}
@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUpdateWatermark_AfterFinalization(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	watermarkExpectedPrepare, watermarkStmt := prepareMockStmt(t, db, mock, upsertTeamGameWatermarkSQL)
+	stmts := preparedStatements{upsertTeamGameWatermark: watermarkStmt}
+
+	e := event{Player: leBronJamesID, Team: losAngelesLakersID, Timestamp: time.Date(2025, time.March, 15, 21, 30, 0, 0, time.UTC), Event: eventRebound, League: defaultLeague}
+	finalizedAt := time.Date(2025, time.March, 15, 22, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	watermarkExpectedPrepare.ExpectQuery().WithArgs(e.League, e.Team, "2025-03-15", e.Timestamp, (5 * time.Minute).Seconds()).
+		WillReturnRows(sqlmock.NewRows([]string{"late", "finalized_at", "state"}).AddRow(true, finalizedAt, gameStateFinal))
+	mock.ExpectExec(`INSERT INTO "late_events"`).WithArgs(e.League, e.Team, "2025-03-15", e.Player, e.Timestamp, e.Event, finalizedAt).
+		WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectCommit()
+
+	tx, err := db.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := updateWatermark(t.Context(), tx, stmts, config{latenessTolerance: 5 * time.Minute}, e, "2025-03-15")
+	if err != nil {
+		t.Fatalf("failed to update watermark: %v", err)
+	}
+	if state != gameStateFinal {
+		t.Errorf("expected %q state of the game, got %q", gameStateFinal, state)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestSettleGames(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	gameDate := time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE "team_games" SET`).WithArgs(60.0).
+		WillReturnRows(sqlmock.NewRows([]string{"league", "team", "game_date"}).AddRow(defaultLeague, losAngelesLakersID, gameDate))
+	for _, table := range statisticsTables {
+		mock.ExpectExec(esc(markGameStatisticsUnprocessedSQLs[table])).WithArgs(defaultLeague, losAngelesLakersID, "2025-03-15").WillReturnResult(driver.RowsAffected(2))
+	}
+	mock.ExpectCommit()
+
+	games, err := settleGames(t.Context(), config{latenessTolerance: time.Minute}, db)
+	if err != nil {
+		t.Fatalf("failed to settle games: %v", err)
+	}
+	if expected := (teamGame{defaultLeague, losAngelesLakersID, "2025-03-15"}); len(games) != 1 || games[0] != expected {
+		t.Errorf("expected settled game %s, got %v", expected, games)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
+
+func TestLateEventsHandler(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock: %v", err)
+	}
+	defer closeIt("DB", db)
+
+	timestamp := time.Date(2025, time.March, 15, 21, 30, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT "team", "game_date"`).WithArgs(defaultLeague, "2025-03-01", "9999-12-31").
+		WillReturnRows(sqlmock.NewRows([]string{"team", "game_date", "player", "timestamp", "event", "finalized_at", "received_at"}).
+			AddRow(losAngelesLakersID, time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC), leBronJamesID, timestamp, "rebound", timestamp.Add(time.Hour), timestamp.Add(2*time.Hour)))
+
+	handler := lateEventsHandler(t.Context(), config{leagues: defaultLeagues}, db)
+	for query, expectedStatusCode := range map[string]int{"?from=2025-03-01": http.StatusOK, "?from=March": http.StatusBadRequest, "?league=xyz": http.StatusBadRequest} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports/late-events"+query, nil))
+
+		if w.Code != expectedStatusCode {
+			t.Errorf("expected status code %d for %q, got %d", expectedStatusCode, query, w.Code)
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var lateEvents []lateEvent
+		if err := json.NewDecoder(w.Body).Decode(&lateEvents); err != nil {
+			t.Fatalf("failed to decode late events: %v", err)
+		}
+		if len(lateEvents) != 1 || lateEvents[0].GameDate != "2025-03-15" || lateEvents[0].Player != leBronJamesID || lateEvents[0].Event != eventRebound {
+			t.Errorf("unexpected late events %+v", lateEvents)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %v", err)
+	}
+}
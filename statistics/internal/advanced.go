@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+)
+
+// metricsAdvanced is the value of 'metrics' query parameter adding advanced metrics to statistics
+const metricsAdvanced = "advanced"
+
+// rateMinutes is the length of playing time per-minute rates of advanced metrics are normalized to
+const rateMinutes = 36
+
+// rates are the per-game statistics normalized to 36 minutes played or to 100 possessions
+type rates struct {
+	Points    float64 `json:"points"`
+	Rebounds  float64 `json:"rebounds"`
+	Assists   float64 `json:"assists"`
+	Steals    float64 `json:"steals"`
+	Blocks    float64 `json:"blocks"`
+	Fouls     float64 `json:"fouls"`
+	Turnovers float64 `json:"turnovers"`
+	PlusMinus float64 `json:"plusMinus"`
+}
+
+// advanced are the shooting and the ball handling metrics derived from Statistics of a player or a team
+type advanced struct {
+	TrueShooting       float64 `json:"trueShooting"`       // 0 without attempts
+	EffectiveFieldGoal float64 `json:"effectiveFieldGoal"` // 0 without field goal attempts
+	AssistToTurnover   float64 `json:"assistToTurnover"`   // 0 without turnovers
+}
+
+// playerAdvanced are the metrics derived from Statistics of a player
+type playerAdvanced struct {
+	advanced
+	GameScore  float64 `json:"gameScore"`
+	Efficiency float64 `json:"efficiency"`
+	Per36      rates   `json:"per36"`
+	Per100     rates   `json:"per100"` // per 100 possessions of the team with the player on the court
+}
+
+// teamAdvanced are the metrics derived from Statistics of a team
+type teamAdvanced struct {
+	advanced
+	Per36  rates `json:"per36"`  // per 36 minutes of the team on the court
+	Per100 rates `json:"per100"` // per 100 possessions of the team
+}
+
+// advancedStatistics are Statistics of a player together with their advanced metrics
+type advancedStatistics struct {
+	Statistics
+	Advanced playerAdvanced `json:"advanced"`
+}
+
+// parseMetrics parses optional 'metrics' query parameter, and reports whether advanced metrics are requested
+func parseMetrics(r *http.Request) (bool, error) {
+	switch metrics := r.URL.Query().Get("metrics"); metrics {
+	case "":
+		return false, nil
+	case metricsAdvanced:
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid 'metrics' parameter %q, %q expected", metrics, metricsAdvanced)
+	}
+}
+
+// round returns the value rounded to the decimals
+func round(value float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	return math.Round(value*scale) / scale
+}
+
+// newAdvanced derives the shooting and the ball handling metrics of the statistics.
+// Per-game averages are over the same games, so their ratios are the ratios of the season sums.
+func newAdvanced(s Statistics) advanced {
+	var a advanced
+	if attempts := s.FieldGoalsAttempted + 0.44*s.FreeThrowsAttempted; attempts > 0 {
+		a.TrueShooting = round(s.Points/(2*attempts), 3)
+	}
+	if s.FieldGoalsAttempted > 0 {
+		a.EffectiveFieldGoal = round((s.FieldGoalsMade+0.5*s.ThreePointersMade)/s.FieldGoalsAttempted, 3)
+	}
+	if s.Turnovers > 0 {
+		a.AssistToTurnover = round(s.Assists/s.Turnovers, 2)
+	}
+	return a
+}
+
+// newPlayerAdvanced derives the advanced metrics of the statistics of a player:
+// game score and efficiency per game, and the statistics per 36 minutes played and per 100 possessions
+func newPlayerAdvanced(s Statistics) playerAdvanced {
+	a := playerAdvanced{advanced: newAdvanced(s)}
+
+	defensiveRebounds := s.Rebounds - s.OffensiveRebounds
+	a.GameScore = round(s.Points+0.4*s.FieldGoalsMade-0.7*s.FieldGoalsAttempted-0.4*(s.FreeThrowsAttempted-s.FreeThrowsMade)+
+		0.7*s.OffensiveRebounds+0.3*defensiveRebounds+s.Steals+0.7*s.Assists+0.7*s.Blocks-0.4*s.Fouls-s.Turnovers, 1)
+	a.Efficiency = round(s.Points+s.Rebounds+s.Assists+s.Steals+s.Blocks-
+		(s.FieldGoalsAttempted-s.FieldGoalsMade)-(s.FreeThrowsAttempted-s.FreeThrowsMade)-s.Turnovers, 1)
+
+	a.Per36 = newRates(s, rateMinutes, s.MinutesPlayed)
+	a.Per100 = newRates(s, ratingPossessions, s.Possessions)
+
+	return a
+}
+
+// newTeamAdvanced derives the advanced metrics of the statistics of a team with its tempo: the statistics per 36 minutes and per 100 possessions of the team.
+// Statistics of teams are averages of the per-game rows of their players, so the minutes of the team per row are the minutes played
+// shared by the players on the court, and its possessions per row are those of its minutes at the pace of its games.
+func newTeamAdvanced(s Statistics, t *tempo) teamAdvanced {
+	a := teamAdvanced{advanced: newAdvanced(s)}
+
+	minutes := s.MinutesPlayed / playersOnCourt
+	a.Per36 = newRates(s, rateMinutes, minutes)
+	if possessions, gameMinutes := t.sums(); gameMinutes > 0 {
+		a.Per100 = newRates(s, ratingPossessions, minutes*possessions/gameMinutes)
+	}
+
+	return a
+}
+
+// newRates returns the per-game statistics normalized to the length of the per-game basis, e.g. 36 of the minutes played
+func newRates(s Statistics, length, basis float64) rates {
+	if basis <= 0 {
+		return rates{}
+	}
+	rate := func(value float64) float64 {
+		return round(value*length/basis, 1)
+	}
+	return rates{
+		Points:    rate(s.Points),
+		Rebounds:  rate(s.Rebounds),
+		Assists:   rate(s.Assists),
+		Steals:    rate(s.Steals),
+		Blocks:    rate(s.Blocks),
+		Fouls:     rate(s.Fouls),
+		Turnovers: rate(s.Turnovers),
+		PlusMinus: rate(s.PlusMinus),
+	}
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewAdvanced(t *testing.T) {
+	s := Statistics{Points: 25, Rebounds: 8, Assists: 7.5, Steals: 1, Blocks: 0.5, Fouls: 2, Turnovers: 3, MinutesPlayed: 35, PlusMinus: 4,
+		FieldGoalsMade: 9, FieldGoalsAttempted: 18, ThreePointersMade: 2, FreeThrowsMade: 5, FreeThrowsAttempted: 6, OffensiveRebounds: 1.5, Possessions: 70}
+	expected := advanced{TrueShooting: 0.606, EffectiveFieldGoal: 0.556, AssistToTurnover: 2.5}
+	if a := newAdvanced(s); a != expected {
+		t.Errorf("expected %+v, got %+v", expected, a)
+	}
+
+	if a := newAdvanced(Statistics{Assists: 1}); a != (advanced{}) {
+		t.Errorf("expected no advanced metrics without attempts and turnovers, got %+v", a)
+	}
+}
+
+func TestNewPlayerAdvanced(t *testing.T) {
+	s := Statistics{Points: 25, Rebounds: 8, Assists: 7.5, Steals: 1, Blocks: 0.5, Fouls: 2, Turnovers: 3, MinutesPlayed: 35, PlusMinus: 4,
+		FieldGoalsMade: 9, FieldGoalsAttempted: 18, ThreePointersMade: 2, FreeThrowsMade: 5, FreeThrowsAttempted: 6, OffensiveRebounds: 1.5, Possessions: 70}
+	expected := playerAdvanced{
+		advanced:   advanced{TrueShooting: 0.606, EffectiveFieldGoal: 0.556, AssistToTurnover: 2.5},
+		GameScore:  21.4,
+		Efficiency: 29,
+		Per36:      rates{Points: 25.7, Rebounds: 8.2, Assists: 7.7, Steals: 1, Blocks: 0.5, Fouls: 2.1, Turnovers: 3.1, PlusMinus: 4.1},
+		Per100:     rates{Points: 35.7, Rebounds: 11.4, Assists: 10.7, Steals: 1.4, Blocks: 0.7, Fouls: 2.9, Turnovers: 4.3, PlusMinus: 5.7},
+	}
+	if a := newPlayerAdvanced(s); a != expected {
+		t.Errorf("expected %+v, got %+v", expected, a)
+	}
+
+	// rates aren't derived without minutes and possessions
+	if a := newPlayerAdvanced(Statistics{Points: 2, FieldGoalsMade: 1, FieldGoalsAttempted: 1}); a.Per36 != (rates{}) || a.Per100 != (rates{}) {
+		t.Errorf("expected no rates without minutes and possessions, got %+v", a)
+	}
+}
+
+func TestNewTeamAdvanced(t *testing.T) {
+	s := Statistics{Points: 10, Rebounds: 4, Assists: 2, Steals: 1, Blocks: 0.4, Fouls: 2, Turnovers: 1.2, MinutesPlayed: 24, PlusMinus: 1,
+		FieldGoalsMade: 4, FieldGoalsAttempted: 8, ThreePointersMade: 1, FreeThrowsMade: 1, FreeThrowsAttempted: 2, Possessions: 9}
+	tempo := &tempo{Games: []gameTempo{
+		{GameDate: "2025-03-15", Opponent: "boston-celtics", Possessions: 100, Minutes: 48, PointsFor: 112, PointsAgainst: 104},
+		{GameDate: "2025-03-17", Opponent: "new-york-knicks", Possessions: 95, Minutes: 53, PointsFor: 101, PointsAgainst: 103},
+	}}
+	// rows of 24 minutes are 4.8 minutes of the team, and 9.3 of its possessions at the pace of its games
+	expected := teamAdvanced{
+		advanced: advanced{TrueShooting: 0.563, EffectiveFieldGoal: 0.563, AssistToTurnover: 1.67},
+		Per36:    rates{Points: 75, Rebounds: 30, Assists: 15, Steals: 7.5, Blocks: 3, Fouls: 15, Turnovers: 9, PlusMinus: 7.5},
+		Per100:   rates{Points: 107.9, Rebounds: 43.2, Assists: 21.6, Steals: 10.8, Blocks: 4.3, Fouls: 21.6, Turnovers: 12.9, PlusMinus: 10.8},
+	}
+	if a := newTeamAdvanced(s, tempo); a != expected {
+		t.Errorf("expected %+v, got %+v", expected, a)
+	}
+
+	// rates per 100 possessions aren't derived without tempo
+	if a := newTeamAdvanced(s, nil); a.Per36 != expected.Per36 || a.Per100 != (rates{}) {
+		t.Errorf("expected rates per 36 minutes only without tempo, got %+v", a)
+	}
+}
+
+func TestParseMetrics(t *testing.T) {
+	tests := []struct {
+		query    string
+		advanced bool
+		err      bool
+	}{
+		{"", false, false},
+		{"?metrics=advanced", true, false},
+		{"?metrics=basic", false, true},
+	}
+	for _, test := range tests {
+		advanced, err := parseMetrics(httptest.NewRequest("GET", "/"+test.query, nil))
+		if advanced != test.advanced || (err != nil) != test.err {
+			t.Errorf("%q: expected %v and error %v, got %v and %v", test.query, test.advanced, test.err, advanced, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"net/http"
+	"strings"
+)
+
+// limits of the number of players to be compared at once
+const (
+	minComparedPlayers = 2
+	maxComparedPlayers = 5
+)
+
+// comparison is a side-by-side view of players statistics for a season.
+// Values of every category are aligned with Players and IDs.
+type comparison struct {
+	League     string               `json:"league"`
+	Season     string               `json:"season"`
+	SeasonType string               `json:"seasonType"`
+	Players    []string             `json:"players"`
+	IDs        []string             `json:"ids"`
+	Categories []categoryComparison `json:"categories"`
+	// Provisional lists players whose statistics are provisional, i.e. may change by late events
+	Provisional []string `json:"provisional,omitempty"`
+}
+
+type categoryComparison struct {
+	Category string    `json:"category"`
+	Values   []float64 `json:"values"`
+	Leaders  []string  `json:"leaders"` // more than one on a tie
+}
+
+// compare builds a comparison of the given statistics which must be aligned with players
+func compare(season string, players []string, statistics []Statistics) comparison {
+	c := comparison{Season: season, Players: players}
+
+	for _, category := range categories {
+		cc := categoryComparison{Category: category.name, Values: make([]float64, len(statistics))}
+		for i, s := range statistics {
+			cc.Values[i] = category.value(s)
+		}
+
+		var best float64
+		for i, value := range cc.Values {
+			better := value > best
+			if category.lowerIsBetter {
+				better = value < best
+			}
+			switch {
+			case i == 0 || better:
+				best = value
+				cc.Leaders = []string{players[i]}
+			case value == best:
+				cc.Leaders = append(cc.Leaders, players[i])
+			}
+		}
+
+		c.Categories = append(c.Categories, cc)
+	}
+
+	for i, s := range statistics {
+		if s.Provisional {
+			c.Provisional = append(c.Provisional, players[i])
+		}
+	}
+
+	return c
+}
+
+// validateComparedPlayers checks the number of players and that none of them is repeated
+func validateComparedPlayers(players []string) error {
+	if len(players) < minComparedPlayers || len(players) > maxComparedPlayers {
+		return fmt.Errorf("from %d to %d 'player' parameters expected, got %d", minComparedPlayers, maxComparedPlayers, len(players))
+	}
+
+	seen := map[string]bool{}
+	for _, player := range players {
+		if player == "" {
+			return errors.New("empty 'player' parameter")
+		}
+		if seen[player] {
+			return fmt.Errorf("player %q is repeated", player)
+		}
+		seen[player] = true
+	}
+
+	return nil
+}
+
+func handleCompare(ctx context.Context, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		players := query["player"]
+		if err := validateComparedPlayers(players); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to validate players: %w", err))
+			return
+		}
+
+		season := query.Get("season")
+		if season == "" {
+			respondError(w, http.StatusBadRequest, errors.New("'season' parameter is not specified"))
+			return
+		}
+
+		seasonType, err := parseSeasonType(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		league, err := parseLeague(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// players are given either by identifiers or by names
+		ids, names, err := resolve(ctx, rdb, "player", players...)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		var unknown []string
+		var hints string
+		for i, id := range ids {
+			if id == "" {
+				unknown = append(unknown, players[i])
+				hints += didYouMean(ctx, rdb, "player", players[i])
+			}
+		}
+
+		if len(unknown) > 0 {
+			respondError(w, http.StatusNotFound, fmt.Errorf("players %s not found%s", strings.Join(quote(unknown), ", "), hints))
+			return
+		}
+
+		if err := validateComparedPlayers(ids); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to validate players: %w", err))
+			return
+		}
+
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = statisticsKey(league, "player", id, season, seasonType)
+		}
+
+		// all the keys are fetched in a single round trip
+		values, err := rdb.MGet(ctx, keys...).Result()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to MGET %q keys from Redis: %w", keys, err))
+			return
+		}
+
+		statistics := make([]Statistics, len(players))
+		var notFound []string
+		for i, value := range values {
+			s, ok := value.(string)
+			if !ok {
+				notFound = append(notFound, players[i])
+				continue
+			}
+
+			if err := json.Unmarshal([]byte(s), &statistics[i]); err != nil {
+				respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to unmarshal %q key from Redis: %w", keys[i], err))
+				return
+			}
+		}
+
+		if len(notFound) > 0 {
+			respondError(w, http.StatusNotFound, fmt.Errorf("statistics for players %s in %s on %s season %s not found", strings.Join(quote(notFound), ", "), league, seasonType, season))
+			return
+		}
+
+		c := compare(season, names, statistics)
+		c.League, c.SeasonType, c.IDs = league, seasonType, ids
+		respondJSON(w, c)
+	}
+}
+
+func quote(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return quoted
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+)
+
+const (
+	leBronJames  = "LeBron James"
+	anthonyDavis = "Anthony Davis"
+	austinReaves = "Austin Reaves"
+)
+
+func TestCompare_Leaders(t *testing.T) {
+	c := compare("2024-25",
+		[]string{leBronJames, anthonyDavis, austinReaves},
+		[]Statistics{
+			{Points: 25, Rebounds: 8, Assists: 9, Fouls: 2, Turnovers: 3.5},
+			{Points: 24, Rebounds: 12, Assists: 3, Fouls: 3, Turnovers: 2, Provisional: true},
+			{Points: 25, Rebounds: 4, Assists: 5, Fouls: 2, Turnovers: 2.5},
+		},
+	)
+
+	if len(c.Categories) != len(categories) {
+		t.Fatalf("expected %d categories, got %d", len(categories), len(c.Categories))
+	}
+
+	if !slices.Equal(c.Provisional, []string{anthonyDavis}) {
+		t.Errorf("expected provisional statistics of %q, got %v", anthonyDavis, c.Provisional)
+	}
+
+	for _, expected := range []categoryComparison{
+		{Category: "points", Values: []float64{25, 24, 25}, Leaders: []string{leBronJames, austinReaves}},
+		{Category: "rebounds", Values: []float64{8, 12, 4}, Leaders: []string{anthonyDavis}},
+		{Category: "assists", Values: []float64{9, 3, 5}, Leaders: []string{leBronJames}},
+		{Category: "steals", Values: []float64{0, 0, 0}, Leaders: []string{leBronJames, anthonyDavis, austinReaves}},
+		// the fewest fouls and turnovers lead
+		{Category: "fouls", Values: []float64{2, 3, 2}, Leaders: []string{leBronJames, austinReaves}},
+		{Category: "turnovers", Values: []float64{3.5, 2, 2.5}, Leaders: []string{anthonyDavis}},
+	} {
+		i := slices.IndexFunc(c.Categories, func(cc categoryComparison) bool { return cc.Category == expected.Category })
+		if i < 0 {
+			t.Fatalf("category %q is missing", expected.Category)
+		}
+
+		actual := c.Categories[i]
+		if !slices.Equal(actual.Values, expected.Values) {
+			t.Errorf("%s: expected values %v, got %v", expected.Category, expected.Values, actual.Values)
+		}
+		if !slices.Equal(actual.Leaders, expected.Leaders) {
+			t.Errorf("%s: expected leaders %v, got %v", expected.Category, expected.Leaders, actual.Leaders)
+		}
+	}
+}
+
+func TestValidateComparedPlayers(t *testing.T) {
+	for _, players := range [][]string{
+		{leBronJames},
+		{leBronJames, leBronJames},
+		{leBronJames, ""},
+		{"1", "2", "3", "4", "5", "6"},
+	} {
+		if err := validateComparedPlayers(players); err == nil {
+			t.Errorf("expected error for players %q", players)
+		}
+	}
+
+	if err := validateComparedPlayers([]string{leBronJames, anthonyDavis}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"slices"
+	"strings"
+)
+
+// maxSuggestions is the maximal number of "did you mean" suggestions
+const maxSuggestions = 3
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+// suggest returns up to maxSuggestions names closest to the given one, ignoring case.
+// The name itself and names too far from it, i.e. more than a quarter of its length plus one edit, are not suggested.
+func suggest(name string, names []string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	lowered := strings.ToLower(name)
+	threshold := len([]rune(lowered))/4 + 1
+
+	var candidates []candidate
+	for _, n := range names {
+		if n == name {
+			continue
+		}
+		if distance := levenshtein(lowered, strings.ToLower(n)); distance <= threshold {
+			candidates = append(candidates, candidate{n, distance})
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b candidate) int { return a.distance - b.distance })
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for _, c := range candidates[:min(len(candidates), maxSuggestions)] {
+		suggestions = append(suggestions, c.name)
+	}
+	return suggestions
+}
+
+// didYouMean returns a hint with suggestions for the name of the subject ("player" or "team") which is not found,
+// or an empty string if there is nothing to suggest
+func didYouMean(ctx context.Context, rdb *redis.Client, subject, name string) string {
+	namesByIDs, err := rdb.HGetAll(ctx, registryNamesKey(subject)).Result()
+	if err != nil || len(namesByIDs) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(namesByIDs))
+	for _, n := range namesByIDs {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+
+	suggestions := suggest(name, names)
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("; did you mean %s?", strings.Join(quote(suggestions), " or "))
+}
@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"antony davis", "anthony davis", 1},
+		{"Dončić", "Doncic", 2},
+	} {
+		if distance := levenshtein(tc.a, tc.b); distance != tc.distance {
+			t.Errorf("levenshtein(%q, %q): expected %d, got %d", tc.a, tc.b, tc.distance, distance)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	names := []string{leBronJames, anthonyDavis, austinReaves, "Anthony Edwards"}
+
+	for _, tc := range []struct {
+		name        string
+		suggestions []string
+	}{
+		{"Antony Davis", []string{anthonyDavis}},
+		{"lebron james", []string{leBronJames}},
+		{leBronJames, []string{}},
+		{"Michael Jordan", []string{}},
+	} {
+		if suggestions := suggest(tc.name, names); !slices.Equal(suggestions, tc.suggestions) {
+			t.Errorf("suggest(%q): expected %q, got %q", tc.name, tc.suggestions, suggestions)
+		}
+	}
+}
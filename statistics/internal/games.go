@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// gameKey returns the Redis key of the state of the game of the team in the league on the date in format "2006-01-02"
+func gameKey(league, team, gameDate string) string {
+	return fmt.Sprintf("%s:game:%s:%s", league, team, gameDate)
+}
+
+// handleGame responds the state of the game of the team on the date, stored in Redis by the events service
+func handleGame(ctx context.Context, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		team, err := url.PathUnescape(vars["team"])
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to unescape 'team' parameter: %w", err))
+			return
+		}
+
+		date := vars["date"]
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid game date: %w", err))
+			return
+		}
+
+		league, err := parseLeague(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ids, _, err := resolve(ctx, rdb, "team", team)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if ids[0] == "" {
+			respondError(w, http.StatusNotFound, fmt.Errorf("team %q not found%s", team, didYouMean(ctx, rdb, "team", team)))
+			return
+		}
+
+		key := gameKey(league, ids[0], date)
+		val, err := rdb.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			respondError(w, http.StatusNotFound, fmt.Errorf("game of team %q in %s on %s not found", team, league, date))
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to GET %q key from Redis: %w", key, err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := fmt.Fprint(w, val); err != nil {
+			log.Printf("Error writing response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"net/http"
+	"strconv"
+)
+
+// Redis sorted sets maintained by the events service.
+// All the members have the same score, so they are ordered lexicographically and can be searched by prefix.
+// Players and teams are indexed by their identifiers which are derived from their names.
+// Indexes of players, teams and seasons are kept per league, see indexKey.
+const (
+	indexPlayers = "index:players"
+	indexTeams   = "index:teams"
+	indexSeasons = "index:seasons"
+	indexLeagues = "index:leagues"
+)
+
+// indexKey returns the Redis key of the index of the league
+func indexKey(league, index string) string {
+	return fmt.Sprintf("%s:%s", league, index)
+}
+
+// pagination defaults and limits
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+// page is a paginated response of listing endpoints
+type page struct {
+	Items  any   `json:"items"`
+	Total  int64 `json:"total"`
+	Offset int64 `json:"offset"`
+	Limit  int64 `json:"limit"`
+}
+
+// entry is an item of players or teams listing
+type entry struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// parsePagination parses 'offset' and 'limit' query parameters
+func parsePagination(r *http.Request) (offset, limit int64, err error) {
+	query := r.URL.Query()
+
+	offset, limit = 0, defaultLimit
+	if s := query.Get("offset"); s != "" {
+		if offset, err = strconv.ParseInt(s, 10, 64); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid 'offset' parameter: %q", s)
+		}
+	}
+	if s := query.Get("limit"); s != "" {
+		if limit, err = strconv.ParseInt(s, 10, 64); err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, fmt.Errorf("invalid 'limit' parameter: %q, from 1 to %d expected", s, maxLimit)
+		}
+	}
+
+	return offset, limit, nil
+}
+
+// handleList returns a handler listing the index members, optionally filtered by 'prefix' parameter.
+// Players and teams, i.e. a non-empty subject, are searched by the prefix of their identifiers derived from the given prefix.
+// All the indexes but the one of leagues are of the league given by 'league' parameter.
+func handleList(ctx context.Context, index string, subject string, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		offset, limit, err := parsePagination(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		key := index
+		if index != indexLeagues {
+			league, err := parseLeague(r)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, err)
+				return
+			}
+			key = indexKey(league, index)
+		}
+
+		prefix := r.URL.Query().Get("prefix")
+		if subject != "" {
+			prefix = slugify(prefix)
+		}
+
+		// "-" and "+" are the lowest and the highest possible values
+		lower, upper := "-", "+"
+		if prefix != "" {
+			lower, upper = "["+prefix, "["+prefix+"\xff"
+		}
+
+		total, err := rdb.ZLexCount(ctx, key, lower, upper).Result()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to count %q members from Redis: %w", key, err))
+			return
+		}
+
+		members, err := rdb.ZRangeByLex(ctx, key, &redis.ZRangeBy{Min: lower, Max: upper, Offset: offset, Count: limit}).Result()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to range %q members from Redis: %w", key, err))
+			return
+		}
+
+		if subject == "" || len(members) == 0 {
+			respondJSON(w, page{Items: members, Total: total, Offset: offset, Limit: limit})
+			return
+		}
+
+		names, err := rdb.HMGet(ctx, registryNamesKey(subject), members...).Result()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to get %s names from Redis: %w", subject, err))
+			return
+		}
+
+		entries := make([]entry, len(members))
+		for i, id := range members {
+			entries[i].ID = id
+			entries[i].Name, _ = names[i].(string)
+		}
+
+		respondJSON(w, page{Items: entries, Total: total, Offset: offset, Limit: limit})
+	}
+}
@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// sizes of units of lineups by 'size' query parameter: five-man units by default, and trios and pairs within them
+var lineupSizes = []int{5, 3, 2}
+
+// ratingMinutes is the length of a game net rating is normalized to
+const ratingMinutes = 48
+
+// lineup is a unit of players of a team on the court together, cached by the events service in the order of minutes,
+// completed by display names of the players and its net rating
+type lineup struct {
+	Players       []string `json:"players"`
+	Names         []string `json:"names"` // aligned with Players
+	Minutes       float64  `json:"minutes"`
+	PointsFor     int      `json:"pointsFor"`
+	PointsAgainst int      `json:"pointsAgainst"`
+	NetRating     float64  `json:"netRating"` // point differential per 48 minutes
+}
+
+// lineups is the units of the size of the team in the season
+type lineups struct {
+	League     string   `json:"league"`
+	Team       string   `json:"team"`
+	Season     string   `json:"season"`
+	SeasonType string   `json:"seasonType"`
+	Size       int      `json:"size"`
+	Lineups    []lineup `json:"lineups"`
+}
+
+// lineupsKey returns the Redis key of the units of the size of the team in the league for the season of the given type
+func lineupsKey(league, team, season, seasonType string, size int) string {
+	return fmt.Sprintf("%s:lineups:%d", statisticsKey(league, "team", team, season, seasonType), size)
+}
+
+// parseLineupSize parses optional 'size' query parameter, five-man units by default
+func parseLineupSize(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("size")
+	if value == "" {
+		return lineupSizes[0], nil
+	}
+
+	size, err := strconv.Atoi(value)
+	if err != nil || !slices.Contains(lineupSizes, size) {
+		return 0, fmt.Errorf("invalid 'size' parameter %q, one of %v expected", value, lineupSizes)
+	}
+
+	return size, nil
+}
+
+// netRating returns the point differential per 48 minutes, rounded to 1 decimal
+func netRating(pointsFor, pointsAgainst int, minutes float64) float64 {
+	if minutes <= 0 {
+		return 0
+	}
+	return math.Round(float64(pointsFor-pointsAgainst)*ratingMinutes/minutes*10) / 10
+}
+
+// handleLineups responds the units of the size of the team in the season with their minutes, points for and against, and net rating
+func handleLineups(ctx context.Context, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		team, err := url.PathUnescape(vars["team"])
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to unescape 'team' parameter: %w", err))
+			return
+		}
+
+		season, err := url.PathUnescape(vars["season"])
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to unescape 'season' parameter: %w", err))
+			return
+		}
+
+		seasonType, err := parseSeasonType(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		league, err := parseLeague(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		size, err := parseLineupSize(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ids, _, err := resolve(ctx, rdb, "team", team)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if ids[0] == "" {
+			respondError(w, http.StatusNotFound, fmt.Errorf("team %q not found%s", team, didYouMean(ctx, rdb, "team", team)))
+			return
+		}
+
+		key := lineupsKey(league, ids[0], season, seasonType, size)
+		val, err := rdb.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			respondError(w, http.StatusNotFound, fmt.Errorf("lineups of team %q in %s on %s season %s not found", team, league, seasonType, season))
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to GET %q key from Redis: %w", key, err))
+			return
+		}
+
+		l := lineups{League: league, Team: ids[0], Season: season, SeasonType: seasonType, Size: size}
+		if err := json.Unmarshal([]byte(val), &l.Lineups); err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to unmarshal %q key from Redis: %w", key, err))
+			return
+		}
+
+		var players []string
+		for _, u := range l.Lineups {
+			for _, player := range u.Players {
+				if !slices.Contains(players, player) {
+					players = append(players, player)
+				}
+			}
+		}
+
+		names := map[string]string{}
+		if len(players) > 0 {
+			values, err := rdb.HMGet(ctx, registryNamesKey("player"), players...).Result()
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to get players %q from Redis: %w", players, err))
+				return
+			}
+			for i, player := range players {
+				names[player], _ = values[i].(string)
+			}
+		}
+
+		for i, u := range l.Lineups {
+			l.Lineups[i].Names = make([]string, len(u.Players))
+			for j, player := range u.Players {
+				l.Lineups[i].Names[j] = names[player]
+			}
+			l.Lineups[i].NetRating = netRating(u.PointsFor, u.PointsAgainst, u.Minutes)
+		}
+		if l.Lineups == nil {
+			l.Lineups = []lineup{}
+		}
+
+		respondJSON(w, l)
+	}
+}
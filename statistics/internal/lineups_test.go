@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNetRating(t *testing.T) {
+	for _, tc := range []struct {
+		pointsFor, pointsAgainst int
+		minutes                  float64
+		expected                 float64
+	}{
+		{pointsFor: 30, pointsAgainst: 20, minutes: 48, expected: 10},
+		{pointsFor: 4, pointsAgainst: 3, minutes: 4, expected: 12},
+		{pointsFor: 5, pointsAgainst: 12, minutes: 7, expected: -48},
+		{pointsFor: 2, pointsAgainst: 0, minutes: 0, expected: 0},
+	} {
+		if actual := netRating(tc.pointsFor, tc.pointsAgainst, tc.minutes); actual != tc.expected {
+			t.Errorf("expected %v for %+v, got %v", tc.expected, tc, actual)
+		}
+	}
+}
+
+func TestParseLineupSize(t *testing.T) {
+	for query, expected := range map[string]int{"": 5, "?size=5": 5, "?size=3": 3, "?size=2": 2} {
+		size, err := parseLineupSize(httptest.NewRequest("GET", "/lineups"+query, nil))
+		if err != nil || size != expected {
+			t.Errorf("expected %d for %q, got %d, %v", expected, query, size, err)
+		}
+	}
+
+	for _, query := range []string{"?size=4", "?size=five"} {
+		if _, err := parseLineupSize(httptest.NewRequest("GET", "/lineups"+query, nil)); err == nil {
+			t.Errorf("expected an error for %q", query)
+		}
+	}
+}
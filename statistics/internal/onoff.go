@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"net/http"
+	"net/url"
+)
+
+// courtTime is the minutes of the team with the player on or off the court, and the points of the team and its opponent scored meanwhile,
+// cached by the events service and completed by ratings per 48 minutes
+type courtTime struct {
+	Minutes         float64 `json:"minutes"`
+	PointsFor       int     `json:"pointsFor"`
+	PointsAgainst   int     `json:"pointsAgainst"`
+	OffensiveRating float64 `json:"offensiveRating"` // points of the team per 48 minutes
+	DefensiveRating float64 `json:"defensiveRating"` // points of the opponent per 48 minutes
+	NetRating       float64 `json:"netRating"`
+}
+
+// rate returns the court time with its ratings
+func (c courtTime) rate() courtTime {
+	c.OffensiveRating, c.DefensiveRating = perMinutes(c.PointsFor, c.Minutes), perMinutes(c.PointsAgainst, c.Minutes)
+	c.NetRating = netRating(c.PointsFor, c.PointsAgainst, c.Minutes)
+	return c
+}
+
+// onOff is the impact of a player on the team, i.e. the court time of the team with the player on the court and off it
+type onOff struct {
+	League     string    `json:"league"`
+	Player     string    `json:"player"`
+	Name       string    `json:"name"`
+	Season     string    `json:"season"`
+	SeasonType string    `json:"seasonType"`
+	On         courtTime `json:"on"`
+	Off        courtTime `json:"off"`
+	NetRating  float64   `json:"netRating"` // the difference of net ratings on and off the court
+}
+
+// onOffKey returns the Redis key of the on/off statistics of the player in the league for the season of the given type
+func onOffKey(league, player, season, seasonType string) string {
+	return fmt.Sprintf("%s:onoff", statisticsKey(league, "player", player, season, seasonType))
+}
+
+// perMinutes returns the points per 48 minutes, rounded to 1 decimal
+func perMinutes(points int, minutes float64) float64 {
+	if minutes <= 0 {
+		return 0
+	}
+	return math.Round(float64(points)*ratingMinutes/minutes*10) / 10
+}
+
+// handleOnOff responds the offense and the defense of the team per 48 minutes with the player on the court and off it in the season
+func handleOnOff(ctx context.Context, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		player, err := url.PathUnescape(vars["player"])
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to unescape 'player' parameter: %w", err))
+			return
+		}
+
+		season, err := url.PathUnescape(vars["season"])
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to unescape 'season' parameter: %w", err))
+			return
+		}
+
+		seasonType, err := parseSeasonType(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		league, err := parseLeague(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ids, names, err := resolve(ctx, rdb, "player", player)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if ids[0] == "" {
+			respondError(w, http.StatusNotFound, fmt.Errorf("player %q not found%s", player, didYouMean(ctx, rdb, "player", player)))
+			return
+		}
+
+		key := onOffKey(league, ids[0], season, seasonType)
+		val, err := rdb.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			respondError(w, http.StatusNotFound, fmt.Errorf("on/off statistics for player %q in %s on %s season %s not found", player, league, seasonType, season))
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to GET %q key from Redis: %w", key, err))
+			return
+		}
+
+		o := onOff{League: league, Player: ids[0], Name: names[0], Season: season, SeasonType: seasonType}
+		if err := json.Unmarshal([]byte(val), &o); err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to unmarshal %q key from Redis: %w", key, err))
+			return
+		}
+		o.On, o.Off = o.On.rate(), o.Off.rate()
+		o.NetRating = math.Round((o.On.NetRating-o.Off.NetRating)*10) / 10
+
+		respondJSON(w, o)
+	}
+}
@@ -0,0 +1,21 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestCourtTimeRate(t *testing.T) {
+	on := courtTime{Minutes: 24, PointsFor: 60, PointsAgainst: 50}.rate()
+	if on.OffensiveRating != 120 || on.DefensiveRating != 100 || on.NetRating != 20 {
+		t.Errorf("unexpected ratings on the court %+v", on)
+	}
+
+	off := courtTime{Minutes: 36, PointsFor: 80, PointsAgainst: 85}.rate()
+	if off.OffensiveRating != 106.7 || off.DefensiveRating != 113.3 || off.NetRating != -6.7 {
+		t.Errorf("unexpected ratings off the court %+v", off)
+	}
+
+	if none := (courtTime{}).rate(); none != (courtTime{}) {
+		t.Errorf("expected no ratings without minutes, got %+v", none)
+	}
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"unicode"
+)
+
+// Redis hashes mirroring the registry of players and teams maintained by the events service:
+// aliases, i.e. normalized names, resolving to identifiers, display names by identifiers, and conferences and divisions of teams
+func registryAliasesKey(subject string) string { return fmt.Sprintf("registry:%s:aliases", subject) }
+func registryNamesKey(subject string) string   { return fmt.Sprintf("registry:%s:names", subject) }
+func registryConferencesKey(subject string) string {
+	return fmt.Sprintf("registry:%s:conferences", subject)
+}
+func registryDivisionsKey(subject string) string {
+	return fmt.Sprintf("registry:%s:divisions", subject)
+}
+
+// normalizeName returns the alias of the name: lower-cased with whitespaces collapsed
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// slugify returns the identifier derived from the name, the same way the events service does it
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// resolve returns identifiers and display names of the subjects ("player" or "team") given by identifiers or names.
+// Identifier and name of an unknown subject are empty.
+func resolve(ctx context.Context, rdb *redis.Client, subject string, namesOrIDs ...string) (ids []string, names []string, err error) {
+	aliases := make([]string, len(namesOrIDs))
+	for i, nameOrID := range namesOrIDs {
+		aliases[i] = normalizeName(nameOrID)
+	}
+
+	var byIDs, byAliases *redis.SliceCmd
+	if _, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		byIDs = pipe.HMGet(ctx, registryNamesKey(subject), namesOrIDs...)
+		byAliases = pipe.HMGet(ctx, registryAliasesKey(subject), aliases...)
+		return nil
+	}); err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve %s %q from Redis: %w", subject, namesOrIDs, err)
+	}
+
+	ids, names = make([]string, len(namesOrIDs)), make([]string, len(namesOrIDs))
+	var aliasedIDs []string
+	for i, nameOrID := range namesOrIDs {
+		if name, ok := byIDs.Val()[i].(string); ok {
+			ids[i], names[i] = nameOrID, name
+		} else if id, ok := byAliases.Val()[i].(string); ok {
+			ids[i] = id
+			aliasedIDs = append(aliasedIDs, id)
+		}
+	}
+
+	if len(aliasedIDs) == 0 {
+		return ids, names, nil
+	}
+
+	values, err := rdb.HMGet(ctx, registryNamesKey(subject), aliasedIDs...).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get %s names %q from Redis: %w", subject, aliasedIDs, err)
+	}
+
+	j := 0
+	for i := range ids {
+		if ids[i] != "" && names[i] == "" {
+			names[i], _ = values[j].(string)
+			j++
+		}
+	}
+
+	return ids, names, nil
+}
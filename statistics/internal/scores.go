@@ -0,0 +1,231 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// states of a game in the order they are reached
+var gameStates = []string{"scheduled", "live", "intermission", "final"}
+
+// periodScore is the number of points scored in the period, the period 0 holding points scored before the first period started
+type periodScore struct {
+	Period int `json:"period"`
+	Points int `json:"points"`
+}
+
+// gameStatus is the state and the score of the game of a team, stored in Redis by the events service
+type gameStatus struct {
+	State    string `json:"state"`
+	Period   int    `json:"period"`
+	HomeTeam string `json:"homeTeam"`
+	Opponent string `json:"opponent"`
+	Score    struct {
+		Periods []periodScore `json:"periods"`
+		Total   int           `json:"total"`
+	} `json:"score"`
+}
+
+// teamScore is the score of a team in a game
+type teamScore struct {
+	Team    string        `json:"team"`
+	State   string        `json:"state"`
+	Period  int           `json:"period"`
+	Periods []periodScore `json:"periods"`
+	Total   int           `json:"total"`
+}
+
+// gameScore is the score of a game of the home team and the visiting one, the latter being unknown until the game is paired
+type gameScore struct {
+	ID       string     `json:"id"`
+	League   string     `json:"league"`
+	GameDate string     `json:"gameDate"`
+	State    string     `json:"state"` // the latest state of the teams
+	Home     teamScore  `json:"home"`
+	Away     *teamScore `json:"away,omitempty"`
+	Winner   string     `json:"winner,omitempty"` // of a final game
+}
+
+// scoreboard is the scores of the games of the league on the date
+type scoreboard struct {
+	League   string      `json:"league"`
+	GameDate string      `json:"gameDate"`
+	Games    []gameScore `json:"games"`
+}
+
+// scoreboardKey returns the Redis key of the set of home teams playing games in the league on the date in format "2006-01-02"
+func scoreboardKey(league, gameDate string) string {
+	return fmt.Sprintf("%s:scoreboard:%s", league, gameDate)
+}
+
+// gameID returns the identifier of the game of the home team on the date, e.g. "2025-03-15-los-angeles-lakers"
+func gameID(gameDate, homeTeam string) string {
+	return gameDate + "-" + homeTeam
+}
+
+// parseGameID returns the date and the home team of the game identifier
+func parseGameID(id string) (gameDate, homeTeam string, err error) {
+	if len(id) <= len(time.DateOnly)+1 || id[len(time.DateOnly)] != '-' {
+		return "", "", fmt.Errorf("invalid game identifier %q", id)
+	}
+	gameDate, homeTeam = id[:len(time.DateOnly)], id[len(time.DateOnly)+1:]
+	if _, err := time.Parse(time.DateOnly, gameDate); err != nil {
+		return "", "", fmt.Errorf("invalid date of game identifier %q: %w", id, err)
+	}
+
+	return gameDate, homeTeam, nil
+}
+
+// newTeamScore returns the score of the team in the game of the given state
+func newTeamScore(team string, g gameStatus) teamScore {
+	periods := g.Score.Periods
+	if periods == nil {
+		periods = []periodScore{}
+	}
+	return teamScore{Team: team, State: g.State, Period: g.Period, Periods: periods, Total: g.Score.Total}
+}
+
+// newGameScore composes the score of the game of the home team and the visiting one, if known.
+// The state of the game is the latest state of the teams, so the game is final once the end of either team is received.
+func newGameScore(league, gameDate, homeTeam string, home gameStatus, away *gameStatus) gameScore {
+	s := gameScore{ID: gameID(gameDate, homeTeam), League: league, GameDate: gameDate, State: home.State, Home: newTeamScore(homeTeam, home)}
+	if away == nil {
+		return s
+	}
+
+	awayScore := newTeamScore(home.Opponent, *away)
+	s.Away = &awayScore
+	if slices.Index(gameStates, away.State) > slices.Index(gameStates, s.State) {
+		s.State = away.State
+	}
+
+	if s.State == "final" {
+		switch {
+		case s.Home.Total > s.Away.Total:
+			s.Winner = s.Home.Team
+		case s.Away.Total > s.Home.Total:
+			s.Winner = s.Away.Team
+		}
+	}
+
+	return s
+}
+
+// getGameStatus returns the state of the game of the team on the date, and whether it's found
+func getGameStatus(ctx context.Context, rdb *redis.Client, league, team, gameDate string) (gameStatus, bool, error) {
+	key := gameKey(league, team, gameDate)
+	val, err := rdb.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return gameStatus{}, false, nil
+	}
+	if err != nil {
+		return gameStatus{}, false, fmt.Errorf("failed to GET %q key from Redis: %w", key, err)
+	}
+
+	var g gameStatus
+	if err := json.Unmarshal([]byte(val), &g); err != nil {
+		return gameStatus{}, false, fmt.Errorf("failed to unmarshal %q key from Redis: %w", key, err)
+	}
+
+	return g, true, nil
+}
+
+// getGameScore returns the score of the game of the home team on the date, and whether it's found.
+// A team visiting another one on the date doesn't host a game, although it's kept on the scoreboard since before its game was paired.
+func getGameScore(ctx context.Context, rdb *redis.Client, league, gameDate, homeTeam string) (gameScore, bool, error) {
+	home, found, err := getGameStatus(ctx, rdb, league, homeTeam, gameDate)
+	if err != nil || !found || home.HomeTeam != homeTeam {
+		return gameScore{}, false, err
+	}
+
+	if home.Opponent == "" {
+		return newGameScore(league, gameDate, homeTeam, home, nil), true, nil
+	}
+
+	away, found, err := getGameStatus(ctx, rdb, league, home.Opponent, gameDate)
+	if err != nil {
+		return gameScore{}, false, err
+	}
+	if !found {
+		// the opponent without events is still scoreless
+		away = gameStatus{State: gameStates[0], HomeTeam: homeTeam, Opponent: homeTeam}
+	}
+
+	return newGameScore(league, gameDate, homeTeam, home, &away), true, nil
+}
+
+// handleGameScore responds the live or final score of the game by periods
+func handleGameScore(ctx context.Context, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		gameDate, homeTeam, err := parseGameID(id)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		league, err := parseLeague(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		s, found, err := getGameScore(ctx, rdb, league, gameDate, homeTeam)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !found {
+			respondError(w, http.StatusNotFound, fmt.Errorf("game %q in %s not found", id, league))
+			return
+		}
+
+		respondJSON(w, s)
+	}
+}
+
+// handleScoreboard responds the live and final scores of all the games on the date
+func handleScoreboard(ctx context.Context, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameDate := mux.Vars(r)["date"]
+		if _, err := time.Parse(time.DateOnly, gameDate); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid game date: %w", err))
+			return
+		}
+
+		league, err := parseLeague(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		key := scoreboardKey(league, gameDate)
+		homeTeams, err := rdb.SMembers(ctx, key).Result()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to SMEMBERS %q key from Redis: %w", key, err))
+			return
+		}
+		slices.Sort(homeTeams)
+
+		board := scoreboard{League: league, GameDate: gameDate, Games: []gameScore{}}
+		for _, homeTeam := range homeTeams {
+			s, found, err := getGameScore(ctx, rdb, league, gameDate, homeTeam)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+			if found {
+				board.Games = append(board.Games, s)
+			}
+		}
+
+		respondJSON(w, board)
+	}
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"testing"
+)
+
+const (
+	losAngelesLakers = "los-angeles-lakers"
+	bostonCeltics    = "boston-celtics"
+)
+
+func TestParseGameID(t *testing.T) {
+	gameDate, homeTeam, err := parseGameID(gameID("2025-03-15", losAngelesLakers))
+	if err != nil {
+		t.Fatalf("failed to parse game identifier: %v", err)
+	}
+	if gameDate != "2025-03-15" || homeTeam != losAngelesLakers {
+		t.Errorf("expected game of %q on 2025-03-15, got game of %q on %s", losAngelesLakers, homeTeam, gameDate)
+	}
+
+	for _, id := range []string{"", "2025-03-15", "2025-03-15-", "2025-13-15-" + losAngelesLakers, "2025-03-15_" + losAngelesLakers} {
+		if _, _, err := parseGameID(id); err == nil {
+			t.Errorf("expected invalid game identifier %q", id)
+		}
+	}
+}
+
+func TestNewGameScore(t *testing.T) {
+	home := gameStatus{State: "live", Period: 4, HomeTeam: losAngelesLakers, Opponent: bostonCeltics}
+	home.Score.Periods = []periodScore{{1, 28}, {2, 25}, {3, 30}, {4, 27}}
+	home.Score.Total = 110
+	away := gameStatus{State: "final", Period: 4, HomeTeam: losAngelesLakers, Opponent: losAngelesLakers}
+	away.Score.Periods = []periodScore{{1, 30}, {2, 24}, {3, 22}, {4, 28}}
+	away.Score.Total = 104
+
+	s := newGameScore("nba", "2025-03-15", losAngelesLakers, home, &away)
+	if s.ID != "2025-03-15-"+losAngelesLakers {
+		t.Errorf("unexpected game identifier %q", s.ID)
+	}
+	if s.State != "final" {
+		t.Errorf("expected the latest state of the teams, got %q", s.State)
+	}
+	if s.Away == nil || s.Away.Team != bostonCeltics || s.Away.Total != 104 || len(s.Away.Periods) != 4 {
+		t.Fatalf("unexpected score of the visiting team: %+v", s.Away)
+	}
+	if s.Winner != losAngelesLakers {
+		t.Errorf("expected winner %q, got %q", losAngelesLakers, s.Winner)
+	}
+}
+
+func TestNewGameScore_Unpaired(t *testing.T) {
+	s := newGameScore("nba", "2025-03-15", losAngelesLakers, gameStatus{State: "final", HomeTeam: losAngelesLakers}, nil)
+	if s.Away != nil || s.Winner != "" {
+		t.Errorf("expected no visiting team nor winner of an unpaired game, got %+v", s)
+	}
+	if s.Home.Periods == nil {
+		t.Error("expected empty periods of a scoreless team")
+	}
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
@@ -14,8 +15,20 @@ import (
 func startServer(ctx context.Context, rdb *redis.Client) error {
 	r := mux.NewRouter()
 
+	r.HandleFunc("/api/v1/players", handleList(ctx, indexPlayers, "player", rdb)).Methods("GET")
+	r.HandleFunc("/api/v1/teams", handleList(ctx, indexTeams, "team", rdb)).Methods("GET")
+	r.HandleFunc("/api/v1/seasons", handleList(ctx, indexSeasons, "", rdb)).Methods("GET")
+	r.HandleFunc("/api/v1/leagues", handleList(ctx, indexLeagues, "", rdb)).Methods("GET")
+	r.HandleFunc("/api/v1/statistics/compare", handleCompare(ctx, rdb)).Methods("GET")
+	r.HandleFunc("/api/v1/statistics/player/{player}/season/{season}/teams", handleStints(ctx, rdb)).Methods("GET")
+	r.HandleFunc("/api/v1/statistics/player/{player}/season/{season}/onoff", handleOnOff(ctx, rdb)).Methods("GET")
 	r.HandleFunc("/api/v1/statistics/player/{player}/season/{season}", handle(ctx, "player", rdb)).Methods("GET")
+	r.HandleFunc("/api/v1/statistics/team/{team}/season/{season}/lineups", handleLineups(ctx, rdb)).Methods("GET")
 	r.HandleFunc("/api/v1/statistics/team/{team}/season/{season}", handle(ctx, "team", rdb)).Methods("GET")
+	r.HandleFunc("/api/v1/statistics/team/{team}/games/{date}", handleGame(ctx, rdb)).Methods("GET")
+	r.HandleFunc("/api/v1/games/{id}/score", handleGameScore(ctx, rdb)).Methods("GET")
+	r.HandleFunc("/api/v1/scoreboard/{date}", handleScoreboard(ctx, rdb)).Methods("GET")
+	r.HandleFunc("/api/v1/standings/{season}", handleStandings(ctx, rdb)).Methods("GET")
 
 	log.Println("NBA Players/Teams Statistics server is running")
 	if err := http.ListenAndServe(":8080", r); err != nil {
@@ -41,11 +54,63 @@ func handle(ctx context.Context, subject string, rdb *redis.Client) func(w http.
 			return
 		}
 
-		key := fmt.Sprintf("%s:%s:%s", subject, name, season)
+		seasonType, err := parseSeasonType(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		league, err := parseLeague(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		withAdvanced, err := parseMetrics(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// the subject is given either by identifier or by name
+		ids, _, err := resolve(ctx, rdb, subject, name)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if ids[0] == "" {
+			respondError(w, http.StatusNotFound, fmt.Errorf("%s %q not found%s", subject, name, didYouMean(ctx, rdb, subject, name)))
+			return
+		}
+
+		key := statisticsKey(league, subject, ids[0], season, seasonType)
+
+		// statistics of a player for a single stint, i.e. with the team only
+		var team string
+		if subject == "player" {
+			team = r.URL.Query().Get("team")
+		}
+		if team != "" {
+			teamIDs, _, err := resolve(ctx, rdb, "team", team)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+			if teamIDs[0] == "" {
+				respondError(w, http.StatusNotFound, fmt.Errorf("team %q not found%s", team, didYouMean(ctx, rdb, "team", team)))
+				return
+			}
+			key = stintKey(league, ids[0], teamIDs[0], season, seasonType)
+		}
+
 		val, err := rdb.Get(ctx, key).Result()
 		if err != nil {
 			if errors.Is(err, redis.Nil) {
-				respondError(w, http.StatusNotFound, fmt.Errorf("statistics for %s %q on season %s not found", subject, name, season))
+				if team != "" {
+					respondError(w, http.StatusNotFound, fmt.Errorf("statistics for %s %q with team %q in %s on %s season %s not found", subject, name, team, league, seasonType, season))
+					return
+				}
+				respondError(w, http.StatusNotFound, fmt.Errorf("statistics for %s %q in %s on %s season %s not found", subject, name, league, seasonType, season))
 				return
 			}
 
@@ -53,6 +118,39 @@ func handle(ctx context.Context, subject string, rdb *redis.Client) func(w http.
 			return
 		}
 
+		// statistics of a team come with its pace and ratings
+		if subject == "team" {
+			var s teamStatistics
+			if err := json.Unmarshal([]byte(val), &s.Statistics); err != nil {
+				respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to unmarshal %q key from Redis: %w", key, err))
+				return
+			}
+			if s.Tempo, err = getTempo(ctx, rdb, league, ids[0], season, seasonType); err != nil {
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+			if s.Tempo != nil {
+				s.Tempo.Games, s.Tempo.Total = newTempo(s.Tempo.Games)
+			}
+			if withAdvanced {
+				a := newTeamAdvanced(s.Statistics, s.Tempo)
+				s.Advanced = &a
+			}
+			respondJSON(w, s)
+			return
+		}
+
+		if withAdvanced {
+			var s advancedStatistics
+			if err := json.Unmarshal([]byte(val), &s.Statistics); err != nil {
+				respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to unmarshal %q key from Redis: %w", key, err))
+				return
+			}
+			s.Advanced = newPlayerAdvanced(s.Statistics)
+			respondJSON(w, s)
+			return
+		}
+
 		// Success
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -67,3 +165,18 @@ func respondError(w http.ResponseWriter, statusCode int, err error) {
 	log.Println(err)
 	http.Error(w, fmt.Sprintf("ERROR: %s", err.Error()), statusCode)
 }
+
+// respondJSON writes the value as JSON to http.ResponseWriter with http.StatusOK
+func respondJSON(w http.ResponseWriter, value any) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to marshal response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// groupings of standings by 'groupBy' query parameter, the whole league by default
+const (
+	groupByConference = "conference"
+	groupByDivision   = "division"
+)
+
+// record is a number of wins and losses
+type record struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+}
+
+// teamStanding is the regular season record of a team, cached by the events service in the order of win percentage,
+// completed by its display name and groups from the registry
+type teamStanding struct {
+	Team       string `json:"team"`
+	Name       string `json:"name,omitempty"`
+	Conference string `json:"conference,omitempty"`
+	Division   string `json:"division,omitempty"`
+	record
+	WinPercentage float64 `json:"winPercentage"`
+	GamesBehind   float64 `json:"gamesBehind"` // of the leader of the group
+	Home          record  `json:"home"`
+	Away          record  `json:"away"`
+	Streak        string  `json:"streak"`
+	LastTen       record  `json:"lastTen"`
+}
+
+// standingsGroup is the standings of the teams of a conference or a division, or of the whole league
+type standingsGroup struct {
+	Name  string         `json:"name,omitempty"`
+	Teams []teamStanding `json:"teams"`
+}
+
+// standings is the standings of the league in the season
+type standings struct {
+	League  string           `json:"league"`
+	Season  string           `json:"season"`
+	GroupBy string           `json:"groupBy,omitempty"`
+	Groups  []standingsGroup `json:"groups"`
+}
+
+// standingsKey returns the Redis key of the standings of the league in the season
+func standingsKey(league, season string) string {
+	return fmt.Sprintf("%s:standings:%s", league, season)
+}
+
+// groupStandings splits the standings ordered by win percentage into groups of teams of the same conference or division ordered by names,
+// and calculates games behind the leader of every group. Teams without the group are put into the unnamed group.
+func groupStandings(teams []teamStanding, groupBy string) []standingsGroup {
+	groups := []standingsGroup{}
+	for _, t := range teams {
+		var name string
+		switch groupBy {
+		case groupByConference:
+			name = t.Conference
+		case groupByDivision:
+			name = t.Division
+		}
+
+		i := slices.IndexFunc(groups, func(g standingsGroup) bool { return g.Name == name })
+		if i < 0 {
+			groups = append(groups, standingsGroup{Name: name})
+			i = len(groups) - 1
+		}
+
+		if games := t.Wins + t.Losses; games > 0 {
+			t.WinPercentage = math.Round(float64(t.Wins)/float64(games)*1000) / 1000
+		}
+		if len(groups[i].Teams) > 0 {
+			leader := groups[i].Teams[0]
+			t.GamesBehind = float64(leader.Wins-t.Wins+t.Losses-leader.Losses) / 2
+		}
+		groups[i].Teams = append(groups[i].Teams, t)
+	}
+
+	slices.SortFunc(groups, func(a, b standingsGroup) int {
+		switch {
+		case a.Name == b.Name:
+			return 0
+		case a.Name == "":
+			return 1
+		case b.Name == "":
+			return -1
+		case a.Name < b.Name:
+			return -1
+		default:
+			return 1
+		}
+	})
+
+	return groups
+}
+
+// handleStandings responds the standings of the league in the season, grouped by conferences or divisions of teams if requested
+func handleStandings(ctx context.Context, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		season, err := url.PathUnescape(mux.Vars(r)["season"])
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to unescape 'season' parameter: %w", err))
+			return
+		}
+
+		league, err := parseLeague(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		groupBy := r.URL.Query().Get("groupBy")
+		if groupBy != "" && groupBy != groupByConference && groupBy != groupByDivision {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid 'groupBy' parameter %q, %q or %q expected", groupBy, groupByConference, groupByDivision))
+			return
+		}
+
+		key := standingsKey(league, season)
+		val, err := rdb.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			respondError(w, http.StatusNotFound, fmt.Errorf("standings of %s season %q not found", league, season))
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to GET %q key from Redis: %w", key, err))
+			return
+		}
+
+		var teams []teamStanding
+		if err := json.Unmarshal([]byte(val), &teams); err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to unmarshal %q key from Redis: %w", key, err))
+			return
+		}
+
+		if len(teams) > 0 {
+			ids := make([]string, len(teams))
+			for i, t := range teams {
+				ids[i] = t.Team
+			}
+
+			var names, conferences, divisions *redis.SliceCmd
+			if _, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				names = pipe.HMGet(ctx, registryNamesKey("team"), ids...)
+				conferences = pipe.HMGet(ctx, registryConferencesKey("team"), ids...)
+				divisions = pipe.HMGet(ctx, registryDivisionsKey("team"), ids...)
+				return nil
+			}); err != nil {
+				respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to get teams %q from Redis: %w", ids, err))
+				return
+			}
+
+			for i := range teams {
+				teams[i].Name, _ = names.Val()[i].(string)
+				teams[i].Conference, _ = conferences.Val()[i].(string)
+				teams[i].Division, _ = divisions.Val()[i].(string)
+			}
+		}
+
+		respondJSON(w, standings{League: league, Season: season, GroupBy: groupBy, Groups: groupStandings(teams, groupBy)})
+	}
+}
@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestGroupStandings(t *testing.T) {
+	teams := []teamStanding{
+		{Team: "boston-celtics", Conference: "East", Division: "Atlantic", record: record{10, 2}},
+		{Team: losAngelesLakers, Conference: "West", Division: "Pacific", record: record{8, 4}},
+		{Team: "new-york-knicks", Conference: "East", Division: "Atlantic", record: record{7, 4}},
+		{Team: "golden-state-warriors", Conference: "West", Division: "Pacific", record: record{5, 7}},
+		{Team: "expansion-team", record: record{0, 0}},
+	}
+
+	groups := groupStandings(teams, groupByConference)
+	if len(groups) != 3 || groups[0].Name != "East" || groups[1].Name != "West" || groups[2].Name != "" {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+
+	knicks := groups[0].Teams[1]
+	if knicks.WinPercentage != 0.636 || knicks.GamesBehind != 2.5 {
+		t.Errorf("expected .636 and 2.5 games behind, got %+v", knicks)
+	}
+	warriors := groups[1].Teams[1]
+	if warriors.WinPercentage != 0.417 || warriors.GamesBehind != 3 {
+		t.Errorf("expected .417 and 3 games behind, got %+v", warriors)
+	}
+	if leader := groups[1].Teams[0]; leader.GamesBehind != 0 {
+		t.Errorf("expected the leader 0 games behind, got %+v", leader)
+	}
+
+	if league := groupStandings(teams, ""); len(league) != 1 || len(league[0].Teams) != len(teams) || league[0].Teams[3].GamesBehind != 5 {
+		t.Errorf("unexpected standings of the league %+v", league)
+	}
+}
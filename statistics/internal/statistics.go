@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// Statistics mirrors the JSON value stored in Redis by the events service
+type Statistics struct {
+	Points              float64 `json:"points"`
+	Rebounds            float64 `json:"rebounds"`
+	Assists             float64 `json:"assists"`
+	Steals              float64 `json:"steals"`
+	Blocks              float64 `json:"blocks"`
+	Fouls               float64 `json:"fouls"`
+	Turnovers           float64 `json:"turnovers"`
+	MinutesPlayed       float64 `json:"minutesPlayed"`
+	PlusMinus           float64 `json:"plusMinus"` // the average point differential of the team while on the court
+	FieldGoalsMade      float64 `json:"fieldGoalsMade"`
+	FieldGoalsAttempted float64 `json:"fieldGoalsAttempted"`
+	ThreePointersMade   float64 `json:"threePointersMade"`
+	FreeThrowsMade      float64 `json:"freeThrowsMade"`
+	FreeThrowsAttempted float64 `json:"freeThrowsAttempted"`
+	OffensiveRebounds   float64 `json:"offensiveRebounds"`
+	Possessions         float64 `json:"possessions"`           // the share of the possessions of the team by minutes played
+	PlusMinusTotal      float64 `json:"plusMinusTotal"`        // the sum of plus-minus over the games of the season
+	Provisional         bool    `json:"provisional,omitempty"` // until the watermarks of all the games pass their ends
+}
+
+// category is a single statistics value, named as in the JSON representation of Statistics
+type category struct {
+	name          string
+	value         func(s Statistics) float64
+	lowerIsBetter bool // e.g. fouls, so the lowest value leads
+}
+
+// categories lists Statistics values in the order of their JSON representation
+var categories = []category{
+	{"points", func(s Statistics) float64 { return s.Points }, false},
+	{"rebounds", func(s Statistics) float64 { return s.Rebounds }, false},
+	{"assists", func(s Statistics) float64 { return s.Assists }, false},
+	{"steals", func(s Statistics) float64 { return s.Steals }, false},
+	{"blocks", func(s Statistics) float64 { return s.Blocks }, false},
+	{"fouls", func(s Statistics) float64 { return s.Fouls }, true},
+	{"turnovers", func(s Statistics) float64 { return s.Turnovers }, true},
+	{"minutesPlayed", func(s Statistics) float64 { return s.MinutesPlayed }, false},
+	{"plusMinus", func(s Statistics) float64 { return s.PlusMinus }, false},
+	{"fieldGoalsMade", func(s Statistics) float64 { return s.FieldGoalsMade }, false},
+	{"fieldGoalsAttempted", func(s Statistics) float64 { return s.FieldGoalsAttempted }, false},
+	{"threePointersMade", func(s Statistics) float64 { return s.ThreePointersMade }, false},
+	{"freeThrowsMade", func(s Statistics) float64 { return s.FreeThrowsMade }, false},
+	{"freeThrowsAttempted", func(s Statistics) float64 { return s.FreeThrowsAttempted }, false},
+	{"offensiveRebounds", func(s Statistics) float64 { return s.OffensiveRebounds }, false},
+	{"possessions", func(s Statistics) float64 { return s.Possessions }, false},
+	{"plusMinusTotal", func(s Statistics) float64 { return s.PlusMinusTotal }, false},
+}
+
+// season types, i.e. phases of a season, which statistics are aggregated separately for
+var seasonTypes = []string{"preseason", "regular", "playin", "playoffs"}
+
+const defaultSeasonType = "regular"
+
+// parseSeasonType parses optional 'seasonType' query parameter, regular season by default
+func parseSeasonType(r *http.Request) (string, error) {
+	seasonType := r.URL.Query().Get("seasonType")
+	if seasonType == "" {
+		return defaultSeasonType, nil
+	}
+
+	if !slices.Contains(seasonTypes, seasonType) {
+		return "", fmt.Errorf("invalid 'seasonType' parameter %q, one of %q expected", seasonType, seasonTypes)
+	}
+
+	return seasonType, nil
+}
+
+// defaultLeague is the league of statistics unless 'league' query parameter is specified
+const defaultLeague = "nba"
+
+// parseLeague parses optional 'league' query parameter, the default league by default
+func parseLeague(r *http.Request) (string, error) {
+	league := r.URL.Query().Get("league")
+	if league == "" {
+		return defaultLeague, nil
+	}
+
+	// leagues are identified the same way as players and teams
+	if slugify(league) != league {
+		return "", fmt.Errorf("invalid 'league' parameter %q", league)
+	}
+
+	return league, nil
+}
+
+// statisticsKey returns the Redis key of the statistics of the given subject ("player" or "team") in the league for the season of the given type
+func statisticsKey(league, subject, id, season, seasonType string) string {
+	return fmt.Sprintf("%s:%s:%s:%s:%s", league, subject, id, season, seasonType)
+}
+
+// stintKey returns the Redis key of the statistics of the player in the league for the season of the given type with the team
+func stintKey(league, player, team, season, seasonType string) string {
+	return fmt.Sprintf("%s:team:%s", statisticsKey(league, "player", player, season, seasonType), team)
+}
+
+// stintsKey returns the Redis key of the set of teams the player played for in the league during the season of the given type
+func stintsKey(league, player, season, seasonType string) string {
+	return fmt.Sprintf("%s:teams", statisticsKey(league, "player", player, season, seasonType))
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// breakdown is the combined statistics of a player for a season together with the statistics of every stint with a team
+type breakdown struct {
+	Combined json.RawMessage `json:"combined"`
+	Teams    []stint         `json:"teams"`
+}
+
+type stint struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Statistics json.RawMessage `json:"statistics"`
+}
+
+func handleStints(ctx context.Context, rdb *redis.Client) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		player, err := url.PathUnescape(vars["player"])
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to unescape 'player' parameter: %w", err))
+			return
+		}
+
+		season, err := url.PathUnescape(vars["season"])
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to unescape 'season' parameter: %w", err))
+			return
+		}
+
+		seasonType, err := parseSeasonType(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		league, err := parseLeague(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ids, _, err := resolve(ctx, rdb, "player", player)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if ids[0] == "" {
+			respondError(w, http.StatusNotFound, fmt.Errorf("player %q not found%s", player, didYouMean(ctx, rdb, "player", player)))
+			return
+		}
+
+		teams, err := rdb.SMembers(ctx, stintsKey(league, ids[0], season, seasonType)).Result()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to get teams of player %q in %s on %s season %s from Redis: %w", player, league, seasonType, season, err))
+			return
+		}
+		if len(teams) == 0 {
+			respondError(w, http.StatusNotFound, fmt.Errorf("statistics for player %q in %s on %s season %s not found", player, league, seasonType, season))
+			return
+		}
+		slices.Sort(teams)
+
+		// the combined line goes first, followed by the stints
+		keys := []string{statisticsKey(league, "player", ids[0], season, seasonType)}
+		for _, team := range teams {
+			keys = append(keys, stintKey(league, ids[0], team, season, seasonType))
+		}
+
+		values, err := rdb.MGet(ctx, keys...).Result()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to MGET %q keys from Redis: %w", keys, err))
+			return
+		}
+
+		_, names, err := resolve(ctx, rdb, "team", teams...)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		var b breakdown
+		if value, ok := values[0].(string); ok {
+			b.Combined = json.RawMessage(value)
+		}
+		for i, team := range teams {
+			if value, ok := values[i+1].(string); ok {
+				b.Teams = append(b.Teams, stint{ID: team, Name: names[i], Statistics: json.RawMessage(value)})
+			}
+		}
+
+		respondJSON(w, b)
+	}
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"math"
+)
+
+// ratingPossessions is the number of possessions per-possession rates, and offensive, defensive and net ratings are normalized to
+const ratingPossessions = 100
+
+// playersOnCourt is the number of players of a team on the court at any time of a game
+const playersOnCourt = 5
+
+// gameTempo is the possessions of a team in a game, estimated by the events service from the attempts, offensive rebounds and turnovers of both teams,
+// with the minutes and the points of the game, completed by the pace and the ratings per 100 possessions
+type gameTempo struct {
+	GameDate        string  `json:"gameDate,omitempty"`
+	Opponent        string  `json:"opponent,omitempty"`
+	Possessions     float64 `json:"possessions"` // per game over a season
+	Minutes         float64 `json:"minutes"`     // per game over a season
+	PointsFor       int     `json:"pointsFor"`
+	PointsAgainst   int     `json:"pointsAgainst"`
+	Pace            float64 `json:"pace"`            // possessions per 48 minutes
+	OffensiveRating float64 `json:"offensiveRating"` // points of the team per 100 possessions
+	DefensiveRating float64 `json:"defensiveRating"` // points of the opponent per 100 possessions
+	NetRating       float64 `json:"netRating"`
+}
+
+// tempo is the tempo of a team in the games of a season, and over the season
+type tempo struct {
+	Games []gameTempo `json:"games"`
+	Total gameTempo   `json:"total"`
+}
+
+// teamStatistics are Statistics of a team together with its tempo, and its advanced metrics if requested
+type teamStatistics struct {
+	Statistics
+	Tempo    *tempo        `json:"tempo,omitempty"`
+	Advanced *teamAdvanced `json:"advanced,omitempty"`
+}
+
+// tempoKey returns the Redis key of the tempo of the team in the league for the season of the given type
+func tempoKey(league, team, season, seasonType string) string {
+	return fmt.Sprintf("%s:tempo", statisticsKey(league, "team", team, season, seasonType))
+}
+
+// getTempo returns the tempo of the team in the league for the season of the given type, or nil if it isn't cached yet
+func getTempo(ctx context.Context, rdb *redis.Client, league, team, season, seasonType string) (*tempo, error) {
+	key := tempoKey(league, team, season, seasonType)
+	val, err := rdb.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to GET %q key from Redis: %w", key, err)
+	}
+
+	var t tempo
+	if err := json.Unmarshal([]byte(val), &t); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %q key from Redis: %w", key, err)
+	}
+	return &t, nil
+}
+
+// sums returns the sums of the possessions and the minutes over the games
+func (t *tempo) sums() (possessions, minutes float64) {
+	if t == nil {
+		return 0, 0
+	}
+	for _, g := range t.Games {
+		possessions += g.Possessions
+		minutes += g.Minutes
+	}
+	return possessions, minutes
+}
+
+// perPossessions returns the points per 100 possessions, rounded to 1 decimal
+func perPossessions(points int, possessions float64) float64 {
+	if possessions <= 0 {
+		return 0
+	}
+	return math.Round(float64(points)*ratingPossessions/possessions*10) / 10
+}
+
+// rate returns the game tempo with its pace and ratings. Over a season, they are the ratios of the season sums.
+func (g gameTempo) rate(possessions, minutes float64) gameTempo {
+	if minutes > 0 {
+		g.Pace = math.Round(possessions*ratingMinutes/minutes*10) / 10
+	}
+	g.OffensiveRating, g.DefensiveRating = perPossessions(g.PointsFor, possessions), perPossessions(g.PointsAgainst, possessions)
+	g.NetRating = math.Round((g.OffensiveRating-g.DefensiveRating)*10) / 10
+	return g
+}
+
+// newTempo returns the tempo of the games rated, and of the season with the possessions and the minutes per game
+func newTempo(games []gameTempo) ([]gameTempo, gameTempo) {
+	var total gameTempo
+	var possessions, minutes float64
+	rated := make([]gameTempo, len(games))
+	for i, g := range games {
+		rated[i] = g.rate(g.Possessions, g.Minutes)
+		possessions += g.Possessions
+		minutes += g.Minutes
+		total.PointsFor += g.PointsFor
+		total.PointsAgainst += g.PointsAgainst
+	}
+	if len(games) == 0 {
+		return rated, total
+	}
+
+	total = total.rate(possessions, minutes)
+	total.Possessions = math.Round(possessions/float64(len(games))*10) / 10
+	total.Minutes = math.Round(minutes/float64(len(games))*10) / 10
+	return rated, total
+}
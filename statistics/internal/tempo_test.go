@@ -0,0 +1,34 @@
+package internal
+
+import "testing"
+
+func TestNewTempo(t *testing.T) {
+	games, total := newTempo([]gameTempo{
+		{GameDate: "2025-03-15", Opponent: "boston-celtics", Possessions: 100, Minutes: 48, PointsFor: 112, PointsAgainst: 104},
+		{GameDate: "2025-03-17", Opponent: "new-york-knicks", Possessions: 95, Minutes: 53, PointsFor: 101, PointsAgainst: 103},
+	})
+
+	expectedGames := []gameTempo{
+		{GameDate: "2025-03-15", Opponent: "boston-celtics", Possessions: 100, Minutes: 48, PointsFor: 112, PointsAgainst: 104,
+			Pace: 100, OffensiveRating: 112, DefensiveRating: 104, NetRating: 8},
+		// an overtime game is paced per 48 minutes
+		{GameDate: "2025-03-17", Opponent: "new-york-knicks", Possessions: 95, Minutes: 53, PointsFor: 101, PointsAgainst: 103,
+			Pace: 86, OffensiveRating: 106.3, DefensiveRating: 108.4, NetRating: -2.1},
+	}
+	for i, expected := range expectedGames {
+		if games[i] != expected {
+			t.Errorf("expected %+v, got %+v", expected, games[i])
+		}
+	}
+
+	// the ratings of the season are the ratios of the sums, not the averages of the ratings of the games
+	expectedTotal := gameTempo{Possessions: 97.5, Minutes: 50.5, PointsFor: 213, PointsAgainst: 207,
+		Pace: 92.7, OffensiveRating: 109.2, DefensiveRating: 106.2, NetRating: 3}
+	if total != expectedTotal {
+		t.Errorf("expected %+v, got %+v", expectedTotal, total)
+	}
+
+	if _, total := newTempo(nil); total != (gameTempo{}) {
+		t.Errorf("expected no tempo without games, got %+v", total)
+	}
+}